@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+func TestApprovalLocking(t *testing.T) {
+	s := newTestServer(t)
+
+	owner := s.signUp("owner")
+	member := s.signUp("member")
+
+	workspace := s.addWorkspace(owner)
+	s.addMember(owner, workspace, member, rbac.ROLE_MEMBER)
+
+	// 2022-W09 runs from Monday 2022-02-28 to Sunday 2022-03-06
+	taskID := s.do(member, http.MethodPost, workspace+"/task", gin.H{"description": "Design",
+		"start_time": "2022-03-01T09:00:00Z", "end_time": "2022-03-01T10:00:00Z"}, nil).expect(http.StatusOK).addedID()
+
+	approval := &models.Approval{}
+	s.do(member, http.MethodPost, workspace+"/approval", gin.H{"week": "2022-W09"}, nil).
+		expect(http.StatusOK).decode(approval)
+	if approval.Status != models.APPROVAL_PENDING {
+		t.Fatalf("got a submitted approval %s, want %s", approval.Status, models.APPROVAL_PENDING)
+	}
+
+	s.do(member, http.MethodPost, workspace+"/approval", gin.H{"week": "2022-W09"}, nil).expect(http.StatusConflict)
+	s.do(member, http.MethodPost, workspace+"/approvals/"+approval.ID+"/approve", nil, nil).
+		expect(http.StatusForbidden)
+
+	s.do(owner, http.MethodPost, workspace+"/approvals/"+approval.ID+"/approve", nil, nil).expect(http.StatusOK)
+	s.do(owner, http.MethodPost, workspace+"/approvals/"+approval.ID+"/approve", nil, nil).
+		expect(http.StatusConflict)
+
+	task := &models.Task{}
+	s.do(member, http.MethodGet, workspace+"/tasks/"+taskID, nil, nil).expect(http.StatusOK).decode(task)
+	if !task.IsLocked {
+		t.Fatal("got an unlocked time entry in an approved week")
+	}
+
+	// Neither the entries of an approved week nor the week itself can be changed
+	s.do(member, http.MethodPatch, workspace+"/tasks/"+taskID, gin.H{"description": "Build"}, ifMatch(task.Version)).
+		expect(http.StatusConflict)
+	s.do(member, http.MethodDelete, workspace+"/tasks/"+taskID, nil, ifMatch(task.Version)).
+		expect(http.StatusConflict)
+	s.do(member, http.MethodPost, workspace+"/task", gin.H{"start_time": "2022-03-02T09:00:00Z",
+		"end_time": "2022-03-02T10:00:00Z"}, nil).expect(http.StatusConflict)
+	s.do(member, http.MethodPut, workspace+"/timesheet?week=2022-W09", gin.H{"rows": []gin.H{
+		{"project_id": "", "hours": []float64{0, 2, 0, 0, 0, 0, 0}}}}, nil).expect(http.StatusConflict)
+
+	// The week after is open
+	s.do(member, http.MethodPost, workspace+"/task", gin.H{"start_time": "2022-03-07T09:00:00Z",
+		"end_time": "2022-03-07T10:00:00Z"}, nil).expect(http.StatusOK)
+
+	s.do(owner, http.MethodPost, workspace+"/approvals/"+approval.ID+"/unlock", nil, nil).expect(http.StatusOK)
+
+	s.do(member, http.MethodGet, workspace+"/tasks/"+taskID, nil, nil).expect(http.StatusOK).decode(task)
+	if task.IsLocked {
+		t.Fatal("got a locked time entry in an unlocked week")
+	}
+
+	s.do(member, http.MethodPatch, workspace+"/tasks/"+taskID, gin.H{"description": "Build"}, ifMatch(task.Version)).
+		expect(http.StatusOK)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/conf"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
 	"github.com/qasim-sajid/clockify-api/handler"
 	"github.com/qasim-sajid/clockify-api/models"
 )
@@ -27,7 +28,6 @@ type LoginResponse struct {
 	Name         string `json:"name"`
 	Username     string `json:"username"`
 	Email        string `json:"email"`
-	Error        string `json:"error"`
 }
 
 // LoginUser handles user login requests
@@ -35,7 +35,7 @@ func LoginUser(h *handler.Handler) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		resp, status, err := handleLogin(c, h)
 		if err != nil {
-			c.JSON(status, gin.H{"error": err.Error()})
+			handler.RespondError(c, status, err)
 			return
 		}
 
@@ -56,9 +56,9 @@ func handleLogin(c *gin.Context, h *handler.Handler) (response *LoginResponse, s
 		return response, http.StatusBadRequest, errors.New("credentials missing")
 	}
 
-	user, err := h.DB.CheckUserLogin(login.Identity, login.Password)
+	user, err := h.DB.CheckUserLogin(c.Request.Context(), login.Identity, login.Password)
 	if err != nil {
-		if err.Error() == "invalid credentials" {
+		if errors.Is(err, dbhandler.ErrInvalidCredentials) {
 			return response, http.StatusUnauthorized, err
 		}
 
@@ -101,63 +101,58 @@ func getUserToken(user *models.User, h *handler.Handler) (response *LoginRespons
 func RefreshUserTokenPOST(h *handler.Handler) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		refToken := c.GetHeader("X-Refresh-Token")
-		response := &LoginResponse{}
-
 		if refToken == "" {
-			response.Error = "Token Not Found."
-			c.JSON(http.StatusUnauthorized, response)
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("Token Not Found."))
 			return
 		}
 
 		token, err := jwt.Parse(refToken, func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				c.JSON(http.StatusUnauthorized, "Not Authorized")
 				return nil, errors.New("Not Authorized")
 			}
 			return []byte(conf.Configs.RefreshSigningKey), nil
 		})
 
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, "Not Authorized")
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("Not Authorized"))
 			return
 		}
 
 		claims := token.Claims.(jwt.MapClaims)
 		userID := fmt.Sprintf("%v", claims["user_id"])
 		if userID == "" {
-			c.JSON(http.StatusUnauthorized, "Not Authorized - No user ID")
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("Not Authorized - No user ID"))
 			return
 		}
 
 		if claims["exp"] == nil {
-			response.Error = "no expiry"
-			c.JSON(http.StatusBadRequest, response)
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("no expiry"))
 			return
 		}
 
 		expTime, ok := claims["exp"].(float64)
 		if !ok {
-			response.Error = "invalid expiry"
-			c.JSON(http.StatusBadRequest, response)
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("invalid expiry"))
 			return
 		}
 
 		if time.Now().Unix() > int64(expTime) {
-			response.Error = "token expired"
-			c.JSON(http.StatusConflict, response)
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("token expired"))
 			return
 		}
 
-		user, err := h.DB.GetUser(userID)
-		if err != nil {
-			response.Error = "user not found"
-			c.JSON(http.StatusUnauthorized, response)
+		user, err := h.DB.GetUser(c.Request.Context(), userID)
+		if errors.Is(err, dbhandler.ErrNotFound) {
+			handler.RespondError(c, http.StatusUnauthorized, errors.New("user not found"))
+			return
+		} else if err != nil {
+			handler.RespondError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		response, err = getUserToken(user, h)
+		response, err := getUserToken(user, h)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, err.Error())
+			handler.RespondError(c, http.StatusInternalServerError, err)
 			return
 		}
 
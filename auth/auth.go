@@ -12,6 +12,7 @@ import (
 	"github.com/qasim-sajid/clockify-api/conf"
 	"github.com/qasim-sajid/clockify-api/handler"
 	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
 )
 
 // IsUserAuthorized authorizes user account
@@ -19,26 +20,64 @@ func IsUserAuthorized(endpoint func(c *gin.Context, h *handler.Handler, origin *
 	return func(c *gin.Context) {
 		token, err := verifyUserToken(c.GetHeader("Authorization"))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			handler.RespondError(c, http.StatusUnauthorized, err)
 		} else {
 			user, err := parseClaims(token)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				handler.RespondError(c, http.StatusUnauthorized, err)
 			} else {
 				if token.Valid {
 					if c.Param("user_id") != "" && c.Param("user_id") != user.ID {
-						c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to make this change"})
+						handler.RespondError(c, http.StatusForbidden, errors.New("Not allowed to make this change"))
 					} else {
 						endpoint(c, h, user)
 					}
 				} else {
-					c.JSON(http.StatusUnauthorized, gin.H{"error": "Not Authorized"})
+					handler.RespondError(c, http.StatusUnauthorized, errors.New("Not Authorized"))
 				}
 			}
 		}
 	}
 }
 
+// IsWorkspaceMember authorizes user account and makes sure the user is a team member of the
+// workspace in the route, the member is then available to the endpoint through the context
+func IsWorkspaceMember(endpoint func(c *gin.Context, h *handler.Handler, origin *models.User), h *handler.Handler) gin.HandlerFunc {
+	return IsUserAuthorized(func(c *gin.Context, h *handler.Handler, origin *models.User) {
+		searchParams := make(map[string]interface{})
+		searchParams["workspace_id"] = c.Param("workspace_id")
+		searchParams["user_email"] = origin.Email
+
+		teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), searchParams)
+		if err != nil {
+			handler.RespondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		if len(teamMembers) == 0 {
+			handler.RespondError(c, http.StatusForbidden, errors.New("Not a member of this workspace"))
+			return
+		}
+
+		c.Set(handler.WORKSPACE_MEMBER_KEY, teamMembers[0])
+		endpoint(c, h, origin)
+	}, h)
+}
+
+// HasPermission authorizes user account as a team member of the workspace in the route and makes
+// sure the member's team role grants permission
+func HasPermission(permission rbac.Permission, endpoint func(c *gin.Context, h *handler.Handler, origin *models.User), h *handler.Handler) gin.HandlerFunc {
+	return IsWorkspaceMember(func(c *gin.Context, h *handler.Handler, origin *models.User) {
+		teamMember := c.MustGet(handler.WORKSPACE_MEMBER_KEY).(*models.TeamMember)
+		if !rbac.HasPermission(teamMember.TeamRole, permission) {
+			handler.RespondError(c, http.StatusForbidden, fmt.Errorf("Missing permission %s", permission))
+			return
+		}
+
+		endpoint(c, h, origin)
+	}, h)
+}
+
 func verifyUserToken(authToken string) (*jwt.Token, error) {
 	if authToken == "" {
 		return nil, fmt.Errorf("Credentials missing!")
@@ -69,6 +108,7 @@ func parseClaims(token *jwt.Token) (*models.User, error) {
 	user.Username = fmt.Sprintf("%v", claims["username"])
 	user.Email = fmt.Sprintf("%v", claims["email"])
 	user.Name = fmt.Sprintf("%v", claims["name"])
+	user.Timezone, _ = claims["timezone"].(string)
 
 	if claims["exp"] == nil {
 		return nil, fmt.Errorf("Token expiry not found!")
@@ -19,6 +19,7 @@ func GenerateJWT(user *models.User) (string, error) {
 	claims["username"] = user.Username
 	claims["email"] = user.Email
 	claims["name"] = user.Name
+	claims["timezone"] = user.Timezone
 	claims["exp"] = time.Now().Add(time.Minute * 30).Unix()
 
 	tokenString, err := token.SignedString([]byte(conf.Configs.SigningKey))
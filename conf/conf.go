@@ -3,12 +3,22 @@ package conf
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/subosito/gotenv"
 )
 
 const (
-	TIME_LAYOUT = "2006-01-02T15:05:05"
+	// DB_DRIVER_POSTGRES stores data in the configured postgres database
+	DB_DRIVER_POSTGRES = "postgres"
+	// DB_DRIVER_MEMORY keeps all data in process memory, it is lost on restart
+	DB_DRIVER_MEMORY = "memory"
+
+	// PASSWORD_HASH_BCRYPT hashes passwords with bcrypt, PASSWORD_HASH_COST is its cost
+	PASSWORD_HASH_BCRYPT = "bcrypt"
+	// PASSWORD_HASH_ARGON2ID hashes passwords with argon2id, PASSWORD_HASH_COST is its time parameter
+	PASSWORD_HASH_ARGON2ID = "argon2id"
 )
 
 func GetServerAddress() string {
@@ -25,6 +35,13 @@ type Configuration struct {
 	DBPassword        string
 	DBHost            string
 	DBPort            string
+	DBDriver          string
+	AutoMigrate       bool
+	QueryTimeout      time.Duration
+
+	PasswordHashAlgorithm string
+	PasswordHashCost      int
+	PasswordMinLength     int
 }
 
 var (
@@ -47,6 +64,13 @@ func InitConfigs() {
 		DBPassword:        os.Getenv("DB_PASSWORD"),
 		DBHost:            os.Getenv("DB_HOST"),
 		DBPort:            os.Getenv("DB_PORT"),
+		DBDriver:          getEnv("DB_DRIVER", DB_DRIVER_POSTGRES),
+		AutoMigrate:       getBoolEnv("AUTO_MIGRATE", true),
+		QueryTimeout:      getDurationEnv("DB_QUERY_TIMEOUT", 30*time.Second),
+
+		PasswordHashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", PASSWORD_HASH_BCRYPT),
+		PasswordHashCost:      getIntEnv("PASSWORD_HASH_COST", 0),
+		PasswordMinLength:     getIntEnv("PASSWORD_MIN_LENGTH", 8),
 	}
 
 	validate()
@@ -60,7 +84,27 @@ func validate() {
 		panic(fmt.Sprintf("%v %v", message, "SIGNING_KEY"))
 	} else if Configs.RefreshSigningKey == "" {
 		panic(fmt.Sprintf("%v %v", message, "REFRESH_SIGNING_KEY"))
-	} else if Configs.DBName == "" {
+	}
+
+	switch Configs.PasswordHashAlgorithm {
+	case PASSWORD_HASH_BCRYPT:
+		if Configs.PasswordHashCost != 0 && (Configs.PasswordHashCost < 4 || Configs.PasswordHashCost > 31) {
+			panic(fmt.Sprintf("Invalid env variable: PASSWORD_HASH_COST %v, bcrypt cost must be 4-31", Configs.PasswordHashCost))
+		}
+	case PASSWORD_HASH_ARGON2ID:
+	default:
+		panic(fmt.Sprintf("Invalid env variable: PASSWORD_HASH_ALGORITHM %v", Configs.PasswordHashAlgorithm))
+	}
+
+	switch Configs.DBDriver {
+	case DB_DRIVER_MEMORY:
+		return
+	case DB_DRIVER_POSTGRES:
+	default:
+		panic(fmt.Sprintf("Invalid env variable: DB_DRIVER %v", Configs.DBDriver))
+	}
+
+	if Configs.DBName == "" {
 		panic(fmt.Sprintf("%v %v", message, "DB_NAME"))
 	} else if Configs.DBUser == "" {
 		panic(fmt.Sprintf("%v %v", message, "DB_USER"))
@@ -72,3 +116,66 @@ func validate() {
 		panic(fmt.Sprintf("%v %v", message, "DB_PORT"))
 	}
 }
+
+// getEnv reads an env variable, falling back to def when it is not set
+func getEnv(key string, def string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	return v
+}
+
+// getBoolEnv reads a boolean env variable, falling back to def when it is not set
+func getBoolEnv(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid env variable: %v %v", key, err))
+	}
+
+	return b
+}
+
+// getIntEnv reads a non-negative integer env variable, falling back to def when it is not set
+func getIntEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid env variable: %v %v", key, err))
+	}
+
+	if i < 0 {
+		panic(fmt.Sprintf("Invalid env variable: %v must not be negative", key))
+	}
+
+	return i
+}
+
+// getDurationEnv reads a duration env variable such as "5s", falling back to def when it is not set
+func getDurationEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid env variable: %v %v", key, err))
+	}
+
+	if d < 0 {
+		panic(fmt.Sprintf("Invalid env variable: %v must not be negative", key))
+	}
+
+	return d
+}
@@ -0,0 +1,137 @@
+package dbhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *dbClient) AddApproval(ctx context.Context, approval *models.Approval) (*models.Approval, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	approval.ID = fmt.Sprintf("a_%v", id)
+	approval.Version = 1
+
+	insertQuery, args, err := db.GetInsertQuery(*approval)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddApproval: %w", err)
+	}
+
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddApproval: %w", err)
+	}
+
+	return approval, http.StatusOK, nil
+}
+
+func (db *dbClient) GetApproval(ctx context.Context, approvalID string) (*models.Approval, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = approvalID
+
+	approvals, err := db.GetApprovalsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetApproval: %w", err)
+	}
+
+	if len(approvals) <= 0 {
+		return nil, fmt.Errorf("GetApproval: %w", NotFound("approval with given id not found"))
+	}
+
+	return approvals[0], nil
+}
+
+func (db *dbClient) GetApprovalsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Approval, error) {
+	a := models.Approval{}
+
+	selectQuery, args, err := db.GetSelectQueryForStruct(a, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsWithFilters: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsWithFilters: %w", err)
+	}
+
+	approvals, err := db.GetApprovalsFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsWithFilters: %w", err)
+	}
+
+	return approvals, nil
+}
+
+func (db *dbClient) GetApprovalsForUpdate(ctx context.Context, searchParams map[string]interface{}) ([]*models.Approval, error) {
+	selectQuery, args, err := db.GetSelectQueryForStruct(models.Approval{}, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsForUpdate: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery+" FOR UPDATE", args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsForUpdate: %w", err)
+	}
+
+	approvals, err := db.GetApprovalsFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsForUpdate: %w", err)
+	}
+
+	return approvals, nil
+}
+
+func (db *dbClient) GetApprovalsFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Approval, error) {
+	defer rows.Close()
+
+	approvals := make([]*models.Approval, 0)
+	for rows.Next() {
+		a := models.Approval{}
+
+		var reviewedAt sql.NullTime
+		var reviewerID sql.NullString
+
+		err := rows.Scan(&a.ID, &a.Status, &a.PeriodStart, &a.PeriodEnd, &a.Comment, &a.SubmittedAt, &reviewedAt, &a.User,
+			&reviewerID, &a.Workspace, &a.Version)
+		if err != nil {
+			return nil, fmt.Errorf("GetApprovalsFromRows: %w", err)
+		}
+
+		if reviewedAt.Valid {
+			a.ReviewedAt = &reviewedAt.Time
+		}
+		a.Reviewer = reviewerID.String
+
+		approvals = append(approvals, &a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetApprovalsFromRows: %w", err)
+	}
+
+	return approvals, nil
+}
+
+func (db *dbClient) UpdateApproval(ctx context.Context, approvalID string, updates map[string]interface{}) (*models.Approval, error) {
+	if len(updates) > 0 {
+		err := db.updateStruct(ctx, models.Approval{}, approvalID, updates)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateApproval: %w", err)
+		}
+	}
+
+	approval, err := db.GetApproval(ctx, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateApproval: %w", err)
+	}
+
+	return approval, nil
+}
@@ -1,6 +1,7 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,48 +11,49 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddClient(client *models.Client) (*models.Client, int, error) {
+func (db *dbClient) AddClient(ctx context.Context, client *models.Client) (*models.Client, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
 	}
 	client.ID = fmt.Sprintf("c_%v", id)
+	client.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*client)
+	insertQuery, args, err := db.GetInsertQuery(*client)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddClient: %v", err)
+		return nil, -1, fmt.Errorf("AddClient: %w", err)
 	}
 
-	_, err = db.RunInsertQuery(insertQuery)
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddClient: %v", err)
+		return nil, -1, fmt.Errorf("AddClient: %w", err)
 	}
 
 	return client, http.StatusOK, nil
 }
 
-func (db *dbClient) GetAllClients() ([]*models.Client, error) {
-	clients, err := db.GetClientsWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllClients(ctx context.Context) ([]*models.Client, error) {
+	clients, err := db.GetClientsWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllProjects: %v", err)
+		return nil, fmt.Errorf("GetAllProjects: %w", err)
 	}
 
 	return clients, nil
 }
 
-func (db *dbClient) GetClient(clientID string) (*models.Client, error) {
+func (db *dbClient) GetClient(ctx context.Context, clientID string) (*models.Client, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = clientID
 
-	clients, err := db.GetClientsWithFilters(selectParams)
+	clients, err := db.GetClientsWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetClient: %v", err)
+		return nil, fmt.Errorf("GetClient: %w", err)
 	}
 
 	var client *models.Client
 	if clients == nil || len(clients) <= 0 {
-		return nil, fmt.Errorf("GetClient: %v", errors.New("client with given id not found"))
+		return nil, fmt.Errorf("GetClient: %w", NotFound("client with given id not found"))
 	} else {
 		client = clients[0]
 	}
@@ -59,78 +61,76 @@ func (db *dbClient) GetClient(clientID string) (*models.Client, error) {
 	return client, nil
 }
 
-func (db *dbClient) GetClientsWithFilters(searchParams map[string]interface{}) ([]*models.Client, error) {
+func (db *dbClient) GetClientsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Client, error) {
 	p := models.Client{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetClientsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetClientsWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetClientsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetClientsWithFilters: %w", err)
 	}
 
-	clients, err := db.GetClientsFromRows(rows)
+	clients, err := db.GetClientsFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetClientsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetClientsWithFilters: %w", err)
 	}
 
 	return clients, nil
 }
 
-func (db *dbClient) GetClientsFromRows(rows *sql.Rows) ([]*models.Client, error) {
+func (db *dbClient) GetClientsFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Client, error) {
+	defer rows.Close()
+
 	clients := make([]*models.Client, 0)
 	for rows.Next() {
 		c := models.Client{}
 
-		err := rows.Scan(&c.ID, &c.Name, &c.Address, &c.Note, &c.IsArchived)
+		var workspaceID sql.NullString
+
+		err := rows.Scan(&c.ID, &c.Name, &c.Address, &c.Note, &c.IsArchived, &workspaceID, &c.Version)
 
 		if err != nil {
-			return nil, fmt.Errorf("GetClientsFromRows: %v", err)
+			return nil, fmt.Errorf("GetClientsFromRows: %w", err)
+		}
+
+		if workspaceID.Valid {
+			c.Workspace = workspaceID.String
 		}
 
 		clients = append(clients, &c)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetClientsFromRows: %w", err)
+	}
+
 	return clients, nil
 }
 
-func (db *dbClient) UpdateClient(clientID string, updates map[string]interface{}) (*models.Client, error) {
-	updateQuery, err := db.GetUpdateQueryForStruct(models.Client{}, clientID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateClient: %v", err)
-	}
-
+func (db *dbClient) UpdateClient(ctx context.Context, clientID string, updates map[string]interface{}) (*models.Client, error) {
 	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
+		err := db.updateStruct(ctx, models.Client{}, clientID, updates)
 		if err != nil {
-			return nil, fmt.Errorf("UpdateClient: %v", err)
+			return nil, fmt.Errorf("UpdateClient: %w", err)
 		}
 	}
 
-	client, err := db.GetClient(clientID)
+	client, err := db.GetClient(ctx, clientID)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateClient: %v", err)
+		return nil, fmt.Errorf("UpdateClient: %w", err)
 	}
 
 	return client, nil
 }
 
-func (db *dbClient) DeleteClient(clientID string) error {
-	deleteParams := make(map[string]interface{})
-
-	deleteParams["_id"] = clientID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.Client{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteClient: %v", err)
-	}
-
-	_, err = db.RunDeleteQuery(deleteQuery)
+func (db *dbClient) DeleteClient(ctx context.Context, clientID string, version int) error {
+	err := db.deleteStruct(ctx, models.Client{}, clientID, version)
 	if err != nil {
-		return fmt.Errorf("DeleteClient: %v", err)
+		return fmt.Errorf("DeleteClient: %w", err)
 	}
 
 	return nil
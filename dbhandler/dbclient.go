@@ -1,6 +1,7 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,11 +11,17 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/qasim-sajid/clockify-api/conf"
 	"github.com/qasim-sajid/clockify-api/models"
-	"github.com/qasim-sajid/clockify-api/queries"
 )
 
 type dbClient struct {
 	dbName string
+	tx     *sql.Tx
+}
+
+// queryExecutor is satisfied by both *sql.DB and *sql.Tx
+type queryExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 // NewDBClient returns ref to a new dbClient object
@@ -35,12 +42,10 @@ func (db *dbClient) SetupDB() {
 	dbC, err := sql.Open("postgres", dbinfo)
 
 	if err != nil {
-		panic(fmt.Errorf("SetupDB: %v", err))
+		panic(fmt.Errorf("SetupDB: %w", err))
 	}
 
 	dbConnection = dbC
-
-	initializeTablesIfNotExist()
 }
 
 func (db *dbClient) CloseDB() {
@@ -49,288 +54,281 @@ func (db *dbClient) CloseDB() {
 	}
 }
 
-func initializeTablesIfNotExist() {
-	_, _ = dbConnection.Exec(queries.CREATE_TABLES)
+// executor returns the open transaction if there is one, the shared connection otherwise
+func (db *dbClient) executor() queryExecutor {
+	if db.tx != nil {
+		return db.tx
+	}
+
+	return dbConnection
 }
 
-func (db *dbClient) RunInsertQuery(query string) (sql.Result, error) {
-	result, err := dbConnection.Exec(query)
+func (db *dbClient) RunInsertQuery(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := db.executor().ExecContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, fmt.Errorf("RunInsertQuery: %v", err)
+		return nil, fmt.Errorf("RunInsertQuery: %w", queryError(err))
 	}
 
 	return result, nil
 }
 
-func (db *dbClient) RunSelectQuery(query string) (*sql.Rows, error) {
-	rows, err := dbConnection.Query(query)
+func (db *dbClient) RunSelectQuery(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := db.executor().QueryContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, fmt.Errorf("RunSelectQuery: %v", err)
+		return nil, fmt.Errorf("RunSelectQuery: %w", queryError(err))
 	}
 
 	return rows, nil
 }
 
-func (db *dbClient) RunUpdateQuery(query string) (sql.Result, error) {
-	result, err := dbConnection.Exec(query)
+func (db *dbClient) RunUpdateQuery(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := db.executor().ExecContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, fmt.Errorf("RunUpdateQuery: %v", err)
+		return nil, fmt.Errorf("RunUpdateQuery: %w", queryError(err))
 	}
 
 	return result, nil
 }
 
-func (db *dbClient) RunDeleteQuery(query string) (sql.Result, error) {
-	result, err := dbConnection.Exec(query)
+func (db *dbClient) RunDeleteQuery(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := db.executor().ExecContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, fmt.Errorf("RunDeleteQuery: %v", err)
+		return nil, fmt.Errorf("RunDeleteQuery: %w", queryError(err))
 	}
 
 	return result, nil
 }
 
-func (db *dbClient) GetInsertQuery(structType interface{}) (string, error) {
+func (db *dbClient) GetInsertQuery(structType interface{}) (string, []interface{}, error) {
 	tableName, err := db.GetTableNameForStruct(structType)
 	if err != nil {
-		return ``, fmt.Errorf("GetInsertQuery: %v", err)
+		return ``, nil, fmt.Errorf("GetInsertQuery: %w", err)
 	}
 
-	query := ``
+	var values []interface{}
 	switch reflect.TypeOf(structType).Name() {
+	case "Approval":
+		approval := structType.(models.Approval)
+		values = []interface{}{approval.ID, approval.Status, approval.PeriodStart, approval.PeriodEnd, approval.Comment,
+			approval.SubmittedAt, approval.ReviewedAt, approval.User, nullIfEmpty(approval.Reviewer), approval.Workspace}
 	case "Client":
 		client := structType.(models.Client)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s', '%s', '%s', %t)`,
-			tableName, db.GetColumnNamesForStruct(client), client.ID, client.Name, client.Address, client.Note, client.IsArchived)
+		values = []interface{}{client.ID, client.Name, client.Address, client.Note, client.IsArchived,
+			nullIfEmpty(client.Workspace)}
+	case "Invoice":
+		invoice := structType.(models.Invoice)
+		values = []interface{}{invoice.ID, invoice.Number, invoice.Status, invoice.Currency, invoice.IssueDate,
+			invoice.DueDate, invoice.PeriodStart, invoice.PeriodEnd, invoice.TaxPercentage, invoice.DiscountPercentage,
+			invoice.Note, invoice.Client, invoice.Workspace}
+	case "InvoiceItem":
+		item := structType.(models.InvoiceItem)
+		values = []interface{}{item.ID, item.Position, item.Description, item.Quantity, item.UnitPrice, item.Invoice,
+			nullIfEmpty(item.Project)}
+	case "InvoiceTemplate":
+		template := structType.(models.InvoiceTemplate)
+		values = []interface{}{template.ID, template.Title, template.AccentColor, template.Header, template.Footer,
+			template.Logo, template.Workspace}
+	case "Notification":
+		notification := structType.(models.Notification)
+		values = []interface{}{notification.ID, notification.Kind, notification.Threshold, notification.PeriodStart,
+			notification.Message, notification.CreatedAt, notification.IsRead, notification.User, notification.Workspace,
+			notification.Project}
 	case "Project":
 		project := structType.(models.Project)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s', '%s', %t, %f, %f, %f`,
-			tableName, db.GetColumnNamesForStruct(project), project.ID, project.Name, project.ColorTag, project.IsPublic,
-			project.TrackedHours, project.TrackedAmount, project.ProgressPercentage)
-		if project.Client != "" {
-			query = fmt.Sprintf(`%s, '%s'`, query, project.Client)
-		} else {
-			query = fmt.Sprintf(`%s, %v`, query, "null")
-		}
-		if project.Workspace != "" {
-			query = fmt.Sprintf(`%s, '%s')`, query, project.Workspace)
-		} else {
-			query = fmt.Sprintf(`%s, %v)`, query, "null")
-		}
+		values = []interface{}{project.ID, project.Name, project.ColorTag, project.IsPublic, project.BillableRate,
+			project.EstimateHours, project.BudgetAmount, project.BudgetPeriod, project.BudgetAlerts,
+			nullIfEmpty(project.Client), nullIfEmpty(project.Workspace)}
 	case "Tag":
 		tag := structType.(models.Tag)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s')`,
-			tableName, db.GetColumnNamesForStruct(tag), tag.ID, tag.Name)
+		values = []interface{}{tag.ID, tag.Name, nullIfEmpty(tag.Workspace)}
 	case "Task":
 		task := structType.(models.Task)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s', %t, '%s', '%s', '%s', %t`,
-			tableName, db.GetColumnNamesForStruct(task), task.ID, task.Description, task.Billable, task.StartTime.Format(conf.TIME_LAYOUT),
-			task.EndTime.Format(conf.TIME_LAYOUT), task.Date.Format(conf.TIME_LAYOUT), task.IsActive)
-		if task.Project != "" {
-			query = fmt.Sprintf(`%s, '%s')`, query, task.Project)
-		} else {
-			query = fmt.Sprintf(`%s, %v)`, query, "null")
-		}
+		values = []interface{}{task.ID, task.Description, task.Billable, task.StartTime,
+			task.EndTime, task.Date, task.IsActive, task.IsLocked, nullIfEmpty(task.Project),
+			nullIfEmpty(task.User), nullIfEmpty(task.Workspace), nullIfEmpty(task.InvoiceItem)}
 	case "TeamGroup":
 		teamGroup := structType.(models.TeamGroup)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s'`,
-			tableName, db.GetColumnNamesForStruct(teamGroup), teamGroup.ID, teamGroup.Name)
-		if teamGroup.Workspace != "" {
-			query = fmt.Sprintf(`%s, '%s')`, query, teamGroup.Workspace)
-		} else {
-			query = fmt.Sprintf(`%s, %v)`, query, "null")
-		}
+		values = []interface{}{teamGroup.ID, teamGroup.Name, nullIfEmpty(teamGroup.Workspace)}
 	case "TeamMember":
 		teamMember := structType.(models.TeamMember)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', %f`, tableName, db.GetColumnNamesForStruct(teamMember),
-			teamMember.ID, teamMember.BillableRate)
-		if teamMember.Workspace != "" {
-			query = fmt.Sprintf(`%s, '%s'`, query, teamMember.Workspace)
-		} else {
-			query = fmt.Sprintf(`%s, %v`, query, "null")
-		}
-		if teamMember.User != "" {
-			query = fmt.Sprintf(`%s, '%s'`, query, teamMember.User)
-		} else {
-			query = fmt.Sprintf(`%s, %v`, query, "null")
-		}
-		if teamMember.TeamRole != "" {
-			query = fmt.Sprintf(`%s, '%s')`, query, teamMember.TeamRole)
-		} else {
-			query = fmt.Sprintf(`%s, %v)`, query, "null")
-		}
+		values = []interface{}{teamMember.ID, teamMember.BillableRate, nullIfEmpty(teamMember.Workspace),
+			nullIfEmpty(teamMember.User), nullIfEmpty(teamMember.TeamRole)}
 	case "TeamRole":
 		teamRole := structType.(models.TeamRole)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s')`,
-			tableName, db.GetColumnNamesForStruct(teamRole), teamRole.ID, teamRole.Role)
+		values = []interface{}{teamRole.ID, teamRole.Role}
 	case "User":
 		user := structType.(models.User)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s', '%s', '%s', '%s')`,
-			tableName, db.GetColumnNamesForStruct(user), user.ID, user.Name, user.Email, user.Username, user.Password)
+		values = []interface{}{user.ID, user.Name, user.Email, user.Username, user.Password, user.Timezone}
 	case "Workspace":
 		workspace := structType.(models.Workspace)
-		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES ('%s', '%s')`,
-			tableName, db.GetColumnNamesForStruct(workspace), workspace.ID, workspace.Name)
+		values = []interface{}{workspace.ID, workspace.Name}
 	default:
-		return ``, fmt.Errorf("GetInsertQuery: %v",
+		return ``, nil, fmt.Errorf("GetInsertQuery: %v",
 			errors.New("insert query generation error"))
 	}
 
-	return query, nil
+	// version is the last column of every table
+	values = append(values, reflect.ValueOf(structType).FieldByName("Version").Interface())
+
+	query, args, err := newQueryBuilder(tableName, db.GetColumnsForStruct(structType)).insert(values)
+	if err != nil {
+		return ``, nil, fmt.Errorf("GetInsertQuery: %w", err)
+	}
+
+	return query, args, nil
 }
 
-func (db *dbClient) GetSelectQueryForStruct(structType interface{}, searchParams map[string]interface{}) (string, error) {
+func (db *dbClient) GetSelectQueryForStruct(structType interface{}, searchParams map[string]interface{}) (string, []interface{}, error) {
 	if reflect.ValueOf(structType).Kind() == reflect.Struct {
 		tableName, err := db.GetTableNameForStruct(structType)
 		if err != nil {
-			return ``, fmt.Errorf("GetSelectQueryForStruct: %v", err)
+			return ``, nil, fmt.Errorf("GetSelectQueryForStruct: %w", err)
 		}
 
-		query := fmt.Sprintf("SELECT * FROM %s", tableName)
-
-		i := 0
-		for k, v := range searchParams {
-			val := reflect.ValueOf(v)
-			kind := val.Kind()
-			if kind == reflect.Int || kind == reflect.Int64 {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = %d", query, k, val.Int())
-				} else {
-					query = fmt.Sprintf("%s && %s = %d", query, k, val.Int())
-				}
-			} else if kind == reflect.Float32 || kind == reflect.Float64 {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = %f", query, k, val.Float())
-				} else {
-					query = fmt.Sprintf("%s && %s = %f", query, k, val.Float())
-				}
-			} else if kind == reflect.Bool {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = %t", query, k, val.Bool())
-				} else {
-					query = fmt.Sprintf("%s && %s = %t", query, k, val.Bool())
-				}
-			} else {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = '%s'", query, k, val.String())
-				} else {
-					query = fmt.Sprintf("%s && %s = '%s'", query, k, val.String())
-				}
-			}
-
-			i++
+		query, args, err := newQueryBuilder(tableName, db.GetColumnsForStruct(structType)).selectWhere(searchParams)
+		if err != nil {
+			return ``, nil, fmt.Errorf("GetSelectQueryForStruct: %w", err)
 		}
 
-		return query, nil
+		return query, args, nil
 	}
 
-	return ``, fmt.Errorf("GetSelectQueryForStruct: %v", errors.New("select query generation error"))
+	return ``, nil, fmt.Errorf("GetSelectQueryForStruct: %v", errors.New("select query generation error"))
 }
 
-func (db *dbClient) GetUpdateQueryForStruct(structType interface{}, itemID string, updates map[string]interface{}) (string, error) {
+func (db *dbClient) GetUpdateQueryForStruct(structType interface{}, itemID string, updates map[string]interface{}) (string, []interface{}, error) {
 	if reflect.ValueOf(structType).Kind() == reflect.Struct {
 		tableName, err := db.GetTableNameForStruct(structType)
 		if err != nil {
-			return ``, fmt.Errorf("GetUpdateQueryForStruct: %v", err)
+			return ``, nil, fmt.Errorf("GetUpdateQueryForStruct: %w", err)
 		}
 
-		query := fmt.Sprintf("UPDATE %s", tableName)
+		if _, ok := updates["_id"]; ok {
+			return ``, nil, fmt.Errorf("GetUpdateQueryForStruct: %v", errors.New("_id can not be updated"))
+		}
 
-		i := 0
+		searchParams := map[string]interface{}{"_id": itemID}
+		columnUpdates := make(map[string]interface{}, len(updates))
 		for k, v := range updates {
-			kind := reflect.ValueOf(v).Kind()
-			val := reflect.ValueOf(v)
-			if kind == reflect.Int || kind == reflect.Int64 {
-				if i == 0 {
-					query = fmt.Sprintf("%s SET %s = %d", query, k, val.Int())
-				} else {
-					query = fmt.Sprintf("%s, %s = %d", query, k, val.Int())
-				}
-			} else if kind == reflect.Float32 || kind == reflect.Float64 {
-				if i == 0 {
-					query = fmt.Sprintf("%s SET %s = %f", query, k, val.Float())
-				} else {
-					query = fmt.Sprintf("%s, %s = %f", query, k, val.Float())
-				}
-			} else if kind == reflect.Bool {
-				if i == 0 {
-					query = fmt.Sprintf("%s SET %s = %t", query, k, val.Bool())
-				} else {
-					query = fmt.Sprintf("%s, %s = %t", query, k, val.Bool())
-				}
+			if k == VERSION_COLUMN {
+				searchParams[k] = v
 			} else {
-				if i == 0 {
-					query = fmt.Sprintf("%s SET %s = '%s'", query, k, val.String())
-				} else {
-					query = fmt.Sprintf("%s, %s = '%s'", query, k, val.String())
-				}
+				columnUpdates[k] = v
 			}
+		}
 
-			i++
+		query, args, err := newQueryBuilder(tableName, db.GetColumnsForStruct(structType)).updateWhere(columnUpdates,
+			searchParams)
+		if err != nil {
+			return ``, nil, fmt.Errorf("GetUpdateQueryForStruct: %w", err)
 		}
 
-		query = fmt.Sprintf("%s WHERE _id = '%s'", query, itemID)
-		return query, nil
+		return query, args, nil
 	}
 
-	return ``, fmt.Errorf("GetUpdateQueryForStruct: %v", errors.New("update query generation error"))
+	return ``, nil, fmt.Errorf("GetUpdateQueryForStruct: %v", errors.New("update query generation error"))
 }
 
-func (db *dbClient) GetDeleteQueryForStruct(structType interface{}, columnParams map[string]interface{}) (string, error) {
+// updateStruct updates the row of structType with itemID and moves its version on. A version in
+// updates is the one the row has to be at, the update fails with ErrPreconditionFailed when the
+// row was changed since.
+func (db *dbClient) updateStruct(ctx context.Context, structType interface{}, itemID string, updates map[string]interface{}) error {
+	updateQuery, args, err := db.GetUpdateQueryForStruct(structType, itemID, updates)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.RunUpdateQuery(ctx, updateQuery, args...)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := updates[VERSION_COLUMN]; ok {
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return errStaleVersion
+		}
+	}
+
+	return nil
+}
+
+// deleteStruct deletes the row of structType with itemID. A version other than 0 is the one the
+// row has to be at, the delete fails with ErrPreconditionFailed when the row was changed since.
+func (db *dbClient) deleteStruct(ctx context.Context, structType interface{}, itemID string, version int) error {
+	deleteParams := map[string]interface{}{"_id": itemID}
+	if version != 0 {
+		deleteParams[VERSION_COLUMN] = version
+	}
+
+	deleteQuery, args, err := db.GetDeleteQueryForStruct(structType, deleteParams)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.RunDeleteQuery(ctx, deleteQuery, args...)
+	if err != nil {
+		return err
+	}
+
+	if version != 0 {
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return errStaleVersion
+		}
+	}
+
+	return nil
+}
+
+func (db *dbClient) GetDeleteQueryForStruct(structType interface{}, columnParams map[string]interface{}) (string, []interface{}, error) {
 	if reflect.ValueOf(structType).Kind() == reflect.Struct {
 		tableName, err := db.GetTableNameForStruct(structType)
 		if err != nil {
-			return ``, fmt.Errorf("GetSelectQueryForStruct: %v", err)
+			return ``, nil, fmt.Errorf("GetDeleteQueryForStruct: %w", err)
 		}
 
-		query := fmt.Sprintf("DELETE FROM %s", tableName)
-
-		i := 0
-		for k, v := range columnParams {
-			kind := reflect.ValueOf(v).Kind()
-			val := reflect.ValueOf(v)
-			if kind == reflect.Int || kind == reflect.Int64 {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = %d", query, k, val.Int())
-				} else {
-					query = fmt.Sprintf("%s && %s = %d", query, k, val.Int())
-				}
-			} else if kind == reflect.Float32 || kind == reflect.Float64 {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = %f", query, k, val.Float())
-				} else {
-					query = fmt.Sprintf("%s && %s = %f", query, k, val.Float())
-				}
-			} else if kind == reflect.Bool {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = %t", query, k, val.Bool())
-				} else {
-					query = fmt.Sprintf("%s && %s = %t", query, k, val.Bool())
-				}
-			} else {
-				if i == 0 {
-					query = fmt.Sprintf("%s WHERE %s = '%s'", query, k, val.String())
-				} else {
-					query = fmt.Sprintf("%s && %s = '%s'", query, k, val.String())
-				}
-			}
-
-			i++
+		query, args, err := newQueryBuilder(tableName, db.GetColumnsForStruct(structType)).deleteWhere(columnParams)
+		if err != nil {
+			return ``, nil, fmt.Errorf("GetDeleteQueryForStruct: %w", err)
 		}
 
-		return query, nil
+		return query, args, nil
 	}
 
-	return ``, fmt.Errorf("GetSelectQueryForStruct: %v", errors.New("select query generation error"))
+	return ``, nil, fmt.Errorf("GetDeleteQueryForStruct: %v", errors.New("delete query generation error"))
 }
 
 func (db *dbClient) GetTableNameForStruct(t interface{}) (string, error) {
+	return getTableNameForStruct(t)
+}
+
+func getTableNameForStruct(t interface{}) (string, error) {
 	switch reflect.TypeOf(t).Name() {
+	case "Approval":
+		return "approval", nil
 	case "Client":
 		return "client", nil
+	case "Invoice":
+		return "invoice", nil
+	case "InvoiceItem":
+		return "invoice_item", nil
+	case "InvoiceTemplate":
+		return "invoice_template", nil
+	case "Notification":
+		return "notification", nil
 	case "Project":
 		return "project", nil
 	case "Tag":
@@ -353,43 +351,53 @@ func (db *dbClient) GetTableNameForStruct(t interface{}) (string, error) {
 }
 
 func (db *dbClient) GetColumnNamesForStruct(structType interface{}) string {
+	return strings.Join(db.GetColumnsForStruct(structType), ", ")
+}
+
+// GetColumnsForStruct returns column names of the struct's table, taken from its json tags
+func (db *dbClient) GetColumnsForStruct(structType interface{}) []string {
+	return getColumnsForStruct(structType)
+}
+
+func getColumnsForStruct(structType interface{}) []string {
 	s := reflect.TypeOf(structType)
-	columnNames := ""
+	columns := make([]string, 0, s.NumField())
 	for i := 0; i < s.NumField(); i++ {
-		r := s.Field(i)
-		if r.Type.Kind() == reflect.Pointer {
-			r = reflect.Indirect(reflect.ValueOf(structType)).Type().Field(i)
-		}
-		if r.Type.Kind() == reflect.Slice {
-			continue
-		}
-		if r.Type.Kind() == reflect.Array {
-			continue
+		if columnName := getColumnNameForField(s.Field(i)); columnName != "" {
+			columns = append(columns, columnName)
 		}
+	}
 
-		switch jsonTag := r.Tag.Get("json"); jsonTag {
-		case "-":
-			continue
-		case "":
-			continue
-		default:
-			parts := strings.Split(jsonTag, ",")
-			columnName := parts[0]
-			if columnName == "" {
-				continue
-			}
+	return columns
+}
 
-			if i == 0 {
-				columnNames = columnName
-			} else {
-				columnNames = fmt.Sprintf("%s, %s", columnNames, columnName)
-			}
-		}
+// getColumnNameForField returns the column a struct field is stored in, empty if it is not a column
+func getColumnNameForField(r reflect.StructField) string {
+	if r.Type.Kind() == reflect.Slice {
+		return ""
+	}
+	if r.Type.Kind() == reflect.Array {
+		return ""
+	}
+	if r.Tag.Get("db") == "-" {
+		return ""
 	}
 
-	return columnNames
+	switch jsonTag := r.Tag.Get("json"); jsonTag {
+	case "-":
+		return ""
+	case "":
+		return ""
+	default:
+		parts := strings.Split(jsonTag, ",")
+		return parts[0]
+	}
 }
 
+// VERSION_COLUMN is the column holding the version of a row, every update moves it on. In the
+// updates given to an Update method it is the version the row is expected to be at.
+const VERSION_COLUMN = "version"
+
 //Names for composite tables in database
 const (
 	PROJECT_TEAM_MEMBER    = "project_team_member"
@@ -398,190 +406,89 @@ const (
 	TASK_TAG               = "task_tag"
 )
 
-func (db *dbClient) GetInsertQueryForCompositeTable(tableName string, valuesMap map[string]interface{}) (string, error) {
-	query := ""
-	columnNames := ""
-	values := ""
-
-	i := 0
-	for k, v := range valuesMap {
-		if i > 0 {
-			columnNames = fmt.Sprintf("%s, ", columnNames)
-			values = fmt.Sprintf("%s, ", values)
-		}
-
-		columnNames = fmt.Sprintf("%s%s", columnNames, k)
-
-		kind := reflect.ValueOf(v).Kind()
-		if kind == reflect.Int || kind == reflect.Int64 {
-			values = fmt.Sprintf("%s%d", values, v)
-		} else if kind == reflect.Float32 || kind == reflect.Float64 {
-			values = fmt.Sprintf("%s%f", values, v)
-		} else if kind == reflect.Bool {
-			values = fmt.Sprintf("%s%t", values, v)
-		} else {
-			values = fmt.Sprintf("%s'%s'", values, v)
-		}
+// Columns of composite tables in database
+var compositeTableColumns = map[string][]string{
+	PROJECT_TEAM_MEMBER:    {"project_id", "team_member_id"},
+	PROJECT_TEAM_GROUP:     {"project_id", "team_group_id"},
+	TEAM_GROUP_TEAM_MEMBER: {"team_group_id", "team_member_id"},
+	TASK_TAG:               {"task_id", "tag_id"},
+}
 
-		i++
+func (db *dbClient) getQueryBuilderForCompositeTable(tableName string) (*queryBuilder, error) {
+	columns, ok := compositeTableColumns[tableName]
+	if !ok {
+		return nil, fmt.Errorf("unknown composite table %s", tableName)
 	}
 
-	if i <= 0 {
-		return "", errors.New("getInsertqueryforcompositetable: no values given")
+	return newQueryBuilder(tableName, columns), nil
+}
 
-	} else {
-		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", tableName, columnNames, values)
+func (db *dbClient) GetInsertQueryForCompositeTable(tableName string, valuesMap map[string]interface{}) (string, []interface{}, error) {
+	qb, err := db.getQueryBuilderForCompositeTable(tableName)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetInsertQueryForCompositeTable: %w", err)
 	}
 
-	return query, nil
-}
-
-func (db *dbClient) GetSelectQueryForCompositeTable(tableName string, searchParams map[string]interface{}) (string, error) {
-	query := fmt.Sprintf("SELECT * FROM %s", tableName)
+	if len(valuesMap) != len(qb.columns) {
+		return "", nil, fmt.Errorf("GetInsertQueryForCompositeTable: %v", errors.New("values missing for columns"))
+	}
 
-	i := 0
-	for k, v := range searchParams {
-		val := reflect.ValueOf(v)
-		kind := val.Kind()
-		if kind == reflect.Int || kind == reflect.Int64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %d", query, k, val.Int())
-			} else {
-				query = fmt.Sprintf("%s && %s = %d", query, k, val.Int())
-			}
-		} else if kind == reflect.Float32 || kind == reflect.Float64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %f", query, k, val.Float())
-			} else {
-				query = fmt.Sprintf("%s && %s = %f", query, k, val.Float())
-			}
-		} else if kind == reflect.Bool {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %t", query, k, val.Bool())
-			} else {
-				query = fmt.Sprintf("%s && %s = %t", query, k, val.Bool())
-			}
-		} else {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = '%s'", query, k, val.String())
-			} else {
-				query = fmt.Sprintf("%s && %s = '%s'", query, k, val.String())
-			}
+	values := make([]interface{}, 0, len(qb.columns))
+	for _, c := range qb.columns {
+		v, ok := valuesMap[c]
+		if !ok {
+			return "", nil, fmt.Errorf("GetInsertQueryForCompositeTable: value missing for column %s", c)
 		}
 
-		i++
+		values = append(values, v)
 	}
 
-	return query, nil
-}
+	query, args, err := qb.insert(values)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetInsertQueryForCompositeTable: %w", err)
+	}
 
-func (db *dbClient) GetUpdateQueryForCompositeTable(tableName string, searchParams map[string]interface{}, updates map[string]interface{}) (string, error) {
-	query := fmt.Sprintf("UPDATE %s", tableName)
+	return query, args, nil
+}
 
-	i := 0
-	for k, v := range updates {
-		kind := reflect.ValueOf(v).Kind()
-		val := reflect.ValueOf(v)
-		if kind == reflect.Int || kind == reflect.Int64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s SET %s = %d", query, k, val.Int())
-			} else {
-				query = fmt.Sprintf("%s, %s = %d", query, k, val.Int())
-			}
-		} else if kind == reflect.Float32 || kind == reflect.Float64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s SET %s = %f", query, k, val.Float())
-			} else {
-				query = fmt.Sprintf("%s, %s = %f", query, k, val.Float())
-			}
-		} else if kind == reflect.Bool {
-			if i == 0 {
-				query = fmt.Sprintf("%s SET %s = %t", query, k, val.Bool())
-			} else {
-				query = fmt.Sprintf("%s, %s = %t", query, k, val.Bool())
-			}
-		} else {
-			if i == 0 {
-				query = fmt.Sprintf("%s SET %s = '%s'", query, k, val.String())
-			} else {
-				query = fmt.Sprintf("%s, %s = '%s'", query, k, val.String())
-			}
-		}
+func (db *dbClient) GetSelectQueryForCompositeTable(tableName string, searchParams map[string]interface{}) (string, []interface{}, error) {
+	qb, err := db.getQueryBuilderForCompositeTable(tableName)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetSelectQueryForCompositeTable: %w", err)
+	}
 
-		i++
+	query, args, err := qb.selectWhere(searchParams)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetSelectQueryForCompositeTable: %w", err)
 	}
 
-	i = 0
-	for k, v := range searchParams {
-		val := reflect.ValueOf(v)
-		kind := val.Kind()
-		if kind == reflect.Int || kind == reflect.Int64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %d", query, k, val.Int())
-			} else {
-				query = fmt.Sprintf("%s && %s = %d", query, k, val.Int())
-			}
-		} else if kind == reflect.Float32 || kind == reflect.Float64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %f", query, k, val.Float())
-			} else {
-				query = fmt.Sprintf("%s && %s = %f", query, k, val.Float())
-			}
-		} else if kind == reflect.Bool {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %t", query, k, val.Bool())
-			} else {
-				query = fmt.Sprintf("%s && %s = %t", query, k, val.Bool())
-			}
-		} else {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = '%s'", query, k, val.String())
-			} else {
-				query = fmt.Sprintf("%s && %s = '%s'", query, k, val.String())
-			}
-		}
+	return query, args, nil
+}
 
-		i++
+func (db *dbClient) GetUpdateQueryForCompositeTable(tableName string, searchParams map[string]interface{}, updates map[string]interface{}) (string, []interface{}, error) {
+	qb, err := db.getQueryBuilderForCompositeTable(tableName)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetUpdateQueryForCompositeTable: %w", err)
 	}
 
-	return query, nil
-}
+	query, args, err := qb.updateWhere(updates, searchParams)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetUpdateQueryForCompositeTable: %w", err)
+	}
 
-func (db *dbClient) GetDeleteQueryForCompositeTable(tableName string, searchParams map[string]interface{}) (string, error) {
-	query := fmt.Sprintf("DELETE FROM %s", tableName)
+	return query, args, nil
+}
 
-	i := 0
-	for k, v := range searchParams {
-		kind := reflect.ValueOf(v).Kind()
-		val := reflect.ValueOf(v)
-		if kind == reflect.Int || kind == reflect.Int64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %d", query, k, val.Int())
-			} else {
-				query = fmt.Sprintf("%s && %s = %d", query, k, val.Int())
-			}
-		} else if kind == reflect.Float32 || kind == reflect.Float64 {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %f", query, k, val.Float())
-			} else {
-				query = fmt.Sprintf("%s && %s = %f", query, k, val.Float())
-			}
-		} else if kind == reflect.Bool {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = %t", query, k, val.Bool())
-			} else {
-				query = fmt.Sprintf("%s && %s = %t", query, k, val.Bool())
-			}
-		} else {
-			if i == 0 {
-				query = fmt.Sprintf("%s WHERE %s = '%s'", query, k, val.String())
-			} else {
-				query = fmt.Sprintf("%s && %s = '%s'", query, k, val.String())
-			}
-		}
+func (db *dbClient) GetDeleteQueryForCompositeTable(tableName string, searchParams map[string]interface{}) (string, []interface{}, error) {
+	qb, err := db.getQueryBuilderForCompositeTable(tableName)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetDeleteQueryForCompositeTable: %w", err)
+	}
 
-		i++
+	query, args, err := qb.deleteWhere(searchParams)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetDeleteQueryForCompositeTable: %w", err)
 	}
 
-	return query, nil
+	return query, args, nil
 }
@@ -1,77 +1,136 @@
 package dbhandler
 
 import (
+	"context"
+	"time"
+
+	"github.com/qasim-sajid/clockify-api/migrations"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-// DbHandler specifies DB context
+// DbHandler specifies DB context. Every call except SetupDB and CloseDB takes the caller's
+// context, queries still running when it is cancelled or times out are aborted. Every update moves
+// the version of the row on, an update given the version the row was read at under VERSION_COLUMN
+// fails with ErrPreconditionFailed when the row was changed since. The same goes for a delete
+// given a version other than 0, a version of 0 deletes the row whatever version it is at.
 type DbHandler interface {
 	SetupDB()
 	CloseDB()
 
-	AddClient(*models.Client) (*models.Client, int, error)
-	GetAllClients() ([]*models.Client, error)
-	GetClientsWithFilters(searchParams map[string]interface{}) ([]*models.Client, error)
-	GetClient(clientID string) (*models.Client, error)
-	UpdateClient(clientID string, updates map[string]interface{}) (*models.Client, error)
-	DeleteClient(clientID string) error
-
-	AddProject(*models.Project) (*models.Project, int, error)
-	GetAllProjects() ([]*models.Project, error)
-	GetProjectsWithFilters(searchParams map[string]interface{}) ([]*models.Project, error)
-	GetProject(projectID string) (*models.Project, error)
-	UpdateProject(projectID string, updates map[string]interface{}) (*models.Project, error)
-	DeleteProject(projectID string) error
-
-	AddTag(*models.Tag) (*models.Tag, int, error)
-	GetAllTags() ([]*models.Tag, error)
-	GetTagsWithFilters(searchParams map[string]interface{}) ([]*models.Tag, error)
-	GetTag(tagID string) (*models.Tag, error)
-	UpdateTag(tagID string, updates map[string]interface{}) (*models.Tag, error)
-	DeleteTag(tagID string) error
-
-	AddTask(*models.Task) (*models.Task, int, error)
-	GetAllTasks() ([]*models.Task, error)
-	GetTasksWithFilters(searchParams map[string]interface{}) ([]*models.Task, error)
-	GetTask(taskID string) (*models.Task, error)
-	UpdateTask(taskID string, updates map[string]interface{}) (*models.Task, error)
-	DeleteTask(taskID string) error
-
-	AddTeamGroup(*models.TeamGroup) (*models.TeamGroup, int, error)
-	GetAllTeamGroups() ([]*models.TeamGroup, error)
-	GetTeamGroupsWithFilters(searchParams map[string]interface{}) ([]*models.TeamGroup, error)
-	GetTeamGroup(teamGroupID string) (*models.TeamGroup, error)
-	UpdateTeamGroup(teamGroupID string, updates map[string]interface{}) (*models.TeamGroup, error)
-	DeleteTeamGroup(teamGroupID string) error
-
-	AddTeamMember(*models.TeamMember) (*models.TeamMember, int, error)
-	AddTeamMemberTeamGroups(string, []string) error
-	GetAllTeamMembers() ([]*models.TeamMember, error)
-	GetTeamMembersWithFilters(searchParams map[string]interface{}) ([]*models.TeamMember, error)
-	GetTeamMember(teamMemberID string) (*models.TeamMember, error)
-	UpdateTeamMember(teamMemberID string, updates map[string]interface{}) (*models.TeamMember, error)
-	DeleteTeamMember(teamMemberID string) error
-
-	AddTeamRole(*models.TeamRole) (*models.TeamRole, int, error)
-	GetAllTeamRoles() ([]*models.TeamRole, error)
-	GetTeamRolesWithFilters(searchParams map[string]interface{}) ([]*models.TeamRole, error)
-	GetTeamRole(teamRoleID string) (*models.TeamRole, error)
-	UpdateTeamRole(teamRoleID string, updates map[string]interface{}) (*models.TeamRole, error)
-	DeleteTeamRole(teamRoleID string) error
-
-	AddUser(*models.User) (*models.User, int, error)
-	GetAllUsers() ([]*models.User, error)
-	GetUsersWithFilters(searchParams map[string]interface{}) ([]*models.User, error)
-	GetUser(userID string) (*models.User, error)
-	GetUserWithIdentity(userID string) (*models.User, error)
-	UpdateUser(userID string, updates map[string]interface{}) (*models.User, error)
-	DeleteUser(userID string) error
-	CheckUserLogin(string, string) (*models.User, error)
-
-	AddWorkspace(*models.Workspace) (*models.Workspace, int, error)
-	GetAllWorkspaces() ([]*models.Workspace, error)
-	GetWorkspacesWithFilters(searchParams map[string]interface{}) ([]*models.Workspace, error)
-	GetWorkspace(workspaceID string) (*models.Workspace, error)
-	UpdateWorkspace(workspaceID string, updates map[string]interface{}) (*models.Workspace, error)
-	DeleteWorkspace(workspaceID string) error
+	// WithTx runs fn in a transaction, everything done through tx commits or rolls back as a unit
+	WithTx(ctx context.Context, fn func(tx DbHandler) error) error
+
+	MigrateUp(ctx context.Context) (int, error)
+	MigrateDown(ctx context.Context, steps int) (int, error)
+	GetMigrationStatus(ctx context.Context) ([]*migrations.Status, error)
+
+	// GetIDPage returns the ids of one page of the rows of the table of structType matching
+	// searchParams and the filter of options, in the order and from the position options gives
+	GetIDPage(ctx context.Context, structType interface{}, searchParams map[string]interface{}, options *models.ListOptions) (*models.IDPage, error)
+
+	AddClient(ctx context.Context, client *models.Client) (*models.Client, int, error)
+	GetAllClients(ctx context.Context) ([]*models.Client, error)
+	GetClientsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Client, error)
+	GetClient(ctx context.Context, clientID string) (*models.Client, error)
+	UpdateClient(ctx context.Context, clientID string, updates map[string]interface{}) (*models.Client, error)
+	DeleteClient(ctx context.Context, clientID string, version int) error
+
+	AddApproval(ctx context.Context, approval *models.Approval) (*models.Approval, int, error)
+	GetApprovalsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Approval, error)
+	// GetApprovalsForUpdate is GetApprovalsWithFilters locking the approvals until the transaction
+	// ends, they can not be reviewed by another transaction in the meantime
+	GetApprovalsForUpdate(ctx context.Context, searchParams map[string]interface{}) ([]*models.Approval, error)
+	GetApproval(ctx context.Context, approvalID string) (*models.Approval, error)
+	UpdateApproval(ctx context.Context, approvalID string, updates map[string]interface{}) (*models.Approval, error)
+
+	AddInvoice(ctx context.Context, invoice *models.Invoice) (*models.Invoice, int, error)
+	GetInvoicesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Invoice, error)
+	GetInvoice(ctx context.Context, invoiceID string) (*models.Invoice, error)
+	UpdateInvoice(ctx context.Context, invoiceID string, updates map[string]interface{}) (*models.Invoice, error)
+	DeleteInvoice(ctx context.Context, invoiceID string, version int) error
+	AddInvoiceItem(ctx context.Context, item *models.InvoiceItem) (*models.InvoiceItem, int, error)
+	GetInvoiceItemsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.InvoiceItem, error)
+	UpdateInvoiceItem(ctx context.Context, itemID string, updates map[string]interface{}) (*models.InvoiceItem, error)
+	DeleteInvoiceItem(ctx context.Context, itemID string, version int) error
+	AddInvoiceTemplate(ctx context.Context, template *models.InvoiceTemplate) (*models.InvoiceTemplate, int, error)
+	GetInvoiceTemplatesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.InvoiceTemplate, error)
+	UpdateInvoiceTemplate(ctx context.Context, templateID string, updates map[string]interface{}) (*models.InvoiceTemplate, error)
+
+	AddNotification(ctx context.Context, notification *models.Notification) (*models.Notification, int, error)
+	GetNotificationsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Notification, error)
+	GetNotification(ctx context.Context, notificationID string) (*models.Notification, error)
+	UpdateNotification(ctx context.Context, notificationID string, updates map[string]interface{}) (*models.Notification, error)
+	DeleteNotification(ctx context.Context, notificationID string) error
+
+	AddProject(ctx context.Context, project *models.Project) (*models.Project, int, error)
+	GetAllProjects(ctx context.Context) ([]*models.Project, error)
+	GetProjectsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Project, error)
+	GetProject(ctx context.Context, projectID string) (*models.Project, error)
+	UpdateProject(ctx context.Context, projectID string, updates map[string]interface{}) (*models.Project, error)
+	DeleteProject(ctx context.Context, projectID string, version int) error
+
+	GetSummaryReport(ctx context.Context, filter *models.ReportFilter, groupBy []string) (*models.SummaryReport, error)
+	GetDetailedReport(ctx context.Context, filter *models.ReportFilter, page, pageSize int) (*models.DetailedReport, error)
+	// EachDetailedEntry reads every entry of the detailed report in one query, oldest first, and
+	// hands them to fn. fn must not use the DbHandler, the query is still being read.
+	EachDetailedEntry(ctx context.Context, filter *models.ReportFilter, fn func(e *models.DetailedEntry) error) error
+
+	AddTag(ctx context.Context, tag *models.Tag) (*models.Tag, int, error)
+	GetAllTags(ctx context.Context) ([]*models.Tag, error)
+	GetTagsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Tag, error)
+	GetTag(ctx context.Context, tagID string) (*models.Tag, error)
+	UpdateTag(ctx context.Context, tagID string, updates map[string]interface{}) (*models.Tag, error)
+	DeleteTag(ctx context.Context, tagID string, version int) error
+
+	AddTask(ctx context.Context, task *models.Task) (*models.Task, int, error)
+	GetAllTasks(ctx context.Context) ([]*models.Task, error)
+	GetTasksWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Task, error)
+	GetTasksInRange(ctx context.Context, searchParams map[string]interface{}, start, end time.Time) ([]*models.Task, error)
+	GetTask(ctx context.Context, taskID string) (*models.Task, error)
+	// GetTaskForUpdate is GetTask locking the task until the transaction ends, it can not be
+	// invoiced or changed by another transaction in the meantime
+	GetTaskForUpdate(ctx context.Context, taskID string) (*models.Task, error)
+	UpdateTask(ctx context.Context, taskID string, updates map[string]interface{}) (*models.Task, error)
+	DeleteTask(ctx context.Context, taskID string, version int) error
+	// InvoiceTasks bills the tasks on the invoice item, it fails with ErrConflict when one of them
+	// was invoiced already
+	InvoiceTasks(ctx context.Context, invoiceItemID string, taskIDs []string) error
+
+	AddTeamGroup(ctx context.Context, teamGroup *models.TeamGroup) (*models.TeamGroup, int, error)
+	GetAllTeamGroups(ctx context.Context) ([]*models.TeamGroup, error)
+	GetTeamGroupsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamGroup, error)
+	GetTeamGroup(ctx context.Context, teamGroupID string) (*models.TeamGroup, error)
+	UpdateTeamGroup(ctx context.Context, teamGroupID string, updates map[string]interface{}) (*models.TeamGroup, error)
+	DeleteTeamGroup(ctx context.Context, teamGroupID string, version int) error
+
+	AddTeamMember(ctx context.Context, teamMember *models.TeamMember) (*models.TeamMember, int, error)
+	AddTeamMemberTeamGroups(ctx context.Context, teamMemberID string, teamGroups []string) error
+	GetAllTeamMembers(ctx context.Context) ([]*models.TeamMember, error)
+	GetTeamMembersWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamMember, error)
+	GetTeamMember(ctx context.Context, teamMemberID string) (*models.TeamMember, error)
+	UpdateTeamMember(ctx context.Context, teamMemberID string, updates map[string]interface{}) (*models.TeamMember, error)
+	DeleteTeamMember(ctx context.Context, teamMemberID string, version int) error
+
+	AddTeamRole(ctx context.Context, teamRole *models.TeamRole) (*models.TeamRole, int, error)
+	GetAllTeamRoles(ctx context.Context) ([]*models.TeamRole, error)
+	GetTeamRolesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamRole, error)
+	GetTeamRole(ctx context.Context, teamRoleID string) (*models.TeamRole, error)
+	UpdateTeamRole(ctx context.Context, teamRoleID string, updates map[string]interface{}) (*models.TeamRole, error)
+	DeleteTeamRole(ctx context.Context, teamRoleID string) error
+
+	AddUser(ctx context.Context, user *models.User) (*models.User, int, error)
+	GetAllUsers(ctx context.Context) ([]*models.User, error)
+	GetUsersWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.User, error)
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	GetUserWithIdentity(ctx context.Context, userID string) (*models.User, error)
+	UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) (*models.User, error)
+	DeleteUser(ctx context.Context, userID string, version int) error
+	CheckUserLogin(ctx context.Context, identity, password string) (*models.User, error)
+
+	AddWorkspace(ctx context.Context, workspace *models.Workspace) (*models.Workspace, int, error)
+	GetAllWorkspaces(ctx context.Context) ([]*models.Workspace, error)
+	GetWorkspacesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Workspace, error)
+	GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error)
+	UpdateWorkspace(ctx context.Context, workspaceID string, updates map[string]interface{}) (*models.Workspace, error)
+	DeleteWorkspace(ctx context.Context, workspaceID string, version int) error
 }
@@ -0,0 +1,96 @@
+package dbhandler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// The kinds of errors the db handler returns besides failures of the database itself. Errors of
+// a kind match it with errors.Is, e.g. errors.Is(err, ErrNotFound) for a task that does not exist.
+var (
+	// ErrNotFound is returned for rows that do not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned for changes that clash with stored rows, such as a duplicate email
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned for values the database refuses, such as an id of no row
+	ErrValidation = errors.New("validation failed")
+	// ErrForbidden is returned for changes the caller is not allowed to make
+	ErrForbidden = errors.New("forbidden")
+	// ErrPreconditionFailed is returned for changes to a row that was changed since it was read
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// ErrInvalidCredentials is returned by CheckUserLogin for an unknown user or a wrong password
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Error is an error of one of the kinds above, its message is meant for the client and details
+// can tell more, e.g. the fields that are wrong
+type Error struct {
+	Kind    error
+	Message string
+	Details interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is makes errors.Is match the kind of e
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// NotFound returns an ErrNotFound error with a formatted message
+func NotFound(format string, a ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, a...)}
+}
+
+// Conflict returns an ErrConflict error with a formatted message
+func Conflict(format string, a ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, a...)}
+}
+
+// Validation returns an ErrValidation error with a formatted message
+func Validation(format string, a ...interface{}) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, a...)}
+}
+
+// Forbidden returns an ErrForbidden error with a formatted message
+func Forbidden(format string, a ...interface{}) error {
+	return &Error{Kind: ErrForbidden, Message: fmt.Sprintf(format, a...)}
+}
+
+// PreconditionFailed returns an ErrPreconditionFailed error with a formatted message
+func PreconditionFailed(format string, a ...interface{}) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: fmt.Sprintf(format, a...)}
+}
+
+// errStaleVersion is returned for updates expecting a version the row is no longer at
+var errStaleVersion = PreconditionFailed("the resource was changed since it was read")
+
+// errTasksInvoiced is returned for tasks that were invoiced since they were read
+var errTasksInvoiced = Conflict("time entries were invoiced in the meantime")
+
+// pqErrorKinds are the kinds of the Postgres errors caused by the data of a query rather than the
+// database, by their SQLSTATE code
+var pqErrorKinds = map[pq.ErrorCode]error{
+	"23505": ErrConflict,   // unique_violation
+	"23503": ErrValidation, // foreign_key_violation
+	"23502": ErrValidation, // not_null_violation
+	"23514": ErrValidation, // check_violation
+	"22P02": ErrValidation, // invalid_text_representation
+}
+
+// queryError returns err of a query as an Error when Postgres refused the data of the query
+func queryError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if kind, ok := pqErrorKinds[pqErr.Code]; ok {
+			return &Error{Kind: kind, Message: pqErr.Message}
+		}
+	}
+
+	return err
+}
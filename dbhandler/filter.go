@@ -0,0 +1,227 @@
+package dbhandler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// filterRelation is a list of values kept in a composite table that rows can be filtered by with
+// models.FILTER_ANY
+type filterRelation struct {
+	tableName   string
+	ownerColumn string
+	valueColumn string
+}
+
+// filterRelations are the relations of each table, by the name they are filtered by
+var filterRelations = map[string]map[string]filterRelation{
+	"task": {"tags": {TASK_TAG, "task_id", "tag_id"}},
+}
+
+// sqlOperators are the comparisons of models.Filter written as SQL
+var sqlOperators = map[string]string{
+	models.FILTER_EQ:  "=",
+	models.FILTER_NE:  "IS DISTINCT FROM",
+	models.FILTER_GT:  ">",
+	models.FILTER_GTE: ">=",
+	models.FILTER_LT:  "<",
+	models.FILTER_LTE: "<=",
+}
+
+// filter returns the condition of filter, empty for a nil filter or an empty group
+func (qb *queryBuilder) filter(filter *models.Filter) (string, error) {
+	if filter == nil {
+		return "", nil
+	}
+
+	if filter.IsGroup() {
+		conditions := make([]string, 0, len(filter.Filters))
+		for _, f := range filter.Filters {
+			condition, err := qb.filter(f)
+			if err != nil {
+				return "", err
+			}
+
+			if condition != "" {
+				conditions = append(conditions, condition)
+			}
+		}
+
+		if len(conditions) == 0 {
+			return "", nil
+		}
+
+		join := " AND "
+		if filter.Or {
+			join = " OR "
+		}
+
+		return "(" + strings.Join(conditions, join) + ")", nil
+	}
+
+	if relation, ok := filterRelations[qb.tableName][filter.Column]; ok {
+		values, ok := filter.Value.([]string)
+		if filter.Operator != models.FILTER_ANY || !ok {
+			return "", fmt.Errorf("can not filter %s by %s", filter.Column, filter.Operator)
+		}
+
+		return fmt.Sprintf("_id IN (SELECT %s FROM public.%s WHERE %s = ANY(%s))", relation.ownerColumn,
+			relation.tableName, relation.valueColumn, qb.bind(pq.Array(values))), nil
+	}
+
+	if err := qb.checkColumn(filter.Column); err != nil {
+		return "", err
+	}
+
+	switch filter.Operator {
+	case models.FILTER_IN:
+		values, ok := filter.Value.([]string)
+		if !ok {
+			return "", fmt.Errorf("can not filter %s by %s", filter.Column, filter.Operator)
+		}
+
+		return fmt.Sprintf("%s = ANY(%s)", filter.Column, qb.bind(pq.Array(values))), nil
+	case models.FILTER_CONTAINS:
+		return fmt.Sprintf("strpos(lower(%s), lower(%s)) > 0", filter.Column, qb.bind(filter.Value)), nil
+	}
+
+	operator, ok := sqlOperators[filter.Operator]
+	if !ok {
+		return "", fmt.Errorf("can not filter %s by %s", filter.Column, filter.Operator)
+	}
+
+	return fmt.Sprintf("%s %s %s", filter.Column, operator, qb.bind(filter.Value)), nil
+}
+
+// filterMatches tells whether row of the table tableName matches filter the way the condition
+// written by queryBuilder.filter does
+func (s *memStore) filterMatches(tableName string, row interface{}, filter *models.Filter) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+
+	if filter.IsGroup() {
+		for _, f := range filter.Filters {
+			ok, err := s.filterMatches(tableName, row, f)
+			if err != nil {
+				return false, err
+			}
+
+			if ok == filter.Or {
+				return ok, nil
+			}
+		}
+
+		return !filter.Or || len(filter.Filters) == 0, nil
+	}
+
+	if relation, ok := filterRelations[tableName][filter.Column]; ok {
+		values, ok := filter.Value.([]string)
+		if filter.Operator != models.FILTER_ANY || !ok {
+			return false, fmt.Errorf("can not filter %s by %s", filter.Column, filter.Operator)
+		}
+
+		id, _ := getColumnValue(row, "_id")
+		linked, err := s.getCompositeValues(relation.tableName, map[string]interface{}{relation.ownerColumn: id},
+			relation.valueColumn)
+		if err != nil {
+			return false, err
+		}
+
+		for _, v := range linked {
+			if containsValue(values, v) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	stored, ok := getColumnValue(row, filter.Column)
+	if !ok {
+		return false, fmt.Errorf("unknown column %q for table %s", filter.Column, tableName)
+	}
+
+	if t, ok := stored.(*time.Time); ok {
+		stored = nil
+		if t != nil {
+			stored = *t
+		}
+	}
+
+	// NULL matches no comparison but ne
+	if stored == nil {
+		return filter.Operator == models.FILTER_NE, nil
+	}
+
+	switch filter.Operator {
+	case models.FILTER_IN:
+		values, ok := filter.Value.([]string)
+		if !ok {
+			return false, fmt.Errorf("can not filter %s by %s", filter.Column, filter.Operator)
+		}
+
+		return containsValue(values, fmt.Sprint(stored)), nil
+	case models.FILTER_CONTAINS:
+		return strings.Contains(strings.ToLower(fmt.Sprint(stored)), strings.ToLower(fmt.Sprint(filter.Value))), nil
+	}
+
+	c, err := compareFilterValues(stored, filter.Value)
+	if err != nil {
+		return false, err
+	}
+
+	switch filter.Operator {
+	case models.FILTER_EQ:
+		return c == 0, nil
+	case models.FILTER_NE:
+		return c != 0, nil
+	case models.FILTER_GT:
+		return c > 0, nil
+	case models.FILTER_GTE:
+		return c >= 0, nil
+	case models.FILTER_LT:
+		return c < 0, nil
+	case models.FILTER_LTE:
+		return c <= 0, nil
+	}
+
+	return false, fmt.Errorf("can not filter %s by %s", filter.Column, filter.Operator)
+}
+
+// compareFilterValues is compareValues comparing numbers as floats, so an integer column can be
+// compared with a fraction
+func compareFilterValues(stored, value interface{}) (int, error) {
+	a, b := reflect.ValueOf(stored), reflect.ValueOf(value)
+	if isNumberKind(a.Kind()) && isNumberKind(b.Kind()) {
+		x, y := a.Convert(reflect.TypeOf(float64(0))).Float(), b.Convert(reflect.TypeOf(float64(0))).Float()
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	return compareValues(stored, value)
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64 && k != reflect.Uintptr
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
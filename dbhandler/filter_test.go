@@ -0,0 +1,113 @@
+package dbhandler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func TestQueryBuilderFilter(t *testing.T) {
+	start := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"_id", "description", "billable", "start_time", "project_id"}
+
+	tests := []struct {
+		name      string
+		filter    *models.Filter
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{
+			name: "no filter",
+		},
+		{
+			name:      "eq",
+			filter:    &models.Filter{Column: "billable", Operator: models.FILTER_EQ, Value: true},
+			wantQuery: "billable = $1",
+			wantArgs:  []interface{}{true},
+		},
+		{
+			name:      "ne matches NULL",
+			filter:    &models.Filter{Column: "project_id", Operator: models.FILTER_NE, Value: "p_1"},
+			wantQuery: "project_id IS DISTINCT FROM $1",
+			wantArgs:  []interface{}{"p_1"},
+		},
+		{
+			name:      "gte",
+			filter:    &models.Filter{Column: "start_time", Operator: models.FILTER_GTE, Value: start},
+			wantQuery: "start_time >= $1",
+			wantArgs:  []interface{}{start},
+		},
+		{
+			name:      "in",
+			filter:    &models.Filter{Column: "project_id", Operator: models.FILTER_IN, Value: []string{"p_1", "p_2"}},
+			wantQuery: "project_id = ANY($1)",
+			wantArgs:  []interface{}{pq.Array([]string{"p_1", "p_2"})},
+		},
+		{
+			name:      "contains",
+			filter:    &models.Filter{Column: "description", Operator: models.FILTER_CONTAINS, Value: "Rev"},
+			wantQuery: "strpos(lower(description), lower($1)) > 0",
+			wantArgs:  []interface{}{"Rev"},
+		},
+		{
+			name:      "any of a relation",
+			filter:    &models.Filter{Column: "tags", Operator: models.FILTER_ANY, Value: []string{"tg_1"}},
+			wantQuery: "_id IN (SELECT task_id FROM public.task_tag WHERE tag_id = ANY($1))",
+			wantArgs:  []interface{}{pq.Array([]string{"tg_1"})},
+		},
+		{
+			name: "groups",
+			filter: &models.Filter{Filters: []*models.Filter{
+				{Column: "billable", Operator: models.FILTER_EQ, Value: true},
+				{Or: true, Filters: []*models.Filter{
+					{Column: "description", Operator: models.FILTER_CONTAINS, Value: "a"},
+					{Column: "start_time", Operator: models.FILTER_LT, Value: start},
+				}},
+			}},
+			wantQuery: "(billable = $1 AND (strpos(lower(description), lower($2)) > 0 OR start_time < $3))",
+			wantArgs:  []interface{}{true, "a", start},
+		},
+		{
+			name:   "empty group",
+			filter: &models.Filter{Or: true},
+		},
+		{
+			name:    "unknown column",
+			filter:  &models.Filter{Column: "color", Operator: models.FILTER_EQ, Value: "red"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown operator",
+			filter:  &models.Filter{Column: "description", Operator: "like", Value: "a%"},
+			wantErr: true,
+		},
+		{
+			name:    "in without a list",
+			filter:  &models.Filter{Column: "project_id", Operator: models.FILTER_IN, Value: "p_1"},
+			wantErr: true,
+		},
+		{
+			name:    "relation compared with eq",
+			filter:  &models.Filter{Column: "tags", Operator: models.FILTER_EQ, Value: "tg_1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := newQueryBuilder("task", columns)
+			query, err := qb.filter(tt.filter)
+			checkQuery(t, query, qb.args, err, tt.wantQuery, tt.wantArgs, tt.wantErr)
+		})
+	}
+}
+
+func TestQueryBuilderCount(t *testing.T) {
+	query, args, err := newQueryBuilder("tag", testColumns).count(map[string]interface{}{"workspace_id": "w_1"},
+		&models.Filter{Column: "rate", Operator: models.FILTER_GT, Value: 2.0})
+	checkQuery(t, query, args, err, "SELECT COUNT(*) FROM tag WHERE workspace_id = $1 AND rate > $2",
+		[]interface{}{"w_1", 2.0}, false)
+}
@@ -0,0 +1,336 @@
+package dbhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// invoiceNumberLockQuery serializes numbering the invoices of a workspace until the transaction
+// ends, so two invoices never get the same number
+const invoiceNumberLockQuery = `SELECT pg_advisory_xact_lock(hashtext('invoice:' || $1))`
+
+const nextInvoiceNumberQuery = `SELECT COALESCE(MAX(number), 0) + 1 FROM public.invoice WHERE workspace_id = $1`
+
+// AddInvoice adds invoice with its items and gives it the next number of its workspace
+func (db *dbClient) AddInvoice(ctx context.Context, invoice *models.Invoice) (*models.Invoice, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	invoice.ID = fmt.Sprintf("i_%v", id)
+	invoice.Version = 1
+
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		for _, query := range []string{invoiceNumberLockQuery, nextInvoiceNumberQuery} {
+			rows, err := tx.RunSelectQuery(ctx, query, invoice.Workspace)
+			if err != nil {
+				return err
+			}
+
+			if query == nextInvoiceNumberQuery && rows.Next() {
+				err = rows.Scan(&invoice.Number)
+			}
+			rows.Close()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		insertQuery, args, err := tx.GetInsertQuery(*invoice)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.RunInsertQuery(ctx, insertQuery, args...)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range invoice.Items {
+			item.Invoice = invoice.ID
+			item.Position = i + 1
+			_, _, err = tx.AddInvoiceItem(ctx, item)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoice: %w", err)
+	}
+
+	invoice.SetTotals()
+	return invoice, http.StatusOK, nil
+}
+
+func (db *dbClient) GetInvoice(ctx context.Context, invoiceID string) (*models.Invoice, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = invoiceID
+
+	invoices, err := db.GetInvoicesWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoice: %w", err)
+	}
+
+	if len(invoices) <= 0 {
+		return nil, fmt.Errorf("GetInvoice: %w", NotFound("invoice with given id not found"))
+	}
+
+	return invoices[0], nil
+}
+
+func (db *dbClient) GetInvoicesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Invoice, error) {
+	i := models.Invoice{}
+
+	selectQuery, args, err := db.GetSelectQueryForStruct(i, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoicesWithFilters: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoicesWithFilters: %w", err)
+	}
+
+	invoices, err := db.GetInvoicesFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoicesWithFilters: %w", err)
+	}
+
+	return invoices, nil
+}
+
+func (db *dbClient) GetInvoicesFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Invoice, error) {
+	defer rows.Close()
+
+	invoices := make([]*models.Invoice, 0)
+	for rows.Next() {
+		i := models.Invoice{}
+
+		var dueDate sql.NullTime
+
+		err := rows.Scan(&i.ID, &i.Number, &i.Status, &i.Currency, &i.IssueDate, &dueDate, &i.PeriodStart, &i.PeriodEnd,
+			&i.TaxPercentage, &i.DiscountPercentage, &i.Note, &i.Client, &i.Workspace, &i.Version)
+		if err != nil {
+			return nil, fmt.Errorf("GetInvoicesFromRows: %w", err)
+		}
+
+		if dueDate.Valid {
+			i.DueDate = &dueDate.Time
+		}
+
+		invoices = append(invoices, &i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetInvoicesFromRows: %w", err)
+	}
+
+	//Relations are loaded after rows are drained as a transaction runs one query at a time
+	var err error
+	for _, i := range invoices {
+		i.Items, err = db.GetInvoiceItemsWithFilters(ctx, map[string]interface{}{"invoice_id": i.ID})
+		if err != nil {
+			return nil, fmt.Errorf("GetInvoicesFromRows: %w", err)
+		}
+
+		i.SetTotals()
+	}
+
+	return invoices, nil
+}
+
+func (db *dbClient) UpdateInvoice(ctx context.Context, invoiceID string, updates map[string]interface{}) (*models.Invoice, error) {
+	if len(updates) > 0 {
+		err := db.updateStruct(ctx, models.Invoice{}, invoiceID, updates)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateInvoice: %w", err)
+		}
+	}
+
+	invoice, err := db.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateInvoice: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// DeleteInvoice deletes invoice with its items, the time entries billed on them become billable
+// again
+func (db *dbClient) DeleteInvoice(ctx context.Context, invoiceID string, version int) error {
+	err := db.deleteStruct(ctx, models.Invoice{}, invoiceID, version)
+	if err != nil {
+		return fmt.Errorf("DeleteInvoice: %w", err)
+	}
+
+	return nil
+}
+
+func (db *dbClient) AddInvoiceItem(ctx context.Context, item *models.InvoiceItem) (*models.InvoiceItem, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	item.ID = fmt.Sprintf("ii_%v", id)
+	item.Version = 1
+
+	insertQuery, args, err := db.GetInsertQuery(*item)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoiceItem: %w", err)
+	}
+
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoiceItem: %w", err)
+	}
+
+	return item, http.StatusOK, nil
+}
+
+func (db *dbClient) GetInvoiceItemsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.InvoiceItem, error) {
+	selectQuery, args, err := db.GetSelectQueryForStruct(models.InvoiceItem{}, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoiceItemsWithFilters: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery+" ORDER BY position, _id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoiceItemsWithFilters: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*models.InvoiceItem, 0)
+	for rows.Next() {
+		item := models.InvoiceItem{}
+
+		var projectID sql.NullString
+
+		err := rows.Scan(&item.ID, &item.Position, &item.Description, &item.Quantity, &item.UnitPrice, &item.Invoice,
+			&projectID, &item.Version)
+		if err != nil {
+			return nil, fmt.Errorf("GetInvoiceItemsWithFilters: %w", err)
+		}
+
+		item.Project = projectID.String
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetInvoiceItemsWithFilters: %w", err)
+	}
+
+	return items, nil
+}
+
+func (db *dbClient) UpdateInvoiceItem(ctx context.Context, itemID string, updates map[string]interface{}) (*models.InvoiceItem, error) {
+	if len(updates) > 0 {
+		err := db.updateStruct(ctx, models.InvoiceItem{}, itemID, updates)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateInvoiceItem: %w", err)
+		}
+	}
+
+	items, err := db.GetInvoiceItemsWithFilters(ctx, map[string]interface{}{"_id": itemID})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateInvoiceItem: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("UpdateInvoiceItem: %w", NotFound("invoice item with given id not found"))
+	}
+
+	return items[0], nil
+}
+
+// DeleteInvoiceItem deletes item, the time entries billed on it become billable again
+func (db *dbClient) DeleteInvoiceItem(ctx context.Context, itemID string, version int) error {
+	err := db.deleteStruct(ctx, models.InvoiceItem{}, itemID, version)
+	if err != nil {
+		return fmt.Errorf("DeleteInvoiceItem: %w", err)
+	}
+
+	return nil
+}
+
+func (db *dbClient) AddInvoiceTemplate(ctx context.Context, template *models.InvoiceTemplate) (*models.InvoiceTemplate, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	template.ID = fmt.Sprintf("it_%v", id)
+	template.Version = 1
+
+	insertQuery, args, err := db.GetInsertQuery(*template)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoiceTemplate: %w", err)
+	}
+
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoiceTemplate: %w", err)
+	}
+
+	return template, http.StatusOK, nil
+}
+
+func (db *dbClient) GetInvoiceTemplatesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.InvoiceTemplate, error) {
+	selectQuery, args, err := db.GetSelectQueryForStruct(models.InvoiceTemplate{}, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoiceTemplatesWithFilters: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoiceTemplatesWithFilters: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make([]*models.InvoiceTemplate, 0)
+	for rows.Next() {
+		t := models.InvoiceTemplate{}
+
+		err := rows.Scan(&t.ID, &t.Title, &t.AccentColor, &t.Header, &t.Footer, &t.Logo, &t.Workspace, &t.Version)
+		if err != nil {
+			return nil, fmt.Errorf("GetInvoiceTemplatesWithFilters: %w", err)
+		}
+
+		templates = append(templates, &t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetInvoiceTemplatesWithFilters: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (db *dbClient) UpdateInvoiceTemplate(ctx context.Context, templateID string, updates map[string]interface{}) (*models.InvoiceTemplate, error) {
+	if len(updates) > 0 {
+		err := db.updateStruct(ctx, models.InvoiceTemplate{}, templateID, updates)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateInvoiceTemplate: %w", err)
+		}
+	}
+
+	templates, err := db.GetInvoiceTemplatesWithFilters(ctx, map[string]interface{}{"_id": templateID})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateInvoiceTemplate: %w", err)
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("UpdateInvoiceTemplate: %w", NotFound("invoice template with given id not found"))
+	}
+
+	return templates[0], nil
+}
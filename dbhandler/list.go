@@ -0,0 +1,92 @@
+package dbhandler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// GetIDPage returns the ids of the page of the table of structType described by options, of the
+// rows matching searchParams and options.Filter
+func (db *dbClient) GetIDPage(ctx context.Context, structType interface{}, searchParams map[string]interface{}, options *models.ListOptions) (*models.IDPage, error) {
+	tableName, err := db.GetTableNameForStruct(structType)
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+	columns := db.GetColumnsForStruct(structType)
+
+	page := &models.IDPage{IDs: make([]string, 0, options.Limit)}
+
+	countQuery, args, err := newQueryBuilder(tableName, columns).count(searchParams, options.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, countQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	if rows.Next() {
+		err = rows.Scan(&page.Total)
+	}
+	rows.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	selectQuery, args, err := newQueryBuilder(tableName, columns).selectPage(searchParams, options)
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	rows, err = db.RunSelectQuery(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+	defer rows.Close()
+
+	var last []interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(options.Sort)+1)
+		pointers := make([]interface{}, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return nil, fmt.Errorf("GetIDPage: %w", err)
+		}
+
+		if len(page.IDs) == options.Limit {
+			page.Next = pageKey(last)
+			break
+		}
+
+		page.IDs = append(page.IDs, fmt.Sprint(values[0]))
+		last = values
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	return page, nil
+}
+
+// pageKey turns the _id and sort values of a row into the sort values followed by the _id, numeric
+// columns are read as text
+func pageKey(values []interface{}) []interface{} {
+	key := make([]interface{}, 0, len(values))
+	for _, v := range values[1:] {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		key = append(key, v)
+	}
+
+	return append(key, values[0])
+}
@@ -0,0 +1,824 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qasim-sajid/clockify-api/migrations"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+// memClient is a DbHandler that keeps all data in memory. It follows the same table layout
+// and constraints as the postgres schema so the API behaves the same without a database.
+type memClient struct {
+	dbName string
+	mu     *sync.RWMutex
+	store  *memStore
+	inTx   bool
+}
+
+// NewMemClient returns ref to a new in-memory DbHandler
+func NewMemClient(dbName string) (h DbHandler, err error) {
+	client := &memClient{
+		dbName: dbName,
+		mu:     &sync.RWMutex{},
+		store:  newMemStore(),
+	}
+
+	return client, nil
+}
+
+func (db *memClient) SetupDB() {}
+
+func (db *memClient) CloseDB() {}
+
+// WithTx holds the store lock for the whole of fn and restores the previous state if fn fails
+// or ctx is done before fn returns
+func (db *memClient) WithTx(ctx context.Context, fn func(tx DbHandler) error) (err error) {
+	if db.inTx {
+		return fn(db)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("WithTx: %w", err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	snapshot := db.store.clone()
+	defer func() {
+		if p := recover(); p != nil {
+			*db.store = *snapshot
+			panic(p)
+		}
+	}()
+
+	err = fn(&memClient{dbName: db.dbName, mu: db.mu, store: db.store, inTx: true})
+	if err != nil {
+		*db.store = *snapshot
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		*db.store = *snapshot
+		return fmt.Errorf("WithTx: %w", err)
+	}
+
+	return nil
+}
+
+// The in-memory schema is always current, so migrations are reported as applied
+func (db *memClient) MigrateUp(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (db *memClient) MigrateDown(ctx context.Context, steps int) (int, error) {
+	return 0, fmt.Errorf("MigrateDown: %v", errors.New("migrations are not supported by the memory driver"))
+}
+
+func (db *memClient) GetMigrationStatus(ctx context.Context) ([]*migrations.Status, error) {
+	statuses := make([]*migrations.Status, 0)
+	for _, m := range migrations.All() {
+		appliedAt := db.store.createdAt
+		statuses = append(statuses, &migrations.Status{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// read runs fn against the store unless ctx is already done, the same way a cancelled
+// query never reaches postgres
+func (db *memClient) read(ctx context.Context, fn func(s *memStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !db.inTx {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+
+	return fn(db.store)
+}
+
+// write applies fn atomically, a failing fn leaves the store untouched
+func (db *memClient) write(ctx context.Context, fn func(s *memStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if db.inTx {
+		return fn(db.store)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	snapshot := db.store.clone()
+	err := fn(db.store)
+	if err != nil {
+		*db.store = *snapshot
+		return err
+	}
+
+	return nil
+}
+
+// memTable holds the rows of one table in insertion order. Rows are struct values for
+// entity tables and map[string]interface{} for composite tables.
+type memTable struct {
+	name    string
+	columns []string
+	keys    []string
+	rows    map[string]interface{}
+}
+
+type memStore struct {
+	tables    map[string]*memTable
+	createdAt time.Time
+}
+
+// memForeignKey mirrors a foreign key constraint of the postgres schema
+type memForeignKey struct {
+	table     string
+	column    string
+	refTable  string
+	refColumn string
+	cascade   bool
+}
+
+const memUserTable = `"user"`
+
+// memEntityTypes lists the structs that get a table in the store
+var memEntityTypes = []interface{}{
+	models.Approval{},
+	models.Client{},
+	models.Invoice{},
+	models.InvoiceItem{},
+	models.InvoiceTemplate{},
+	models.Notification{},
+	models.Project{},
+	models.Tag{},
+	models.Task{},
+	models.TeamGroup{},
+	models.TeamMember{},
+	models.TeamRole{},
+	models.User{},
+	models.Workspace{},
+}
+
+var memForeignKeys = []memForeignKey{
+	{"team_member", "team_role_id", "team_role", "_id", false},
+	{"team_member", "user_email", memUserTable, "email", false},
+	{"team_member", "workspace_id", "workspace", "_id", false},
+	{"team_group", "workspace_id", "workspace", "_id", false},
+	{"client", "workspace_id", "workspace", "_id", false},
+	{"tag", "workspace_id", "workspace", "_id", false},
+	{"project", "client_id", "client", "_id", false},
+	{"project", "workspace_id", "workspace", "_id", false},
+	{"task", "project_id", "project", "_id", false},
+	{"task", "user_id", memUserTable, "_id", false},
+	{"task", "workspace_id", "workspace", "_id", false},
+	{"invoice", "client_id", "client", "_id", false},
+	{"invoice", "workspace_id", "workspace", "_id", true},
+	{"invoice_item", "invoice_id", "invoice", "_id", true},
+	{"invoice_item", "project_id", "project", "_id", false},
+	{"task", "invoice_item_id", "invoice_item", "_id", false},
+	{"invoice_template", "workspace_id", "workspace", "_id", true},
+	{"notification", "user_id", memUserTable, "_id", true},
+	{"notification", "workspace_id", "workspace", "_id", true},
+	{"notification", "project_id", "project", "_id", true},
+	{"approval", "user_id", memUserTable, "_id", true},
+	{"approval", "reviewer_id", memUserTable, "_id", false},
+	{"approval", "workspace_id", "workspace", "_id", true},
+	{PROJECT_TEAM_GROUP, "project_id", "project", "_id", true},
+	{PROJECT_TEAM_GROUP, "team_group_id", "team_group", "_id", true},
+	{PROJECT_TEAM_MEMBER, "project_id", "project", "_id", true},
+	{PROJECT_TEAM_MEMBER, "team_member_id", "team_member", "_id", true},
+	{TEAM_GROUP_TEAM_MEMBER, "team_group_id", "team_group", "_id", true},
+	{TEAM_GROUP_TEAM_MEMBER, "team_member_id", "team_member", "_id", true},
+	{TASK_TAG, "task_id", "task", "_id", true},
+	{TASK_TAG, "tag_id", "tag", "_id", true},
+}
+
+var memNotNullColumns = map[string][]string{
+	"team_member":      {"workspace_id", "user_email", "team_role_id"},
+	"team_group":       {"workspace_id"},
+	"project":          {"workspace_id"},
+	"notification":     {"user_id", "workspace_id", "project_id"},
+	"approval":         {"user_id", "workspace_id"},
+	"invoice":          {"client_id", "workspace_id"},
+	"invoice_item":     {"invoice_id"},
+	"invoice_template": {"workspace_id"},
+}
+
+var memUniqueColumns = map[string][]string{
+	memUserTable:       {"email", "username"},
+	"invoice_template": {"workspace_id"},
+}
+
+// memPartialUnique mirrors a partial unique index of the postgres schema, column is unique among
+// the rows whose boolean column where is true
+type memPartialUnique struct {
+	name   string
+	column string
+	where  string
+}
+
+var memPartialUniqueColumns = map[string][]memPartialUnique{
+	"task": {{"task_running_user_unique", "user_id", "is_active"}},
+}
+
+func newMemStore() *memStore {
+	s := &memStore{
+		tables:    make(map[string]*memTable),
+		createdAt: time.Now(),
+	}
+
+	for _, structType := range memEntityTypes {
+		tableName, _ := getTableNameForStruct(structType)
+		s.tables[tableName] = newMemTable(tableName, getColumnsForStruct(structType))
+	}
+
+	for tableName, columns := range compositeTableColumns {
+		s.tables[tableName] = newMemTable(tableName, columns)
+	}
+
+	// Rows the migrations seed in postgres
+	for _, r := range rbac.Roles() {
+		_ = s.insertRow(models.TeamRole{ID: r.ID, Role: r.Name, Version: 1})
+	}
+
+	return s
+}
+
+func newMemTable(name string, columns []string) *memTable {
+	return &memTable{
+		name:    name,
+		columns: columns,
+		keys:    make([]string, 0),
+		rows:    make(map[string]interface{}),
+	}
+}
+
+func (s *memStore) clone() *memStore {
+	c := &memStore{
+		tables:    make(map[string]*memTable, len(s.tables)),
+		createdAt: s.createdAt,
+	}
+
+	for name, t := range s.tables {
+		ct := newMemTable(t.name, t.columns)
+		ct.keys = append(ct.keys, t.keys...)
+		for k, row := range t.rows {
+			ct.rows[k] = row
+		}
+		c.tables[name] = ct
+	}
+
+	return c
+}
+
+func (s *memStore) table(name string) (*memTable, error) {
+	t, ok := s.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("relation %s does not exist", name)
+	}
+
+	return t, nil
+}
+
+// insertRow adds an entity struct, keyed by its _id column
+func (s *memStore) insertRow(row interface{}) error {
+	tableName, err := getTableNameForStruct(row)
+	if err != nil {
+		return err
+	}
+
+	id, _ := getColumnValue(row, "_id")
+	return s.insert(tableName, fmt.Sprint(id), row)
+}
+
+// insertCompositeRow adds a row of a composite table, keyed by all of its values
+func (s *memStore) insertCompositeRow(tableName string, valuesMap map[string]interface{}) error {
+	t, err := s.table(tableName)
+	if err != nil {
+		return err
+	}
+
+	row := make(map[string]interface{}, len(t.columns))
+	keyParts := make([]string, 0, len(t.columns))
+	for _, c := range t.columns {
+		v, ok := valuesMap[c]
+		if !ok {
+			return fmt.Errorf("value missing for column %s", c)
+		}
+
+		row[c] = v
+		keyParts = append(keyParts, fmt.Sprint(v))
+	}
+
+	return s.insert(tableName, strings.Join(keyParts, "|"), row)
+}
+
+func (s *memStore) insert(tableName string, key string, row interface{}) error {
+	t, err := s.table(tableName)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.rows[key]; ok {
+		return Conflict("duplicate key value violates unique constraint %s_pkey", strings.Trim(tableName, `"`))
+	}
+
+	err = s.checkConstraints(t, key, row)
+	if err != nil {
+		return err
+	}
+
+	t.keys = append(t.keys, key)
+	t.rows[key] = row
+
+	return nil
+}
+
+// selectRows returns the rows of tableName matching every search param, in insertion order
+func (s *memStore) selectRows(tableName string, searchParams map[string]interface{}) ([]interface{}, error) {
+	t, err := s.table(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range searchParams {
+		if err := checkMemColumn(t, k); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]interface{}, 0)
+	for _, key := range t.keys {
+		row := t.rows[key]
+		ok, err := rowMatches(row, searchParams)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// updateRow applies column updates to the entity stored under id and moves its version on. A
+// version in updates is the one the entity has to be at, like the update query of postgres.
+func (s *memStore) updateRow(structType interface{}, id string, updates map[string]interface{}) error {
+	tableName, err := getTableNameForStruct(structType)
+	if err != nil {
+		return err
+	}
+
+	t, err := s.table(tableName)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := updates["_id"]; ok {
+		return errors.New("_id can not be updated")
+	}
+
+	row, ok := t.rows[id]
+	if !ok {
+		return nil
+	}
+
+	version, _ := getColumnValue(row, VERSION_COLUMN)
+	if expected, ok := updates[VERSION_COLUMN]; ok {
+		equal, err := valuesEqual(version, expected)
+		if err != nil {
+			return err
+		}
+
+		if !equal {
+			return errStaleVersion
+		}
+	}
+
+	updated := reflect.New(reflect.TypeOf(row)).Elem()
+	updated.Set(reflect.ValueOf(row))
+	for _, k := range sortedKeys(updates) {
+		if k == VERSION_COLUMN {
+			continue
+		}
+
+		if err := checkMemColumn(t, k); err != nil {
+			return err
+		}
+
+		err = setColumnValue(updated, k, updates[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = setColumnValue(updated, VERSION_COLUMN, version.(int)+1)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkConstraints(t, id, updated.Interface())
+	if err != nil {
+		return err
+	}
+
+	t.rows[id] = updated.Interface()
+
+	return nil
+}
+
+// deleteRows removes matching rows, cascading to or restricted by referencing tables
+func (s *memStore) deleteRows(tableName string, searchParams map[string]interface{}) (int, error) {
+	if len(searchParams) == 0 {
+		return 0, errors.New("refusing to delete without conditions")
+	}
+
+	t, err := s.table(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	for k := range searchParams {
+		if err := checkMemColumn(t, k); err != nil {
+			return 0, err
+		}
+	}
+
+	deleted := make(map[string]interface{})
+	for _, key := range t.keys {
+		ok, err := rowMatches(t.rows[key], searchParams)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			deleted[key] = t.rows[key]
+		}
+	}
+
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, 0, len(t.keys)-len(deleted))
+	for _, key := range t.keys {
+		if _, ok := deleted[key]; ok {
+			delete(t.rows, key)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	t.keys = keys
+
+	for _, fk := range memForeignKeys {
+		if fk.refTable != tableName {
+			continue
+		}
+
+		for _, row := range deleted {
+			refValue, _ := getColumnValue(row, fk.refColumn)
+			params := map[string]interface{}{fk.column: refValue}
+
+			if fk.cascade {
+				_, err = s.deleteRows(fk.table, params)
+				if err != nil {
+					return 0, err
+				}
+				continue
+			}
+
+			referencing, err := s.selectRows(fk.table, params)
+			if err != nil {
+				return 0, err
+			}
+
+			if len(referencing) > 0 {
+				return 0, Validation("update or delete on table %s violates foreign key constraint %s_%s_fkey on table %s",
+					strings.Trim(tableName, `"`), fk.table, fk.column, fk.table)
+			}
+		}
+	}
+
+	return len(deleted), nil
+}
+
+// deleteRow deletes the entity of tableName stored under id. A version other than 0 is the one
+// the entity has to be at, like the delete query of postgres.
+func (s *memStore) deleteRow(tableName string, id string, version int) error {
+	deleteParams := map[string]interface{}{"_id": id}
+	if version != 0 {
+		deleteParams[VERSION_COLUMN] = version
+	}
+
+	deleted, err := s.deleteRows(tableName, deleteParams)
+	if err != nil {
+		return err
+	}
+
+	if version != 0 && deleted == 0 {
+		return errStaleVersion
+	}
+
+	return nil
+}
+
+func (s *memStore) checkConstraints(t *memTable, key string, row interface{}) error {
+	for _, c := range memNotNullColumns[t.name] {
+		if v, _ := getColumnValue(row, c); isEmptyValue(v) {
+			return Validation("null value in column %s of relation %s violates not-null constraint", c, t.name)
+		}
+	}
+
+	for _, c := range memUniqueColumns[t.name] {
+		v, _ := getColumnValue(row, c)
+		for otherKey, other := range t.rows {
+			if otherKey == key {
+				continue
+			}
+
+			if ov, _ := getColumnValue(other, c); ov == v {
+				return Conflict("duplicate key value violates unique constraint %s_unique", c)
+			}
+		}
+	}
+
+	for _, u := range memPartialUniqueColumns[t.name] {
+		v, _ := getColumnValue(row, u.column)
+		if in, _ := getColumnValue(row, u.where); in != true || isEmptyValue(v) {
+			continue
+		}
+
+		for otherKey, other := range t.rows {
+			if otherKey == key {
+				continue
+			}
+
+			ov, _ := getColumnValue(other, u.column)
+			if in, _ := getColumnValue(other, u.where); in == true && ov == v {
+				return Conflict("duplicate key value violates unique constraint %s", u.name)
+			}
+		}
+	}
+
+	for _, fk := range memForeignKeys {
+		if fk.table != t.name {
+			continue
+		}
+
+		v, _ := getColumnValue(row, fk.column)
+		if isEmptyValue(v) {
+			continue
+		}
+
+		referenced, err := s.selectRows(fk.refTable, map[string]interface{}{fk.refColumn: v})
+		if err != nil {
+			return err
+		}
+
+		if len(referenced) == 0 {
+			return Validation("insert or update on table %s violates foreign key constraint %s_%s_fkey",
+				t.name, t.name, fk.column)
+		}
+	}
+
+	return nil
+}
+
+// getCompositeValues returns column of the composite rows matching searchParams
+func (s *memStore) getCompositeValues(tableName string, searchParams map[string]interface{}, column string) ([]string, error) {
+	rows, err := s.selectRows(tableName, searchParams)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		v, _ := getColumnValue(row, column)
+		values = append(values, fmt.Sprint(v))
+	}
+
+	return values, nil
+}
+
+// addCompositeValues links ownerID to each of values that is not linked yet
+func (s *memStore) addCompositeValues(tableName, ownerColumn, ownerID, valueColumn string, values []string) error {
+	for _, v := range values {
+		valuesMap := map[string]interface{}{
+			ownerColumn: ownerID,
+			valueColumn: v,
+		}
+
+		existing, err := s.selectRows(tableName, valuesMap)
+		if err != nil {
+			return err
+		}
+
+		if len(existing) == 0 {
+			err = s.insertCompositeRow(tableName, valuesMap)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// replaceCompositeValues drops every link of ownerID and links it to values instead
+func (s *memStore) replaceCompositeValues(tableName, ownerColumn, ownerID, valueColumn string, values []string) error {
+	_, err := s.deleteRows(tableName, map[string]interface{}{ownerColumn: ownerID})
+	if err != nil {
+		return err
+	}
+
+	return s.addCompositeValues(tableName, ownerColumn, ownerID, valueColumn, values)
+}
+
+// popRelationUpdate removes the ids a relation is set to from updates, the same way the
+// postgres client handles relation columns passed along with regular updates. An empty list
+// unlinks every id.
+func popRelationUpdate(updates map[string]interface{}, key string) ([]string, bool) {
+	values, ok := updates[key].([]string)
+	if ok {
+		delete(updates, key)
+	}
+
+	return values, ok
+}
+
+func checkMemColumn(t *memTable, column string) error {
+	for _, c := range t.columns {
+		if c == column {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown column %q for table %s", column, t.name)
+}
+
+func rowMatches(row interface{}, searchParams map[string]interface{}) (bool, error) {
+	for k, param := range searchParams {
+		v, ok := getColumnValue(row, k)
+		if !ok {
+			return false, fmt.Errorf("unknown column %q", k)
+		}
+
+		equal, err := valuesEqual(v, param)
+		if err != nil {
+			return false, err
+		}
+
+		if !equal {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func getColumnValue(row interface{}, column string) (interface{}, bool) {
+	if m, ok := row.(map[string]interface{}); ok {
+		v, ok := m[column]
+		return v, ok
+	}
+
+	v := reflect.ValueOf(row)
+	for i := 0; i < v.NumField(); i++ {
+		if getColumnNameForField(v.Type().Field(i)) == column {
+			return v.Field(i).Interface(), true
+		}
+	}
+
+	return nil, false
+}
+
+func setColumnValue(row reflect.Value, column string, value interface{}) error {
+	for i := 0; i < row.NumField(); i++ {
+		if getColumnNameForField(row.Type().Field(i)) != column {
+			continue
+		}
+
+		v, err := coerceValue(value, row.Field(i).Type())
+		if err != nil {
+			return fmt.Errorf("invalid value for column %s: %v", column, err)
+		}
+
+		row.Field(i).Set(v)
+		return nil
+	}
+
+	return fmt.Errorf("unknown column %q", column)
+}
+
+func valuesEqual(stored interface{}, param interface{}) (bool, error) {
+	// A list of values matches any of them
+	if values, ok := param.([]string); ok {
+		for _, v := range values {
+			equal, err := valuesEqual(stored, v)
+			if err != nil || equal {
+				return equal, err
+			}
+		}
+
+		return false, nil
+	}
+
+	if stored == nil || param == nil {
+		return isEmptyValue(stored) && isEmptyValue(param), nil
+	}
+
+	v, err := coerceValue(param, reflect.TypeOf(stored))
+	if err != nil {
+		return false, err
+	}
+
+	if t, ok := stored.(time.Time); ok {
+		return t.Equal(v.Interface().(time.Time)), nil
+	}
+
+	if t, ok := stored.(*time.Time); ok {
+		other := v.Interface().(*time.Time)
+		if t == nil || other == nil {
+			return t == other, nil
+		}
+
+		return t.Equal(*other), nil
+	}
+
+	return reflect.DeepEqual(stored, v.Interface()), nil
+}
+
+// coerceValue converts value to target the way postgres casts text parameters into columns
+func coerceValue(value interface{}, target reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(target), nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(target) {
+		return v, nil
+	}
+
+	if t, ok := value.(time.Time); ok && target == reflect.TypeOf(&time.Time{}) {
+		return reflect.ValueOf(&t), nil
+	}
+
+	if s, ok := value.(string); ok {
+		switch {
+		case target == reflect.TypeOf(time.Time{}):
+			t, err := time.Parse(time.RFC3339, s)
+			return reflect.ValueOf(t), err
+		case target == reflect.TypeOf(&time.Time{}):
+			if s == "" {
+				return reflect.Zero(target), nil
+			}
+
+			t, err := time.Parse(time.RFC3339, s)
+			return reflect.ValueOf(&t), err
+		case target.Kind() == reflect.String:
+			return reflect.ValueOf(s).Convert(target), nil
+		case target.Kind() == reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			return reflect.ValueOf(b).Convert(target), err
+		case target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64:
+			f, err := strconv.ParseFloat(s, 64)
+			return reflect.ValueOf(f).Convert(target), err
+		case target.Kind() >= reflect.Int && target.Kind() <= reflect.Int64:
+			i, err := strconv.ParseInt(s, 10, 64)
+			return reflect.ValueOf(i).Convert(target), err
+		}
+	}
+
+	if v.Type().ConvertibleTo(target) && v.Kind() != reflect.String && target.Kind() != reflect.String {
+		return v.Convert(target), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("can not use %v as %v", v.Type(), target)
+}
+
+func isEmptyValue(v interface{}) bool {
+	return v == nil || v == ""
+}
@@ -0,0 +1,96 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddApproval(ctx context.Context, approval *models.Approval) (*models.Approval, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	approval.ID = fmt.Sprintf("a_%v", id)
+	approval.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*approval)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddApproval: %w", err)
+	}
+
+	return approval, http.StatusOK, nil
+}
+
+func (db *memClient) GetApproval(ctx context.Context, approvalID string) (*models.Approval, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = approvalID
+
+	approvals, err := db.GetApprovalsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetApproval: %w", err)
+	}
+
+	if len(approvals) <= 0 {
+		return nil, fmt.Errorf("GetApproval: %w", NotFound("approval with given id not found"))
+	}
+
+	return approvals[0], nil
+}
+
+func (db *memClient) GetApprovalsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Approval, error) {
+	approvals := make([]*models.Approval, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("approval", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			v := row.(models.Approval)
+			approvals = append(approvals, &v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsWithFilters: %w", err)
+	}
+
+	return approvals, nil
+}
+
+// GetApprovalsForUpdate needs no locks of its own, a transaction holds the whole store
+func (db *memClient) GetApprovalsForUpdate(ctx context.Context, searchParams map[string]interface{}) ([]*models.Approval, error) {
+	approvals, err := db.GetApprovalsWithFilters(ctx, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalsForUpdate: %w", err)
+	}
+
+	return approvals, nil
+}
+
+func (db *memClient) UpdateApproval(ctx context.Context, approvalID string, updates map[string]interface{}) (*models.Approval, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.Approval{}, approvalID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateApproval: %w", err)
+		}
+	}
+
+	approval, err := db.GetApproval(ctx, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateApproval: %w", err)
+	}
+
+	return approval, nil
+}
@@ -0,0 +1,106 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddClient(ctx context.Context, client *models.Client) (*models.Client, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	client.ID = fmt.Sprintf("c_%v", id)
+	client.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*client)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddClient: %w", err)
+	}
+
+	return client, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllClients(ctx context.Context) ([]*models.Client, error) {
+	clients, err := db.GetClientsWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllClients: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (db *memClient) GetClient(ctx context.Context, clientID string) (*models.Client, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = clientID
+
+	clients, err := db.GetClientsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetClient: %w", err)
+	}
+
+	if len(clients) <= 0 {
+		return nil, fmt.Errorf("GetClient: %w", NotFound("client with given id not found"))
+	}
+
+	return clients[0], nil
+}
+
+func (db *memClient) GetClientsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Client, error) {
+	clients := make([]*models.Client, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("client", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			c := row.(models.Client)
+			clients = append(clients, &c)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetClientsWithFilters: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (db *memClient) UpdateClient(ctx context.Context, clientID string, updates map[string]interface{}) (*models.Client, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.Client{}, clientID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateClient: %w", err)
+		}
+	}
+
+	client, err := db.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateClient: %w", err)
+	}
+
+	return client, nil
+}
+
+func (db *memClient) DeleteClient(ctx context.Context, clientID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("client", clientID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteClient: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddInvoice(ctx context.Context, invoice *models.Invoice) (*models.Invoice, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	invoice.ID = fmt.Sprintf("i_%v", id)
+	invoice.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("invoice", map[string]interface{}{"workspace_id": invoice.Workspace})
+		if err != nil {
+			return err
+		}
+
+		invoice.Number = 1
+		for _, row := range rows {
+			if n := row.(models.Invoice).Number; n >= invoice.Number {
+				invoice.Number = n + 1
+			}
+		}
+
+		err = s.insertRow(*invoice)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range invoice.Items {
+			item.ID = fmt.Sprintf("ii_%v", uuid.New().String())
+			item.Version = 1
+			item.Invoice = invoice.ID
+			item.Position = i + 1
+
+			err = s.insertRow(*item)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoice: %w", err)
+	}
+
+	invoice.SetTotals()
+	return invoice, http.StatusOK, nil
+}
+
+func (db *memClient) GetInvoice(ctx context.Context, invoiceID string) (*models.Invoice, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = invoiceID
+
+	invoices, err := db.GetInvoicesWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoice: %w", err)
+	}
+
+	if len(invoices) <= 0 {
+		return nil, fmt.Errorf("GetInvoice: %w", NotFound("invoice with given id not found"))
+	}
+
+	return invoices[0], nil
+}
+
+func (db *memClient) GetInvoicesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Invoice, error) {
+	invoices := make([]*models.Invoice, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("invoice", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			i := row.(models.Invoice)
+
+			i.Items, err = s.getInvoiceItems(map[string]interface{}{"invoice_id": i.ID})
+			if err != nil {
+				return err
+			}
+
+			i.SetTotals()
+			invoices = append(invoices, &i)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoicesWithFilters: %w", err)
+	}
+
+	return invoices, nil
+}
+
+func (db *memClient) UpdateInvoice(ctx context.Context, invoiceID string, updates map[string]interface{}) (*models.Invoice, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.Invoice{}, invoiceID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateInvoice: %w", err)
+		}
+	}
+
+	invoice, err := db.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateInvoice: %w", err)
+	}
+
+	return invoice, nil
+}
+
+func (db *memClient) DeleteInvoice(ctx context.Context, invoiceID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		items, err := s.selectRows("invoice_item", map[string]interface{}{"invoice_id": invoiceID})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			err = s.releaseInvoiceItem(item.(models.InvoiceItem).ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.deleteRow("invoice", invoiceID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteInvoice: %w", err)
+	}
+
+	return nil
+}
+
+func (db *memClient) AddInvoiceItem(ctx context.Context, item *models.InvoiceItem) (*models.InvoiceItem, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	item.ID = fmt.Sprintf("ii_%v", id)
+	item.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*item)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoiceItem: %w", err)
+	}
+
+	return item, http.StatusOK, nil
+}
+
+func (db *memClient) GetInvoiceItemsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.InvoiceItem, error) {
+	var items []*models.InvoiceItem
+	err := db.read(ctx, func(s *memStore) error {
+		var err error
+		items, err = s.getInvoiceItems(searchParams)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoiceItemsWithFilters: %w", err)
+	}
+
+	return items, nil
+}
+
+func (db *memClient) UpdateInvoiceItem(ctx context.Context, itemID string, updates map[string]interface{}) (*models.InvoiceItem, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.InvoiceItem{}, itemID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateInvoiceItem: %w", err)
+		}
+	}
+
+	items, err := db.GetInvoiceItemsWithFilters(ctx, map[string]interface{}{"_id": itemID})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateInvoiceItem: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("UpdateInvoiceItem: %w", NotFound("invoice item with given id not found"))
+	}
+
+	return items[0], nil
+}
+
+func (db *memClient) DeleteInvoiceItem(ctx context.Context, itemID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		err := s.releaseInvoiceItem(itemID)
+		if err != nil {
+			return err
+		}
+
+		return s.deleteRow("invoice_item", itemID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteInvoiceItem: %w", err)
+	}
+
+	return nil
+}
+
+func (db *memClient) AddInvoiceTemplate(ctx context.Context, template *models.InvoiceTemplate) (*models.InvoiceTemplate, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	template.ID = fmt.Sprintf("it_%v", id)
+	template.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*template)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddInvoiceTemplate: %w", err)
+	}
+
+	return template, http.StatusOK, nil
+}
+
+func (db *memClient) GetInvoiceTemplatesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.InvoiceTemplate, error) {
+	templates := make([]*models.InvoiceTemplate, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("invoice_template", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			t := row.(models.InvoiceTemplate)
+			templates = append(templates, &t)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetInvoiceTemplatesWithFilters: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (db *memClient) UpdateInvoiceTemplate(ctx context.Context, templateID string, updates map[string]interface{}) (*models.InvoiceTemplate, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.InvoiceTemplate{}, templateID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateInvoiceTemplate: %w", err)
+		}
+	}
+
+	templates, err := db.GetInvoiceTemplatesWithFilters(ctx, map[string]interface{}{"_id": templateID})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateInvoiceTemplate: %w", err)
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("UpdateInvoiceTemplate: %w", NotFound("invoice template with given id not found"))
+	}
+
+	return templates[0], nil
+}
+
+// getInvoiceItems returns the invoice items matching searchParams ordered like the postgres query
+func (s *memStore) getInvoiceItems(searchParams map[string]interface{}) ([]*models.InvoiceItem, error) {
+	rows, err := s.selectRows("invoice_item", searchParams)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.InvoiceItem, 0, len(rows))
+	for _, row := range rows {
+		item := row.(models.InvoiceItem)
+		items = append(items, &item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Position < items[j].Position
+	})
+
+	return items, nil
+}
+
+// releaseInvoiceItem clears the time entries billed on an invoice item, which postgres does with
+// ON DELETE SET NULL
+func (s *memStore) releaseInvoiceItem(itemID string) error {
+	tasks, err := s.selectRows("task", map[string]interface{}{"invoice_item_id": itemID})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		err = s.updateRow(models.Task{}, t.(models.Task).ID, map[string]interface{}{"invoice_item_id": nil})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
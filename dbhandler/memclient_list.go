@@ -0,0 +1,190 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) GetIDPage(ctx context.Context, structType interface{}, searchParams map[string]interface{}, options *models.ListOptions) (*models.IDPage, error) {
+	tableName, err := getTableNameForStruct(structType)
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	page := &models.IDPage{IDs: make([]string, 0, options.Limit)}
+	err = db.read(ctx, func(s *memStore) error {
+		t, err := s.table(tableName)
+		if err != nil {
+			return err
+		}
+
+		sortFields := append(append([]models.SortField{}, options.Sort...), models.SortField{Column: "_id"})
+		for _, f := range sortFields {
+			if err := checkMemColumn(t, f.Column); err != nil {
+				return err
+			}
+		}
+
+		if len(options.After) > 0 && len(options.After) != len(sortFields) {
+			return errors.New("cursor does not match the sort order")
+		}
+
+		rows, err := s.selectRows(tableName, searchParams)
+		if err != nil {
+			return err
+		}
+
+		keys := make([][]interface{}, 0, len(rows))
+		for _, row := range rows {
+			ok, err := s.filterMatches(tableName, row, options.Filter)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+			page.Total++
+
+			key := make([]interface{}, 0, len(sortFields))
+			for _, f := range sortFields {
+				v, _ := getColumnValue(row, f.Column)
+				key = append(key, v)
+			}
+
+			if len(options.After) > 0 {
+				c, err := compareKeys(key, options.After, sortFields)
+				if err != nil {
+					return err
+				}
+
+				if c <= 0 {
+					continue
+				}
+			}
+
+			keys = append(keys, key)
+		}
+
+		var sortErr error
+		sort.SliceStable(keys, func(i, j int) bool {
+			c, err := compareKeys(keys[i], keys[j], sortFields)
+			if err != nil {
+				sortErr = err
+			}
+			return c < 0
+		})
+		if sortErr != nil {
+			return sortErr
+		}
+
+		if options.Offset >= len(keys) {
+			return nil
+		}
+		keys = keys[options.Offset:]
+
+		for i, key := range keys {
+			if i == options.Limit {
+				page.Next = keys[i-1]
+				break
+			}
+
+			page.IDs = append(page.IDs, fmt.Sprint(key[len(key)-1]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetIDPage: %w", err)
+	}
+
+	return page, nil
+}
+
+// compareKeys orders two rows by their sort values the way postgres orders them for sortFields
+func compareKeys(a, b []interface{}, sortFields []models.SortField) (int, error) {
+	for i, f := range sortFields {
+		c, err := compareValues(a[i], b[i])
+		if err != nil {
+			return 0, err
+		}
+
+		if f.Desc {
+			c = -c
+		}
+
+		if c != 0 {
+			return c, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// compareValues returns -1, 0 or 1 as stored sorts before, like or after param, which is
+// converted to the type of stored first
+func compareValues(stored interface{}, param interface{}) (int, error) {
+	if stored == nil || param == nil {
+		return 0, errors.New("can not compare NULL")
+	}
+
+	v, err := coerceValue(param, reflect.TypeOf(stored))
+	if err != nil {
+		return 0, err
+	}
+
+	a, b := reflect.ValueOf(stored), v
+	switch {
+	case a.Type() == reflect.TypeOf(time.Time{}):
+		x, y := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case x.Before(y):
+			return -1, nil
+		case x.After(y):
+			return 1, nil
+		}
+		return 0, nil
+	case a.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String()), nil
+	case a.Kind() == reflect.Bool:
+		return compareInts(boolToInt(a.Bool()), boolToInt(b.Bool())), nil
+	case a.Kind() >= reflect.Int && a.Kind() <= reflect.Int64:
+		return compareInts(a.Int(), b.Int()), nil
+	case a.Kind() == reflect.Float32 || a.Kind() == reflect.Float64:
+		switch {
+		case a.Float() < b.Float():
+			return -1, nil
+		case a.Float() > b.Float():
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("can not compare values of type %s", a.Type())
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,110 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddNotification(ctx context.Context, notification *models.Notification) (*models.Notification, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	notification.ID = fmt.Sprintf("n_%v", id)
+	notification.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		// Duplicate alerts are skipped like postgres does with ON CONFLICT DO NOTHING
+		sent, err := s.selectRows("notification", map[string]interface{}{
+			"user_id":      notification.User,
+			"project_id":   notification.Project,
+			"kind":         notification.Kind,
+			"threshold":    notification.Threshold,
+			"period_start": notification.PeriodStart,
+		})
+		if err != nil || len(sent) > 0 {
+			return err
+		}
+
+		return s.insertRow(*notification)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddNotification: %w", err)
+	}
+
+	return notification, http.StatusOK, nil
+}
+
+func (db *memClient) GetNotification(ctx context.Context, notificationID string) (*models.Notification, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = notificationID
+
+	notifications, err := db.GetNotificationsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetNotification: %w", err)
+	}
+
+	if len(notifications) <= 0 {
+		return nil, fmt.Errorf("GetNotification: %w", NotFound("notification with given id not found"))
+	}
+
+	return notifications[0], nil
+}
+
+func (db *memClient) GetNotificationsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Notification, error) {
+	notifications := make([]*models.Notification, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("notification", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			v := row.(models.Notification)
+			notifications = append(notifications, &v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetNotificationsWithFilters: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (db *memClient) UpdateNotification(ctx context.Context, notificationID string, updates map[string]interface{}) (*models.Notification, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.Notification{}, notificationID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateNotification: %w", err)
+		}
+	}
+
+	notification, err := db.GetNotification(ctx, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateNotification: %w", err)
+	}
+
+	return notification, nil
+}
+
+func (db *memClient) DeleteNotification(ctx context.Context, notificationID string) error {
+	err := db.write(ctx, func(s *memStore) error {
+		_, err := s.deleteRows("notification", map[string]interface{}{"_id": notificationID})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteNotification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddProject(ctx context.Context, project *models.Project) (*models.Project, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	project.ID = fmt.Sprintf("p_%v", id)
+	project.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		err := s.insertRow(*project)
+		if err != nil {
+			return err
+		}
+
+		err = s.addCompositeValues(PROJECT_TEAM_MEMBER, "project_id", project.ID, "team_member_id", project.TeamMembers)
+		if err != nil {
+			return err
+		}
+
+		return s.addCompositeValues(PROJECT_TEAM_GROUP, "project_id", project.ID, "team_group_id", project.TeamGroups)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddProject: %w", err)
+	}
+
+	return project, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllProjects(ctx context.Context) ([]*models.Project, error) {
+	projects, err := db.GetProjectsWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllProjects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (db *memClient) GetProject(ctx context.Context, projectID string) (*models.Project, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = projectID
+
+	projects, err := db.GetProjectsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetProject: %w", err)
+	}
+
+	if len(projects) <= 0 {
+		return nil, fmt.Errorf("GetProject: %w", NotFound("project with given id not found"))
+	}
+
+	return projects[0], nil
+}
+
+func (db *memClient) GetProjectsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Project, error) {
+	projects := make([]*models.Project, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("project", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			p := row.(models.Project)
+
+			relationParams := map[string]interface{}{"project_id": p.ID}
+			p.TeamMembers, err = s.getCompositeValues(PROJECT_TEAM_MEMBER, relationParams, "team_member_id")
+			if err != nil {
+				return err
+			}
+
+			p.TeamGroups, err = s.getCompositeValues(PROJECT_TEAM_GROUP, relationParams, "team_group_id")
+			if err != nil {
+				return err
+			}
+
+			err = s.setProjectTracked(&p)
+			if err != nil {
+				return err
+			}
+
+			projects = append(projects, &p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetProjectsWithFilters: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (db *memClient) UpdateProject(ctx context.Context, projectID string, updates map[string]interface{}) (*models.Project, error) {
+	err := db.write(ctx, func(s *memStore) error {
+		if teamMembers, ok := popRelationUpdate(updates, "team_members"); ok {
+			err := s.replaceCompositeValues(PROJECT_TEAM_MEMBER, "project_id", projectID, "team_member_id", teamMembers)
+			if err != nil {
+				return err
+			}
+		}
+
+		if teamGroups, ok := popRelationUpdate(updates, "team_groups"); ok {
+			err := s.replaceCompositeValues(PROJECT_TEAM_GROUP, "project_id", projectID, "team_group_id", teamGroups)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			return s.updateRow(models.Project{}, projectID, updates)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateProject: %w", err)
+	}
+
+	project, err := db.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateProject: %w", err)
+	}
+
+	return project, nil
+}
+
+func (db *memClient) DeleteProject(ctx context.Context, projectID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("project", projectID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteProject: %w", err)
+	}
+
+	return nil
+}
+
+// setProjectTracked sums the finished time entries of project like projectTrackedQuery does
+func (s *memStore) setProjectTracked(project *models.Project) error {
+	rows, err := s.selectRows("task", map[string]interface{}{"project_id": project.ID})
+	if err != nil {
+		return err
+	}
+
+	periodStart := project.BudgetPeriodStart(time.Now())
+
+	var hours, amount, periodHours, periodAmount float64
+	for _, row := range rows {
+		t := row.(models.Task)
+		if t.EndTime == nil {
+			continue
+		}
+
+		inPeriod := !t.StartTime.Before(periodStart)
+
+		taskHours := t.EndTime.Sub(t.StartTime).Hours()
+		hours += taskHours
+		if inPeriod {
+			periodHours += taskHours
+		}
+
+		if !t.Billable {
+			continue
+		}
+
+		rate, err := s.getBillableRate(project, t.User, project.Workspace)
+		if err != nil {
+			return err
+		}
+
+		amount += taskHours * rate
+		if inPeriod {
+			periodAmount += taskHours * rate
+		}
+	}
+
+	project.SetTracked(hours, amount, periodHours, periodAmount)
+	return nil
+}
@@ -0,0 +1,379 @@
+package dbhandler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// memReportEntry defines a time entry matching a report filter with what it is grouped by
+type memReportEntry struct {
+	task    models.Task
+	project models.Project
+	user    models.User
+	rate    float64
+	hours   float64
+	amount  float64
+}
+
+func (db *memClient) GetSummaryReport(ctx context.Context, filter *models.ReportFilter, groupBy []string) (*models.SummaryReport, error) {
+	levels := make([][]*reportRow, 0, len(groupBy)+1)
+	err := db.read(ctx, func(s *memStore) error {
+		entries, err := s.getReportEntries(filter)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i <= len(groupBy); i++ {
+			rows, err := s.getReportRows(entries, groupBy[:i])
+			if err != nil {
+				return err
+			}
+
+			levels = append(levels, rows)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetSummaryReport: %w", err)
+	}
+
+	return buildSummaryReport(filter, groupBy, levels), nil
+}
+
+func (db *memClient) GetDetailedReport(ctx context.Context, filter *models.ReportFilter, page, pageSize int) (*models.DetailedReport, error) {
+	report := &models.DetailedReport{
+		Start:    filter.Start,
+		End:      filter.End,
+		Page:     page,
+		PageSize: pageSize,
+		Entries:  make([]*models.DetailedEntry, 0),
+	}
+
+	err := db.read(ctx, func(s *memStore) error {
+		entries, err := s.getReportEntries(filter)
+		if err != nil {
+			return err
+		}
+
+		sortReportEntries(entries)
+
+		report.Total = len(entries)
+		for _, e := range entries {
+			report.Hours += e.hours
+			report.Amount += e.amount
+		}
+
+		for i := (page - 1) * pageSize; i < len(entries) && i < page*pageSize; i++ {
+			entry, err := s.getDetailedEntry(entries[i])
+			if err != nil {
+				return err
+			}
+
+			report.Entries = append(report.Entries, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetDetailedReport: %w", err)
+	}
+
+	return report, nil
+}
+
+// EachDetailedEntry reads all entries under the lock and hands them to fn once it is released,
+// so that a slow fn does not hold up writers
+func (db *memClient) EachDetailedEntry(ctx context.Context, filter *models.ReportFilter, fn func(e *models.DetailedEntry) error) error {
+	detailed := make([]*models.DetailedEntry, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		entries, err := s.getReportEntries(filter)
+		if err != nil {
+			return err
+		}
+
+		sortReportEntries(entries)
+		for _, e := range entries {
+			entry, err := s.getDetailedEntry(e)
+			if err != nil {
+				return err
+			}
+
+			detailed = append(detailed, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("EachDetailedEntry: %w", err)
+	}
+
+	for _, e := range detailed {
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortReportEntries orders entries like the detailed report query does, oldest first
+func sortReportEntries(entries []*memReportEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].task.StartTime.Equal(entries[j].task.StartTime) {
+			return entries[i].task.StartTime.Before(entries[j].task.StartTime)
+		}
+
+		return entries[i].task.ID < entries[j].task.ID
+	})
+}
+
+// getDetailedEntry returns e as an entry of the detailed report
+func (s *memStore) getDetailedEntry(e *memReportEntry) (*models.DetailedEntry, error) {
+	entry := &models.DetailedEntry{
+		ID:          e.task.ID,
+		Description: e.task.Description,
+		ProjectID:   e.task.Project,
+		ProjectName: e.project.Name,
+		ClientID:    e.project.Client,
+		UserID:      e.task.User,
+		UserName:    e.user.Name,
+		Tags:        make([]string, 0),
+		StartTime:   e.task.StartTime,
+		EndTime:     *e.task.EndTime,
+		Billable:    e.task.Billable,
+		Rate:        e.rate,
+	}
+
+	clients, err := s.getReportGroupValues(e, models.REPORT_GROUP_CLIENT)
+	if err != nil {
+		return nil, err
+	}
+	entry.ClientName = clients[0][1]
+
+	tags, err := s.getReportGroupValues(e, models.REPORT_GROUP_TAG)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tags {
+		if t[0] != "" {
+			entry.Tags = append(entry.Tags, t[1])
+		}
+	}
+	sort.Strings(entry.Tags)
+
+	entry.SetAmount()
+	return entry, nil
+}
+
+// getReportEntries returns the time entries matching filter like getReportWhere does
+func (s *memStore) getReportEntries(filter *models.ReportFilter) ([]*memReportEntry, error) {
+	searchParams := map[string]interface{}{"workspace_id": filter.Workspace}
+	if filter.User != "" {
+		searchParams["user_id"] = filter.User
+	}
+	if filter.Billable != nil {
+		searchParams["billable"] = *filter.Billable
+	}
+
+	rows, err := s.selectRows("task", searchParams)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*memReportEntry, 0)
+	for _, row := range rows {
+		e := &memReportEntry{task: row.(models.Task)}
+		if e.task.EndTime == nil || e.task.StartTime.Before(filter.Start) || !e.task.StartTime.Before(filter.End) {
+			continue
+		}
+
+		ok, err := s.filterMatches("task", row, filter.Filter)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if e.task.Project != "" {
+			projects, err := s.selectRows("project", map[string]interface{}{"_id": e.task.Project})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range projects {
+				e.project = p.(models.Project)
+			}
+		}
+
+		if filter.Client != "" && e.project.Client != filter.Client {
+			continue
+		}
+
+		if filter.Invoiced != nil && (e.task.InvoiceItem != "") != *filter.Invoiced {
+			continue
+		}
+
+		e.hours = e.task.EndTime.Sub(e.task.StartTime).Hours()
+
+		if e.task.User != "" {
+			users, err := s.selectRows(memUserTable, map[string]interface{}{"_id": e.task.User})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, u := range users {
+				e.user = u.(models.User)
+			}
+		}
+
+		e.rate, err = s.getBillableRate(&e.project, e.task.User, e.task.Workspace)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.task.Billable {
+			e.amount = e.hours * e.rate
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// getBillableRate returns what a billable time entry of userID on project is charged per hour like
+// reportRate does, project is empty for entries without one
+func (s *memStore) getBillableRate(project *models.Project, userID, workspaceID string) (float64, error) {
+	if project.BillableRate > 0 {
+		return project.BillableRate, nil
+	}
+
+	users, err := s.selectRows(memUserTable, map[string]interface{}{"_id": userID})
+	if err != nil || len(users) == 0 {
+		return 0, err
+	}
+
+	teamMembers, err := s.selectRows("team_member", map[string]interface{}{"user_email": users[0].(models.User).Email,
+		"workspace_id": workspaceID})
+	if err != nil || len(teamMembers) == 0 {
+		return 0, err
+	}
+
+	return teamMembers[0].(models.TeamMember).BillableRate, nil
+}
+
+// getReportRows sums entries for each combination of groupBy keys like the report query does
+func (s *memStore) getReportRows(entries []*memReportEntry, groupBy []string) ([]*reportRow, error) {
+	rows := make(map[string]*reportRow)
+	order := make([]string, 0)
+	if len(groupBy) == 0 {
+		rows[""] = &reportRow{}
+		order = append(order, "")
+	}
+
+	for _, e := range entries {
+		combinations := [][][2]string{{}}
+		for _, g := range groupBy {
+			values, err := s.getReportGroupValues(e, g)
+			if err != nil {
+				return nil, err
+			}
+
+			next := make([][][2]string, 0, len(combinations)*len(values))
+			for _, c := range combinations {
+				for _, v := range values {
+					combination := append(append(make([][2]string, 0, len(c)+1), c...), v)
+					next = append(next, combination)
+				}
+			}
+			combinations = next
+		}
+
+		for _, c := range combinations {
+			r := &reportRow{keys: make([]string, 0, len(c)), names: make([]string, 0, len(c))}
+			for _, v := range c {
+				r.keys = append(r.keys, v[0])
+				r.names = append(r.names, v[1])
+			}
+
+			key := strings.Join(r.keys, "\x00")
+			if existing, ok := rows[key]; ok {
+				r = existing
+			} else {
+				rows[key] = r
+				order = append(order, key)
+			}
+
+			r.hours += e.hours
+			r.amount += e.amount
+		}
+	}
+
+	reportRows := make([]*reportRow, 0, len(order))
+	for _, key := range order {
+		reportRows = append(reportRows, rows[key])
+	}
+
+	return reportRows, nil
+}
+
+// getReportGroupValues returns the keys and names entry is grouped under by grouping
+func (s *memStore) getReportGroupValues(e *memReportEntry, grouping string) ([][2]string, error) {
+	switch grouping {
+	case models.REPORT_GROUP_PROJECT:
+		return [][2]string{{e.task.Project, e.project.Name}}, nil
+	case models.REPORT_GROUP_USER:
+		return [][2]string{{e.task.User, e.user.Name}}, nil
+	case models.REPORT_GROUP_CLIENT:
+		name := ""
+		if e.project.Client != "" {
+			clients, err := s.selectRows("client", map[string]interface{}{"_id": e.project.Client})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, c := range clients {
+				name = c.(models.Client).Name
+			}
+		}
+
+		return [][2]string{{e.project.Client, name}}, nil
+	case models.REPORT_GROUP_TAG:
+		tagIDs, err := s.getCompositeValues(TASK_TAG, map[string]interface{}{"task_id": e.task.ID}, "tag_id")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tagIDs) == 0 {
+			return [][2]string{{"", ""}}, nil
+		}
+
+		values := make([][2]string, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			name := ""
+			tags, err := s.selectRows("tag", map[string]interface{}{"_id": tagID})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, t := range tags {
+				name = t.(models.Tag).Name
+			}
+
+			values = append(values, [2]string{tagID, name})
+		}
+
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("unknown grouping %s", grouping)
+}
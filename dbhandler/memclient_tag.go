@@ -0,0 +1,106 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddTag(ctx context.Context, tag *models.Tag) (*models.Tag, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
+	}
+	tag.ID = fmt.Sprintf("t_%v", id)
+	tag.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*tag)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddTag: %w", err)
+	}
+
+	return tag, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllTags(ctx context.Context) ([]*models.Tag, error) {
+	tags, err := db.GetTagsWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (db *memClient) GetTag(ctx context.Context, tagID string) (*models.Tag, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = tagID
+
+	tags, err := db.GetTagsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTag: %w", err)
+	}
+
+	if len(tags) <= 0 {
+		return nil, fmt.Errorf("GetTag: %w", NotFound("tag with given id not found"))
+	}
+
+	return tags[0], nil
+}
+
+func (db *memClient) GetTagsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Tag, error) {
+	tags := make([]*models.Tag, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("tag", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			v := row.(models.Tag)
+			tags = append(tags, &v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTagsWithFilters: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (db *memClient) UpdateTag(ctx context.Context, tagID string, updates map[string]interface{}) (*models.Tag, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.Tag{}, tagID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateTag: %w", err)
+		}
+	}
+
+	tag, err := db.GetTag(ctx, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTag: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (db *memClient) DeleteTag(ctx context.Context, tagID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("tag", tagID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteTag: %w", err)
+	}
+
+	return nil
+}
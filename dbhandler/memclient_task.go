@@ -0,0 +1,188 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddTask(ctx context.Context, task *models.Task) (*models.Task, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
+	}
+	task.ID = fmt.Sprintf("t_%v", id)
+	task.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		err := s.insertRow(*task)
+		if err != nil {
+			return err
+		}
+
+		return s.addCompositeValues(TASK_TAG, "task_id", task.ID, "tag_id", task.Tags)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddTask: %w", err)
+	}
+
+	return task, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	tasks, err := db.GetTasksWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (db *memClient) GetTask(ctx context.Context, taskID string) (*models.Task, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = taskID
+
+	tasks, err := db.GetTasksWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTask: %w", err)
+	}
+
+	if len(tasks) <= 0 {
+		return nil, fmt.Errorf("GetTask: %w", NotFound("task with given id not found"))
+	}
+
+	return tasks[0], nil
+}
+
+// GetTaskForUpdate needs no locks of its own, a transaction holds the whole store
+func (db *memClient) GetTaskForUpdate(ctx context.Context, taskID string) (*models.Task, error) {
+	task, err := db.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("GetTaskForUpdate: %w", err)
+	}
+
+	return task, nil
+}
+
+func (db *memClient) GetTasksWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Task, error) {
+	tasks := make([]*models.Task, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("task", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			t := row.(models.Task)
+
+			t.Tags, err = s.getCompositeValues(TASK_TAG, map[string]interface{}{"task_id": t.ID}, "tag_id")
+			if err != nil {
+				return err
+			}
+
+			tasks = append(tasks, &t)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTasksWithFilters: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (db *memClient) GetTasksInRange(ctx context.Context, searchParams map[string]interface{}, start, end time.Time) ([]*models.Task, error) {
+	tasks, err := db.GetTasksWithFilters(ctx, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTasksInRange: %w", err)
+	}
+
+	inRange := make([]*models.Task, 0, len(tasks))
+	for _, t := range tasks {
+		if !t.StartTime.Before(start) && t.StartTime.Before(end) {
+			inRange = append(inRange, t)
+		}
+	}
+
+	sort.Slice(inRange, func(i, j int) bool {
+		if !inRange[i].StartTime.Equal(inRange[j].StartTime) {
+			return inRange[i].StartTime.Before(inRange[j].StartTime)
+		}
+		return inRange[i].ID < inRange[j].ID
+	})
+
+	return inRange, nil
+}
+
+func (db *memClient) UpdateTask(ctx context.Context, taskID string, updates map[string]interface{}) (*models.Task, error) {
+	err := db.write(ctx, func(s *memStore) error {
+		if tagIDs, ok := popRelationUpdate(updates, "tags"); ok {
+			err := s.replaceCompositeValues(TASK_TAG, "task_id", taskID, "tag_id", tagIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			return s.updateRow(models.Task{}, taskID, updates)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTask: %w", err)
+	}
+
+	task, err := db.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTask: %w", err)
+	}
+
+	return task, nil
+}
+
+func (db *memClient) DeleteTask(ctx context.Context, taskID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("task", taskID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteTask: %w", err)
+	}
+
+	return nil
+}
+
+func (db *memClient) InvoiceTasks(ctx context.Context, invoiceItemID string, taskIDs []string) error {
+	err := db.write(ctx, func(s *memStore) error {
+		tasks, err := s.selectRows("task", map[string]interface{}{"_id": taskIDs, "invoice_item_id": nil})
+		if err != nil {
+			return err
+		}
+
+		if len(tasks) != len(taskIDs) {
+			return errTasksInvoiced
+		}
+
+		for _, t := range tasks {
+			err = s.updateRow(models.Task{}, t.(models.Task).ID, map[string]interface{}{"invoice_item_id": invoiceItemID})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("InvoiceTasks: %w", err)
+	}
+
+	return nil
+}
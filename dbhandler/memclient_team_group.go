@@ -0,0 +1,127 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddTeamGroup(ctx context.Context, teamGroup *models.TeamGroup) (*models.TeamGroup, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
+	}
+	teamGroup.ID = fmt.Sprintf("tg_%v", id)
+	teamGroup.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		err := s.insertRow(*teamGroup)
+		if err != nil {
+			return err
+		}
+
+		return s.addCompositeValues(TEAM_GROUP_TEAM_MEMBER, "team_group_id", teamGroup.ID, "team_member_id", teamGroup.TeamMembers)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddTeamGroup: %w", err)
+	}
+
+	return teamGroup, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllTeamGroups(ctx context.Context) ([]*models.TeamGroup, error) {
+	teamGroups, err := db.GetTeamGroupsWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTeamGroups: %w", err)
+	}
+
+	return teamGroups, nil
+}
+
+func (db *memClient) GetTeamGroup(ctx context.Context, teamGroupID string) (*models.TeamGroup, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = teamGroupID
+
+	teamGroups, err := db.GetTeamGroupsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamGroup: %w", err)
+	}
+
+	if len(teamGroups) <= 0 {
+		return nil, fmt.Errorf("GetTeamGroup: %w", NotFound("team group with given id not found"))
+	}
+
+	return teamGroups[0], nil
+}
+
+func (db *memClient) GetTeamGroupsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamGroup, error) {
+	teamGroups := make([]*models.TeamGroup, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("team_group", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			tg := row.(models.TeamGroup)
+
+			tg.TeamMembers, err = s.getCompositeValues(TEAM_GROUP_TEAM_MEMBER, map[string]interface{}{"team_group_id": tg.ID},
+				"team_member_id")
+			if err != nil {
+				return err
+			}
+
+			teamGroups = append(teamGroups, &tg)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %w", err)
+	}
+
+	return teamGroups, nil
+}
+
+func (db *memClient) UpdateTeamGroup(ctx context.Context, teamGroupID string, updates map[string]interface{}) (*models.TeamGroup, error) {
+	err := db.write(ctx, func(s *memStore) error {
+		if teamMembers, ok := popRelationUpdate(updates, "team_members"); ok {
+			err := s.replaceCompositeValues(TEAM_GROUP_TEAM_MEMBER, "team_group_id", teamGroupID, "team_member_id", teamMembers)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			return s.updateRow(models.TeamGroup{}, teamGroupID, updates)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTeamGroup: %w", err)
+	}
+
+	teamGroup, err := db.GetTeamGroup(ctx, teamGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTeamGroup: %w", err)
+	}
+
+	return teamGroup, nil
+}
+
+func (db *memClient) DeleteTeamGroup(ctx context.Context, teamGroupID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("team_group", teamGroupID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteTeamGroup: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddTeamMember(ctx context.Context, teamMember *models.TeamMember) (*models.TeamMember, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, fmt.Errorf("AddTeamMember: %v", errors.New("unable to generate id"))
+	}
+	teamMember.ID = fmt.Sprintf("tm_%v", id)
+	teamMember.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		existing, err := s.selectRows("team_member", map[string]interface{}{"user_email": teamMember.User,
+			"workspace_id": teamMember.Workspace})
+		if err != nil {
+			return err
+		}
+
+		if len(existing) > 0 {
+			return Conflict("team member with this user email already exists in this workspace")
+		}
+
+		return s.insertRow(*teamMember)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("AddTeamMember: %w", err)
+	}
+
+	return teamMember, http.StatusOK, nil
+}
+
+func (db *memClient) AddTeamMemberTeamGroups(ctx context.Context, teamMemberID string, teamGroups []string) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.addCompositeValues(TEAM_GROUP_TEAM_MEMBER, "team_member_id", teamMemberID, "team_group_id", teamGroups)
+	})
+	if err != nil {
+		return fmt.Errorf("AddTeamMemberTeamGroups: %w", err)
+	}
+
+	return nil
+}
+
+func (db *memClient) GetAllTeamMembers(ctx context.Context) ([]*models.TeamMember, error) {
+	teamMembers, err := db.GetTeamMembersWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTeamMembers: %w", err)
+	}
+
+	return teamMembers, nil
+}
+
+func (db *memClient) GetTeamMember(ctx context.Context, teamMemberID string) (*models.TeamMember, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = teamMemberID
+
+	teamMembers, err := db.GetTeamMembersWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamMember: %w", err)
+	}
+
+	if len(teamMembers) <= 0 {
+		return nil, fmt.Errorf("GetTeamMember: %w", NotFound("team member with given id not found"))
+	}
+
+	return teamMembers[0], nil
+}
+
+func (db *memClient) GetTeamMembersWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamMember, error) {
+	teamMembers := make([]*models.TeamMember, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("team_member", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			tm := row.(models.TeamMember)
+
+			tm.TeamGroups, err = s.getCompositeValues(TEAM_GROUP_TEAM_MEMBER, map[string]interface{}{"team_member_id": tm.ID},
+				"team_group_id")
+			if err != nil {
+				return err
+			}
+
+			teamMembers = append(teamMembers, &tm)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamMembersWithFilters: %w", err)
+	}
+
+	return teamMembers, nil
+}
+
+func (db *memClient) UpdateTeamMember(ctx context.Context, teamMemberID string, updates map[string]interface{}) (*models.TeamMember, error) {
+	err := db.write(ctx, func(s *memStore) error {
+		if teamGroups, ok := popRelationUpdate(updates, "team_groups"); ok {
+			err := s.replaceCompositeValues(TEAM_GROUP_TEAM_MEMBER, "team_member_id", teamMemberID, "team_group_id", teamGroups)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			return s.updateRow(models.TeamMember{}, teamMemberID, updates)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTeamMember: %w", err)
+	}
+
+	teamMember, err := db.GetTeamMember(ctx, teamMemberID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTeamMember: %w", err)
+	}
+
+	return teamMember, nil
+}
+
+func (db *memClient) DeleteTeamMember(ctx context.Context, teamMemberID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("team_member", teamMemberID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteTeamMember: %w", err)
+	}
+
+	return nil
+}
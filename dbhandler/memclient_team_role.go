@@ -0,0 +1,107 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddTeamRole(ctx context.Context, teamRole *models.TeamRole) (*models.TeamRole, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
+	}
+	teamRole.ID = fmt.Sprintf("tr_%v", id)
+	teamRole.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*teamRole)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddTeamRole: %w", err)
+	}
+
+	return teamRole, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllTeamRoles(ctx context.Context) ([]*models.TeamRole, error) {
+	teamRoles, err := db.GetTeamRolesWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTeamRoles: %w", err)
+	}
+
+	return teamRoles, nil
+}
+
+func (db *memClient) GetTeamRole(ctx context.Context, teamRoleID string) (*models.TeamRole, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = teamRoleID
+
+	teamRoles, err := db.GetTeamRolesWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamRole: %w", err)
+	}
+
+	if len(teamRoles) <= 0 {
+		return nil, fmt.Errorf("GetTeamRole: %w", NotFound("team role with given id not found"))
+	}
+
+	return teamRoles[0], nil
+}
+
+func (db *memClient) GetTeamRolesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamRole, error) {
+	teamRoles := make([]*models.TeamRole, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("team_role", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			v := row.(models.TeamRole)
+			teamRoles = append(teamRoles, &v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamRolesWithFilters: %w", err)
+	}
+
+	return teamRoles, nil
+}
+
+func (db *memClient) UpdateTeamRole(ctx context.Context, teamRoleID string, updates map[string]interface{}) (*models.TeamRole, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.TeamRole{}, teamRoleID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateTeamRole: %w", err)
+		}
+	}
+
+	teamRole, err := db.GetTeamRole(ctx, teamRoleID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateTeamRole: %w", err)
+	}
+
+	return teamRole, nil
+}
+
+func (db *memClient) DeleteTeamRole(ctx context.Context, teamRoleID string) error {
+	err := db.write(ctx, func(s *memStore) error {
+		_, err := s.deleteRows("team_role", map[string]interface{}{"_id": teamRoleID})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteTeamRole: %w", err)
+	}
+
+	return nil
+}
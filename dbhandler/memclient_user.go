@@ -0,0 +1,194 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddUser(ctx context.Context, user *models.User) (*models.User, int, error) {
+	if status, err := db.CheckForDuplicateUser(ctx, user.Email); err != nil {
+		return nil, status, fmt.Errorf("AddUser: %w", err)
+	}
+
+	if status, err := db.CheckForDuplicateUser(ctx, user.Username); err != nil {
+		return nil, status, fmt.Errorf("AddUser: %w", err)
+	}
+
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
+	}
+	user.ID = fmt.Sprintf("u_%v", id)
+	user.Version = 1
+
+	if user.Timezone == "" {
+		user.Timezone = DEFAULT_TIMEZONE
+	}
+
+	err := hashUserPassword(user)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("AddUser: %w", err)
+	}
+
+	err = db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*user)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddUser: %w", err)
+	}
+
+	return user, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllUsers(ctx context.Context) ([]*models.User, error) {
+	users, err := db.GetUsersWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllUsers: %w", err)
+	}
+
+	return users, nil
+}
+
+func (db *memClient) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = userID
+
+	users, err := db.GetUsersWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetUser: %w", err)
+	}
+
+	if len(users) <= 0 {
+		return nil, fmt.Errorf("GetUser: %w", NotFound("user with given id not found"))
+	}
+
+	return users[0], nil
+}
+
+func (db *memClient) GetUserWithIdentity(ctx context.Context, identity string) (*models.User, error) {
+	searchParams := make(map[string]interface{})
+	if strings.Contains(identity, "@") {
+		searchParams["email"] = identity
+	} else {
+		searchParams["username"] = identity
+	}
+
+	users, err := db.GetUsersWithFilters(ctx, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetUser: %w", err)
+	}
+
+	if len(users) <= 0 {
+		return nil, fmt.Errorf("GetUser: %w", NotFound("user with given identity not found"))
+	}
+
+	return users[0], nil
+}
+
+func (db *memClient) GetUsersWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.User, error) {
+	users := make([]*models.User, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows(memUserTable, searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			u := row.(models.User)
+			users = append(users, &u)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetUsersWithFilters: %w", err)
+	}
+
+	return users, nil
+}
+
+func (db *memClient) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) (*models.User, error) {
+	err := hashPasswordUpdate(updates)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateUser: %w", err)
+	}
+
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.User{}, userID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateUser: %w", err)
+		}
+	}
+
+	user, err := db.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateUser: %w", err)
+	}
+
+	return user, nil
+}
+
+func (db *memClient) DeleteUser(ctx context.Context, userID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow(memUserTable, userID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteUser: %w", err)
+	}
+
+	return nil
+}
+
+func (db *memClient) CheckForDuplicateUser(ctx context.Context, identity string) (int, error) {
+	searchParams := make(map[string]interface{})
+	searchKey := ""
+	if strings.Contains(identity, "@") {
+		searchKey = "email"
+	} else {
+		searchKey = "username"
+	}
+	searchParams[searchKey] = identity
+
+	users, err := db.GetUsersWithFilters(ctx, searchParams)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("CheckForDuplicateUser: %w", err)
+	}
+	if len(users) > 0 {
+		return http.StatusConflict,
+			fmt.Errorf("CheckForDuplicateUser: %w", Conflict("user with this %v already exists", searchKey))
+	}
+
+	return http.StatusOK, nil
+}
+
+func (db *memClient) CheckUserLogin(ctx context.Context, identity, plain string) (*models.User, error) {
+	user, err := db.GetUserWithIdentity(ctx, identity)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, err
+	}
+
+	needsRehash, err := verifyUserPassword(user, plain)
+	if err != nil {
+		return nil, err
+	}
+
+	if needsRehash {
+		_, err = db.UpdateUser(ctx, user.ID, map[string]interface{}{"password": plain})
+		if err != nil {
+			return nil, fmt.Errorf("CheckUserLogin: %w", err)
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,106 @@
+package dbhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func (db *memClient) AddWorkspace(ctx context.Context, workspace *models.Workspace) (*models.Workspace, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
+	}
+	workspace.ID = fmt.Sprintf("w_%v", id)
+	workspace.Version = 1
+
+	err := db.write(ctx, func(s *memStore) error {
+		return s.insertRow(*workspace)
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddWorkspace: %w", err)
+	}
+
+	return workspace, http.StatusOK, nil
+}
+
+func (db *memClient) GetAllWorkspaces(ctx context.Context) ([]*models.Workspace, error) {
+	workspaces, err := db.GetWorkspacesWithFilters(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, fmt.Errorf("GetAllWorkspaces: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (db *memClient) GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = workspaceID
+
+	workspaces, err := db.GetWorkspacesWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkspace: %w", err)
+	}
+
+	if len(workspaces) <= 0 {
+		return nil, fmt.Errorf("GetWorkspace: %w", NotFound("workspace with given id not found"))
+	}
+
+	return workspaces[0], nil
+}
+
+func (db *memClient) GetWorkspacesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Workspace, error) {
+	workspaces := make([]*models.Workspace, 0)
+	err := db.read(ctx, func(s *memStore) error {
+		rows, err := s.selectRows("workspace", searchParams)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			v := row.(models.Workspace)
+			workspaces = append(workspaces, &v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkspacesWithFilters: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (db *memClient) UpdateWorkspace(ctx context.Context, workspaceID string, updates map[string]interface{}) (*models.Workspace, error) {
+	if len(updates) > 0 {
+		err := db.write(ctx, func(s *memStore) error {
+			return s.updateRow(models.Workspace{}, workspaceID, updates)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("UpdateWorkspace: %w", err)
+		}
+	}
+
+	workspace, err := db.GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateWorkspace: %w", err)
+	}
+
+	return workspace, nil
+}
+
+func (db *memClient) DeleteWorkspace(ctx context.Context, workspaceID string, version int) error {
+	err := db.write(ctx, func(s *memStore) error {
+		return s.deleteRow("workspace", workspaceID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteWorkspace: %w", err)
+	}
+
+	return nil
+}
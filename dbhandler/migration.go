@@ -0,0 +1,35 @@
+package dbhandler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qasim-sajid/clockify-api/migrations"
+)
+
+func (db *dbClient) MigrateUp(ctx context.Context) (int, error) {
+	count, err := migrations.Up(ctx, dbConnection)
+	if err != nil {
+		return count, fmt.Errorf("MigrateUp: %w", err)
+	}
+
+	return count, nil
+}
+
+func (db *dbClient) MigrateDown(ctx context.Context, steps int) (int, error) {
+	count, err := migrations.Down(ctx, dbConnection, steps)
+	if err != nil {
+		return count, fmt.Errorf("MigrateDown: %w", err)
+	}
+
+	return count, nil
+}
+
+func (db *dbClient) GetMigrationStatus(ctx context.Context) ([]*migrations.Status, error) {
+	statuses, err := migrations.GetStatus(ctx, dbConnection)
+	if err != nil {
+		return nil, fmt.Errorf("GetMigrationStatus: %w", err)
+	}
+
+	return statuses, nil
+}
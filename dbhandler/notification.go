@@ -0,0 +1,130 @@
+package dbhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// AddNotification stores notification unless the user was given the same alert already, a
+// duplicate alert, e.g. one stored by a concurrent transaction, is skipped rather than failing
+func (db *dbClient) AddNotification(ctx context.Context, notification *models.Notification) (*models.Notification, int, error) {
+	id := uuid.New().String()
+	if id == "" {
+		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
+	}
+	notification.ID = fmt.Sprintf("n_%v", id)
+	notification.Version = 1
+
+	insertQuery, args, err := db.GetInsertQuery(*notification)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddNotification: %w", err)
+	}
+
+	_, err = db.RunInsertQuery(ctx, insertQuery+" ON CONFLICT ON CONSTRAINT notification_alert_unique DO NOTHING", args...)
+	if err != nil {
+		return nil, -1, fmt.Errorf("AddNotification: %w", err)
+	}
+
+	return notification, http.StatusOK, nil
+}
+
+func (db *dbClient) GetNotification(ctx context.Context, notificationID string) (*models.Notification, error) {
+	selectParams := make(map[string]interface{})
+
+	selectParams["_id"] = notificationID
+
+	notifications, err := db.GetNotificationsWithFilters(ctx, selectParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetNotification: %w", err)
+	}
+
+	if len(notifications) <= 0 {
+		return nil, fmt.Errorf("GetNotification: %w", NotFound("notification with given id not found"))
+	}
+
+	return notifications[0], nil
+}
+
+func (db *dbClient) GetNotificationsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Notification, error) {
+	n := models.Notification{}
+
+	selectQuery, args, err := db.GetSelectQueryForStruct(n, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetNotificationsWithFilters: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetNotificationsWithFilters: %w", err)
+	}
+
+	notifications, err := db.GetNotificationsFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetNotificationsWithFilters: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (db *dbClient) GetNotificationsFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Notification, error) {
+	defer rows.Close()
+
+	notifications := make([]*models.Notification, 0)
+	for rows.Next() {
+		n := models.Notification{}
+
+		err := rows.Scan(&n.ID, &n.Kind, &n.Threshold, &n.PeriodStart, &n.Message, &n.CreatedAt, &n.IsRead, &n.User,
+			&n.Workspace, &n.Project, &n.Version)
+		if err != nil {
+			return nil, fmt.Errorf("GetNotificationsFromRows: %w", err)
+		}
+
+		notifications = append(notifications, &n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetNotificationsFromRows: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (db *dbClient) UpdateNotification(ctx context.Context, notificationID string, updates map[string]interface{}) (*models.Notification, error) {
+	if len(updates) > 0 {
+		err := db.updateStruct(ctx, models.Notification{}, notificationID, updates)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateNotification: %w", err)
+		}
+	}
+
+	notification, err := db.GetNotification(ctx, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateNotification: %w", err)
+	}
+
+	return notification, nil
+}
+
+func (db *dbClient) DeleteNotification(ctx context.Context, notificationID string) error {
+	deleteParams := make(map[string]interface{})
+
+	deleteParams["_id"] = notificationID
+
+	deleteQuery, args, err := db.GetDeleteQueryForStruct(models.Notification{}, deleteParams)
+	if err != nil {
+		return fmt.Errorf("DeleteNotification: %w", err)
+	}
+
+	_, err = db.RunDeleteQuery(ctx, deleteQuery, args...)
+	if err != nil {
+		return fmt.Errorf("DeleteNotification: %w", err)
+	}
+
+	return nil
+}
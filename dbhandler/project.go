@@ -1,48 +1,52 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddProject(project *models.Project) (*models.Project, int, error) {
+func (db *dbClient) AddProject(ctx context.Context, project *models.Project) (*models.Project, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate _id")
 	}
 	project.ID = fmt.Sprintf("p_%v", id)
+	project.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*project)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddProject: %v", err)
-	}
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		insertQuery, args, err := tx.GetInsertQuery(*project)
+		if err != nil {
+			return err
+		}
 
-	_, err = db.RunInsertQuery(insertQuery)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddProject: %v", err)
-	}
+		_, err = tx.RunInsertQuery(ctx, insertQuery, args...)
+		if err != nil {
+			return err
+		}
 
-	err = db.AddProjectTeamMembers(project.ID, project.TeamMembers)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddProject: %v", err)
-	}
+		err = tx.AddProjectTeamMembers(ctx, project.ID, project.TeamMembers)
+		if err != nil {
+			return err
+		}
 
-	err = db.AddProjectTeamGroups(project.ID, project.TeamGroups)
+		return tx.AddProjectTeamGroups(ctx, project.ID, project.TeamGroups)
+	})
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddProject: %v", err)
+		return nil, -1, fmt.Errorf("AddProject: %w", err)
 	}
 
 	return project, http.StatusOK, nil
 }
 
-func (db *dbClient) AddProjectTeamMembers(projectID string, teamMembers []string) error {
-	if teamMembers == nil || teamMembers[0] == "" {
+func (db *dbClient) AddProjectTeamMembers(ctx context.Context, projectID string, teamMembers []string) error {
+	if len(teamMembers) == 0 {
 		return nil
 	}
 
@@ -52,12 +56,12 @@ func (db *dbClient) AddProjectTeamMembers(projectID string, teamMembers []string
 		valuesMap["team_member_id"] = tm
 
 		//Check if value already exists
-		_, err := db.GetTeamMemberForProject(projectID, tm)
+		_, err := db.GetTeamMemberForProject(ctx, projectID, tm)
 		if err != nil {
 			//If value doesn't exist then insert it
-			_, err := db.AddValueInCompositeTable(PROJECT_TEAM_MEMBER, valuesMap)
+			_, err := db.AddValueInCompositeTable(ctx, PROJECT_TEAM_MEMBER, valuesMap)
 			if err != nil {
-				return fmt.Errorf("AddProjectTeamMembers: %v", err)
+				return fmt.Errorf("AddProjectTeamMembers: %w", err)
 			}
 		}
 	}
@@ -65,10 +69,10 @@ func (db *dbClient) AddProjectTeamMembers(projectID string, teamMembers []string
 	return nil
 }
 
-func (db *dbClient) GetTeamMemberForProject(projectID, teamMemberID string) (string, error) {
-	teamMembers, err := db.GetProjectTeamMembers(projectID)
+func (db *dbClient) GetTeamMemberForProject(ctx context.Context, projectID, teamMemberID string) (string, error) {
+	teamMembers, err := db.GetProjectTeamMembers(ctx, projectID)
 	if err != nil {
-		return "", fmt.Errorf("GetTeamMemberForProject: %v", err)
+		return "", fmt.Errorf("GetTeamMemberForProject: %w", err)
 	}
 
 	for _, tm := range teamMembers {
@@ -77,11 +81,11 @@ func (db *dbClient) GetTeamMemberForProject(projectID, teamMemberID string) (str
 		}
 	}
 
-	return "", fmt.Errorf("GetTeamMemberForProject: %v", errors.New("team member with given _id not found"))
+	return "", fmt.Errorf("GetTeamMemberForProject: %w", NotFound("team member with given id not found"))
 }
 
-func (db *dbClient) AddProjectTeamGroups(projectID string, teamGroups []string) error {
-	if teamGroups == nil || teamGroups[0] == "" {
+func (db *dbClient) AddProjectTeamGroups(ctx context.Context, projectID string, teamGroups []string) error {
+	if len(teamGroups) == 0 {
 		return nil
 	}
 
@@ -91,12 +95,12 @@ func (db *dbClient) AddProjectTeamGroups(projectID string, teamGroups []string)
 		valuesMap["team_group_id"] = tg
 
 		//Check if value already exists
-		_, err := db.GetTeamGroupForProject(projectID, tg)
+		_, err := db.GetTeamGroupForProject(ctx, projectID, tg)
 		if err != nil {
 			//If value doesn't exist then insert it
-			_, err := db.AddValueInCompositeTable(PROJECT_TEAM_GROUP, valuesMap)
+			_, err := db.AddValueInCompositeTable(ctx, PROJECT_TEAM_GROUP, valuesMap)
 			if err != nil {
-				return fmt.Errorf("AddProjectTeamGroups: %v", err)
+				return fmt.Errorf("AddProjectTeamGroups: %w", err)
 			}
 		}
 	}
@@ -104,10 +108,10 @@ func (db *dbClient) AddProjectTeamGroups(projectID string, teamGroups []string)
 	return nil
 }
 
-func (db *dbClient) GetTeamGroupForProject(projectID, teamGroupID string) (string, error) {
-	teamGroups, err := db.GetProjectTeamGroups(projectID)
+func (db *dbClient) GetTeamGroupForProject(ctx context.Context, projectID, teamGroupID string) (string, error) {
+	teamGroups, err := db.GetProjectTeamGroups(ctx, projectID)
 	if err != nil {
-		return "", fmt.Errorf("GetTeamGroupForProject: %v", err)
+		return "", fmt.Errorf("GetTeamGroupForProject: %w", err)
 	}
 
 	for _, tg := range teamGroups {
@@ -116,31 +120,31 @@ func (db *dbClient) GetTeamGroupForProject(projectID, teamGroupID string) (strin
 		}
 	}
 
-	return "", fmt.Errorf("GetTeamGroupForProject: %v", errors.New("team group with given id not found"))
+	return "", fmt.Errorf("GetTeamGroupForProject: %w", NotFound("team group with given id not found"))
 }
 
-func (db *dbClient) GetAllProjects() ([]*models.Project, error) {
-	projects, err := db.GetProjectsWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllProjects(ctx context.Context) ([]*models.Project, error) {
+	projects, err := db.GetProjectsWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllProjects: %v", err)
+		return nil, fmt.Errorf("GetAllProjects: %w", err)
 	}
 
 	return projects, nil
 }
 
-func (db *dbClient) GetProject(projectID string) (*models.Project, error) {
+func (db *dbClient) GetProject(ctx context.Context, projectID string) (*models.Project, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = projectID
 
-	projects, err := db.GetProjectsWithFilters(selectParams)
+	projects, err := db.GetProjectsWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetProject: %v", err)
+		return nil, fmt.Errorf("GetProject: %w", err)
 	}
 
 	var project *models.Project
 	if projects == nil || len(projects) <= 0 {
-		return nil, fmt.Errorf("GetProject: %v", errors.New("project with given if not found"))
+		return nil, fmt.Errorf("GetProject: %w", NotFound("project with given id not found"))
 	} else {
 		project = projects[0]
 	}
@@ -148,28 +152,30 @@ func (db *dbClient) GetProject(projectID string) (*models.Project, error) {
 	return project, nil
 }
 
-func (db *dbClient) GetProjectsWithFilters(searchParams map[string]interface{}) ([]*models.Project, error) {
+func (db *dbClient) GetProjectsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Project, error) {
 	p := models.Project{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetProjectsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetProjectsWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetProjectsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetProjectsWithFilters: %w", err)
 	}
 
-	projects, err := db.GetProjectsFromRows(rows)
+	projects, err := db.GetProjectsFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetProjectsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetProjectsWithFilters: %w", err)
 	}
 
 	return projects, nil
 }
 
-func (db *dbClient) GetProjectsFromRows(rows *sql.Rows) ([]*models.Project, error) {
+func (db *dbClient) GetProjectsFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Project, error) {
+	defer rows.Close()
+
 	projects := make([]*models.Project, 0)
 	for rows.Next() {
 		p := models.Project{}
@@ -177,10 +183,10 @@ func (db *dbClient) GetProjectsFromRows(rows *sql.Rows) ([]*models.Project, erro
 		var clientID sql.NullString
 		var workspaceID sql.NullString
 
-		err := rows.Scan(&p.ID, &p.Name, &p.ColorTag, &p.IsPublic, &p.TrackedHours, &p.TrackedAmount, &p.ProgressPercentage, &clientID,
-			&workspaceID)
+		err := rows.Scan(&p.ID, &p.Name, &p.ColorTag, &p.IsPublic, &p.BillableRate, &p.EstimateHours, &p.BudgetAmount, &p.BudgetPeriod,
+			&p.BudgetAlerts, &clientID, &workspaceID, &p.Version)
 		if err != nil {
-			return nil, fmt.Errorf("GetProjectsFromRows: %v", err)
+			return nil, fmt.Errorf("GetProjectsFromRows: %w", err)
 		}
 
 		if clientID.Valid {
@@ -191,30 +197,83 @@ func (db *dbClient) GetProjectsFromRows(rows *sql.Rows) ([]*models.Project, erro
 			p.Workspace = workspaceID.String
 		}
 
-		p.TeamMembers, err = db.GetProjectTeamMembers(p.ID)
+		projects = append(projects, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetProjectsFromRows: %w", err)
+	}
+
+	//Relations are loaded after rows are drained as a transaction runs one query at a time
+	var err error
+	for _, p := range projects {
+		p.TeamMembers, err = db.GetProjectTeamMembers(ctx, p.ID)
 		if err != nil {
-			return nil, fmt.Errorf("GetProjectsFromRows: %v", err)
+			return nil, fmt.Errorf("GetProjectsFromRows: %w", err)
 		}
 
-		p.TeamGroups, err = db.GetProjectTeamGroups(p.ID)
+		p.TeamGroups, err = db.GetProjectTeamGroups(ctx, p.ID)
 		if err != nil {
-			return nil, fmt.Errorf("GetProjectsFromRows: %v", err)
+			return nil, fmt.Errorf("GetProjectsFromRows: %w", err)
 		}
 
-		projects = append(projects, &p)
+		err = db.setProjectTracked(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("GetProjectsFromRows: %w", err)
+		}
 	}
 
 	return projects, nil
 }
 
-func (db *dbClient) GetProjectTeamMembers(projectID string) ([]string, error) {
+// projectTrackedQuery sums the finished time entries of a project in total and since the start of
+// its budget period, billable entries are charged at the billable rate of the project or, when it
+// has none, of the team member who tracked them
+const projectTrackedQuery = `SELECT
+		COALESCE(SUM(EXTRACT(EPOCH FROM (t.end_time - t.start_time))), 0) / 3600,
+		COALESCE(SUM(CASE WHEN t.billable THEN EXTRACT(EPOCH FROM (t.end_time - t.start_time)) *
+			COALESCE(NULLIF($4::numeric, 0), tm.billable_rate) END), 0) / 3600,
+		COALESCE(SUM(EXTRACT(EPOCH FROM (t.end_time - t.start_time))) FILTER (WHERE t.start_time >= $3), 0) / 3600,
+		COALESCE(SUM(CASE WHEN t.billable THEN EXTRACT(EPOCH FROM (t.end_time - t.start_time)) *
+			COALESCE(NULLIF($4::numeric, 0), tm.billable_rate) END) FILTER (WHERE t.start_time >= $3), 0) / 3600
+	FROM public.task t
+	LEFT JOIN public."user" u ON u._id = t.user_id
+	LEFT JOIN public.team_member tm ON tm.user_email = u.email AND tm.workspace_id = $2
+	WHERE t.project_id = $1 AND t.end_time IS NOT NULL`
+
+func (db *dbClient) setProjectTracked(ctx context.Context, project *models.Project) error {
+	rows, err := db.RunSelectQuery(ctx, projectTrackedQuery, project.ID, project.Workspace,
+		project.BudgetPeriodStart(time.Now()), project.BillableRate)
+	if err != nil {
+		return fmt.Errorf("setProjectTracked: %w", err)
+	}
+	defer rows.Close()
+
+	var hours, amount, periodHours, periodAmount float64
+	if rows.Next() {
+		err = rows.Scan(&hours, &amount, &periodHours, &periodAmount)
+		if err != nil {
+			return fmt.Errorf("setProjectTracked: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("setProjectTracked: %w", err)
+	}
+
+	project.SetTracked(hours, amount, periodHours, periodAmount)
+	return nil
+}
+
+func (db *dbClient) GetProjectTeamMembers(ctx context.Context, projectID string) ([]string, error) {
 	searchParams := make(map[string]interface{})
 	searchParams["project_id"] = projectID
 
-	rows, err := db.GetValuesFromCompositeTable(PROJECT_TEAM_MEMBER, searchParams)
+	rows, err := db.GetValuesFromCompositeTable(ctx, PROJECT_TEAM_MEMBER, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetProjectTeamMembers: %v", err)
+		return nil, fmt.Errorf("GetProjectTeamMembers: %w", err)
 	}
+	defer rows.Close()
 
 	teamMembers := make([]string, 0)
 	for rows.Next() {
@@ -222,7 +281,7 @@ func (db *dbClient) GetProjectTeamMembers(projectID string) ([]string, error) {
 
 		err := rows.Scan(&projectID, &teamMemberID)
 		if err != nil {
-			return nil, fmt.Errorf("GetProjectTeamMembers: %v", err)
+			return nil, fmt.Errorf("GetProjectTeamMembers: %w", err)
 		}
 
 		teamMembers = append(teamMembers, teamMemberID)
@@ -231,14 +290,15 @@ func (db *dbClient) GetProjectTeamMembers(projectID string) ([]string, error) {
 	return teamMembers, nil
 }
 
-func (db *dbClient) GetProjectTeamGroups(projectID string) ([]string, error) {
+func (db *dbClient) GetProjectTeamGroups(ctx context.Context, projectID string) ([]string, error) {
 	searchParams := make(map[string]interface{})
 	searchParams["project_id"] = projectID
 
-	rows, err := db.GetValuesFromCompositeTable(PROJECT_TEAM_GROUP, searchParams)
+	rows, err := db.GetValuesFromCompositeTable(ctx, PROJECT_TEAM_GROUP, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetProjectTeamGroups: %v", err)
+		return nil, fmt.Errorf("GetProjectTeamGroups: %w", err)
 	}
+	defer rows.Close()
 
 	teamGroups := make([]string, 0)
 	for rows.Next() {
@@ -246,7 +306,7 @@ func (db *dbClient) GetProjectTeamGroups(projectID string) ([]string, error) {
 
 		err := rows.Scan(&projectID, &teamGroupID)
 		if err != nil {
-			return nil, fmt.Errorf("GetProjectTeamGroups: %v", err)
+			return nil, fmt.Errorf("GetProjectTeamGroups: %w", err)
 		}
 
 		teamGroups = append(teamGroups, teamGroupID)
@@ -255,149 +315,136 @@ func (db *dbClient) GetProjectTeamGroups(projectID string) ([]string, error) {
 	return teamGroups, nil
 }
 
-func (db *dbClient) UpdateProject(projectID string, updates map[string]interface{}) (*models.Project, error) {
-	if v, ok := updates["team_members"]; ok {
-		teamMembers := strings.Split(v.(string), ",")
-		if len(teamMembers) > 0 && teamMembers[0] != "" {
-			err := db.UpdateProjectTeamMembers(projectID, teamMembers)
+func (db *dbClient) UpdateProject(ctx context.Context, projectID string, updates map[string]interface{}) (*models.Project, error) {
+	var project *models.Project
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		if teamMembers, ok := updates["team_members"].([]string); ok {
+			err := tx.UpdateProjectTeamMembers(ctx, projectID, teamMembers)
 			if err != nil {
-				return nil, fmt.Errorf("UpdateProject: %v", err)
+				return err
 			}
+			delete(updates, "team_members")
 		}
-		delete(updates, "team_members")
-	}
 
-	if v, ok := updates["team_groups"]; ok {
-		teamGroups := strings.Split(v.(string), ",")
-		if len(teamGroups) > 0 && teamGroups[0] != "" {
-			err := db.UpdateProjectTeamGroups(projectID, teamGroups)
+		if teamGroups, ok := updates["team_groups"].([]string); ok {
+			err := tx.UpdateProjectTeamGroups(ctx, projectID, teamGroups)
 			if err != nil {
-				return nil, fmt.Errorf("UpdateProject: %v", err)
+				return err
 			}
+			delete(updates, "team_groups")
 		}
-		delete(updates, "team_groups")
-	}
 
-	updateQuery, err := db.GetUpdateQueryForStruct(models.Project{}, projectID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateProject: %v", err)
-	}
-
-	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
-		if err != nil {
-			return nil, fmt.Errorf("UpdateProject: %v", err)
+		if len(updates) > 0 {
+			err := tx.updateStruct(ctx, models.Project{}, projectID, updates)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	project, err := db.GetProject(projectID)
+		var err error
+		project, err = tx.GetProject(ctx, projectID)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("UpdateProject: %v", err)
+		return nil, fmt.Errorf("UpdateProject: %w", err)
 	}
 
 	return project, nil
 }
 
-func (db *dbClient) UpdateProjectTeamMembers(projectID string, teamMembers []string) error {
+func (db *dbClient) UpdateProjectTeamMembers(ctx context.Context, projectID string, teamMembers []string) error {
 	deleteParams := make(map[string]interface{})
-	deleteParams["team_member_id"] = projectID
-	_, err := db.DeleteValuesFromCompositeTable(PROJECT_TEAM_MEMBER, deleteParams)
+	deleteParams["project_id"] = projectID
+	_, err := db.DeleteValuesFromCompositeTable(ctx, PROJECT_TEAM_MEMBER, deleteParams)
 	if err != nil {
-		return fmt.Errorf("UpdateProjectTeamMembers: %v", err)
+		return fmt.Errorf("UpdateProjectTeamMembers: %w", err)
 	}
 
-	err = db.AddProjectTeamMembers(projectID, teamMembers)
+	err = db.AddProjectTeamMembers(ctx, projectID, teamMembers)
 	if err != nil {
-		return fmt.Errorf("UpdateProjectTeamMembers: %v", err)
+		return fmt.Errorf("UpdateProjectTeamMembers: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) UpdateProjectTeamGroups(projectID string, teamGroups []string) error {
+func (db *dbClient) UpdateProjectTeamGroups(ctx context.Context, projectID string, teamGroups []string) error {
 	deleteParams := make(map[string]interface{})
-	deleteParams["team_group_id"] = projectID
-	_, err := db.DeleteValuesFromCompositeTable(PROJECT_TEAM_GROUP, deleteParams)
+	deleteParams["project_id"] = projectID
+	_, err := db.DeleteValuesFromCompositeTable(ctx, PROJECT_TEAM_GROUP, deleteParams)
 	if err != nil {
-		return fmt.Errorf("UpdateProjectTeamGroups: %v", err)
+		return fmt.Errorf("UpdateProjectTeamGroups: %w", err)
 	}
 
-	err = db.AddProjectTeamGroups(projectID, teamGroups)
+	err = db.AddProjectTeamGroups(ctx, projectID, teamGroups)
 	if err != nil {
-		return fmt.Errorf("UpdateProjectTeamGroups: %v", err)
+		return fmt.Errorf("UpdateProjectTeamGroups: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) DeleteProject(projectID string) error {
-	deleteParams := make(map[string]interface{})
-
-	deleteParams["_id"] = projectID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.Project{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteProject: %v", err)
-	}
-
-	_, err = db.RunDeleteQuery(deleteQuery)
-	if err != nil {
-		return fmt.Errorf("DeleteProject: %v", err)
-	}
+func (db *dbClient) DeleteProject(ctx context.Context, projectID string, version int) error {
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		deleteParamsForColumns := make(map[string]interface{})
+		deleteParamsForColumns["project_id"] = projectID
 
-	deleteParamsForColumns := make(map[string]interface{})
-	deleteParamsForColumns["project_id"] = projectID
+		_, err := tx.DeleteValuesFromCompositeTable(ctx, PROJECT_TEAM_GROUP, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteTeamGroupsForProject: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(PROJECT_TEAM_GROUP, deleteParamsForColumns)
-	if err != nil {
-		return fmt.Errorf("DeleteTeamGroupsForProject: %v", err)
-	}
+		_, err = tx.DeleteValuesFromCompositeTable(ctx, PROJECT_TEAM_MEMBER, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteTeamMembersForProject: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(PROJECT_TEAM_MEMBER, deleteParamsForColumns)
+		return tx.deleteStruct(ctx, models.Project{}, projectID, version)
+	})
 	if err != nil {
-		return fmt.Errorf("DeleteTeamMembersForProject: %v", err)
+		return fmt.Errorf("DeleteProject: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) AddValueInCompositeTable(tableName string, valuesMap map[string]interface{}) (sql.Result, error) {
-	insertQuery, err := db.GetInsertQueryForCompositeTable(tableName, valuesMap)
+func (db *dbClient) AddValueInCompositeTable(ctx context.Context, tableName string, valuesMap map[string]interface{}) (sql.Result, error) {
+	insertQuery, args, err := db.GetInsertQueryForCompositeTable(tableName, valuesMap)
 	if err != nil {
-		return nil, fmt.Errorf("AddValuesInCompositeTable: %v", err)
+		return nil, fmt.Errorf("AddValuesInCompositeTable: %w", err)
 	}
 
-	result, err := db.RunInsertQuery(insertQuery)
+	result, err := db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("AddValuesInCompositeTable: %v", err)
+		return nil, fmt.Errorf("AddValuesInCompositeTable: %w", err)
 	}
 
 	return result, nil
 }
 
-func (db *dbClient) GetValuesFromCompositeTable(tableName string, searchParams map[string]interface{}) (*sql.Rows, error) {
-	selectQuery, err := db.GetSelectQueryForCompositeTable(tableName, searchParams)
+func (db *dbClient) GetValuesFromCompositeTable(ctx context.Context, tableName string, searchParams map[string]interface{}) (*sql.Rows, error) {
+	selectQuery, args, err := db.GetSelectQueryForCompositeTable(tableName, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetValuesFromCompositeTable: %v", err)
+		return nil, fmt.Errorf("GetValuesFromCompositeTable: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetValuesFromCompositeTable: %v", err)
+		return nil, fmt.Errorf("GetValuesFromCompositeTable: %w", err)
 	}
 
 	return rows, nil
 }
 
-func (db *dbClient) DeleteValuesFromCompositeTable(tableName string, deleteParams map[string]interface{}) (sql.Result, error) {
-	deleteQuery, err := db.GetDeleteQueryForCompositeTable(tableName, deleteParams)
+func (db *dbClient) DeleteValuesFromCompositeTable(ctx context.Context, tableName string, deleteParams map[string]interface{}) (sql.Result, error) {
+	deleteQuery, args, err := db.GetDeleteQueryForCompositeTable(tableName, deleteParams)
 	if err != nil {
-		return nil, fmt.Errorf("DeleteValuesInCompositeTable: %v", err)
+		return nil, fmt.Errorf("DeleteValuesInCompositeTable: %w", err)
 	}
 
-	result, err := db.RunDeleteQuery(deleteQuery)
+	result, err := db.RunDeleteQuery(ctx, deleteQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("DeleteValuesInCompositeTable: %v", err)
+		return nil, fmt.Errorf("DeleteValuesInCompositeTable: %w", err)
 	}
 
 	return result, nil
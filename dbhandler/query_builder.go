@@ -0,0 +1,302 @@
+package dbhandler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// queryBuilder generates parameterized statements for a single table. Values are never
+// written into the SQL text, they are collected in args and referenced as $1..$n
+type queryBuilder struct {
+	tableName string
+	columns   []string
+	args      []interface{}
+}
+
+func newQueryBuilder(tableName string, columns []string) *queryBuilder {
+	return &queryBuilder{
+		tableName: tableName,
+		columns:   columns,
+	}
+}
+
+// bind adds value to the argument list and returns its placeholder
+func (qb *queryBuilder) bind(value interface{}) string {
+	qb.args = append(qb.args, value)
+	return fmt.Sprintf("$%d", len(qb.args))
+}
+
+// checkColumn makes sure only known columns of the table end up in the query
+func (qb *queryBuilder) checkColumn(column string) error {
+	for _, c := range qb.columns {
+		if c == column {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown column %q for table %s", column, qb.tableName)
+}
+
+func (qb *queryBuilder) insert(values []interface{}) (string, []interface{}, error) {
+	if len(values) == 0 {
+		return "", nil, errors.New("no values given")
+	}
+
+	if len(values) != len(qb.columns) {
+		return "", nil, fmt.Errorf("%d values given for %d columns of table %s", len(values), len(qb.columns), qb.tableName)
+	}
+
+	placeholders := make([]string, 0, len(values))
+	for _, v := range values {
+		placeholders = append(placeholders, qb.bind(v))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", qb.tableName, strings.Join(qb.columns, ", "),
+		strings.Join(placeholders, ", "))
+
+	return query, qb.args, nil
+}
+
+func (qb *queryBuilder) selectWhere(searchParams map[string]interface{}) (string, []interface{}, error) {
+	where, err := qb.where(searchParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(qb.columns, ", "), qb.tableName, where)
+
+	return query, qb.args, nil
+}
+
+// selectBetween is selectWhere limited to the rows whose column lies in [start, end), ordered by
+// that column
+func (qb *queryBuilder) selectBetween(searchParams map[string]interface{}, column string, start, end interface{}) (string, []interface{}, error) {
+	if err := qb.checkColumn(column); err != nil {
+		return "", nil, err
+	}
+
+	where, err := qb.where(searchParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if where == "" {
+		where = " WHERE "
+	} else {
+		where += " AND "
+	}
+	where += fmt.Sprintf("%s >= %s AND %s < %s", column, qb.bind(start), column, qb.bind(end))
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s, _id", strings.Join(qb.columns, ", "), qb.tableName, where,
+		column)
+
+	return query, qb.args, nil
+}
+
+// selectPage selects the _id and sort columns of a page of the rows matching searchParams and
+// options.Filter, ordered by options.Sort and then _id. One row more than options.Limit is read to
+// tell whether another page follows.
+func (qb *queryBuilder) selectPage(searchParams map[string]interface{}, options *models.ListOptions) (string, []interface{}, error) {
+	columns := []string{"_id"}
+	order := make([]string, 0, len(options.Sort)+1)
+	for _, f := range options.Sort {
+		if err := qb.checkColumn(f.Column); err != nil {
+			return "", nil, err
+		}
+
+		columns = append(columns, f.Column)
+		if f.Desc {
+			order = append(order, f.Column+" DESC")
+		} else {
+			order = append(order, f.Column)
+		}
+	}
+	order = append(order, "_id")
+
+	where, err := qb.filteredWhere(searchParams, options.Filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(options.After) > 0 {
+		after, err := qb.after(options)
+		if err != nil {
+			return "", nil, err
+		}
+
+		where = andWhere(where, after)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %s OFFSET %s", strings.Join(columns, ", "),
+		qb.tableName, where, strings.Join(order, ", "), qb.bind(options.Limit+1), qb.bind(options.Offset))
+
+	return query, qb.args, nil
+}
+
+// after returns the condition of the rows that come after options.After in the order of the page.
+// Each column can be sorted its own way, so it is written out as (a > $1) OR (a = $1 AND b > $2)...
+func (qb *queryBuilder) after(options *models.ListOptions) (string, error) {
+	sortFields := append(append([]models.SortField{}, options.Sort...), models.SortField{Column: "_id"})
+	if len(options.After) != len(sortFields) {
+		return "", errors.New("cursor does not match the sort order")
+	}
+
+	placeholders := make([]string, 0, len(sortFields))
+	for _, v := range options.After {
+		placeholders = append(placeholders, qb.bind(v))
+	}
+
+	alternatives := make([]string, 0, len(sortFields))
+	for i, f := range sortFields {
+		conditions := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, fmt.Sprintf("%s = %s", sortFields[j].Column, placeholders[j]))
+		}
+
+		operator := ">"
+		if f.Desc {
+			operator = "<"
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s %s", f.Column, operator, placeholders[i]))
+
+		alternatives = append(alternatives, "("+strings.Join(conditions, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+// count counts the rows matching searchParams and filter
+func (qb *queryBuilder) count(searchParams map[string]interface{}, filter *models.Filter) (string, []interface{}, error) {
+	where, err := qb.filteredWhere(searchParams, filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s%s", qb.tableName, where), qb.args, nil
+}
+
+// filteredWhere is where narrowed down by the condition of filter
+func (qb *queryBuilder) filteredWhere(searchParams map[string]interface{}, filter *models.Filter) (string, error) {
+	where, err := qb.where(searchParams)
+	if err != nil {
+		return "", err
+	}
+
+	condition, err := qb.filter(filter)
+	if err != nil {
+		return "", err
+	}
+
+	return andWhere(where, condition), nil
+}
+
+// andWhere adds condition to the WHERE clause where, which may be empty
+func andWhere(where, condition string) string {
+	switch {
+	case condition == "":
+		return where
+	case where == "":
+		return " WHERE " + condition
+	}
+
+	return where + " AND " + condition
+}
+
+// updateWhere sets the columns in updates of the matching rows, the version of tables that have
+// one is moved on as well
+func (qb *queryBuilder) updateWhere(updates map[string]interface{}, searchParams map[string]interface{}) (string, []interface{}, error) {
+	assignments := make([]string, 0, len(updates)+1)
+	for _, k := range sortedKeys(updates) {
+		if err := qb.checkColumn(k); err != nil {
+			return "", nil, err
+		}
+
+		assignments = append(assignments, fmt.Sprintf("%s = %s", k, qb.bind(updates[k])))
+	}
+
+	if qb.checkColumn(VERSION_COLUMN) == nil {
+		assignments = append(assignments, fmt.Sprintf("%s = %s + 1", VERSION_COLUMN, VERSION_COLUMN))
+	}
+
+	if len(assignments) == 0 {
+		return "", nil, errors.New("no updates given")
+	}
+
+	where, err := qb.where(searchParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s%s", qb.tableName, strings.Join(assignments, ", "), where)
+
+	return query, qb.args, nil
+}
+
+func (qb *queryBuilder) deleteWhere(searchParams map[string]interface{}) (string, []interface{}, error) {
+	if len(searchParams) == 0 {
+		return "", nil, errors.New("refusing to delete without conditions")
+	}
+
+	where, err := qb.where(searchParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s%s", qb.tableName, where)
+
+	return query, qb.args, nil
+}
+
+func (qb *queryBuilder) where(searchParams map[string]interface{}) (string, error) {
+	if len(searchParams) == 0 {
+		return "", nil
+	}
+
+	conditions := make([]string, 0, len(searchParams))
+	for _, k := range sortedKeys(searchParams) {
+		if err := qb.checkColumn(k); err != nil {
+			return "", err
+		}
+
+		// A nil value matches NULL, the way the memory driver compares it
+		if searchParams[k] == nil {
+			conditions = append(conditions, fmt.Sprintf("%s IS NULL", k))
+			continue
+		}
+
+		// A list of values matches any of them
+		if values, ok := searchParams[k].([]string); ok {
+			conditions = append(conditions, fmt.Sprintf("%s = ANY(%s)", k, qb.bind(pq.Array(values))))
+			continue
+		}
+
+		conditions = append(conditions, fmt.Sprintf("%s = %s", k, qb.bind(searchParams[k])))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), nil
+}
+
+// sortedKeys keeps generated SQL stable regardless of map iteration order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// nullIfEmpty stores empty references as NULL so foreign keys stay valid
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
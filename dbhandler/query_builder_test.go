@@ -0,0 +1,212 @@
+package dbhandler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+var testColumns = []string{"_id", "name", "rate", "workspace_id", "version"}
+
+func TestQueryBuilderWhere(t *testing.T) {
+	tests := []struct {
+		name         string
+		searchParams map[string]interface{}
+		wantQuery    string
+		wantArgs     []interface{}
+		wantErr      bool
+	}{
+		{
+			name:      "no conditions",
+			wantQuery: "SELECT _id, name, rate, workspace_id, version FROM tag",
+		},
+		{
+			name:         "conditions in column order",
+			searchParams: map[string]interface{}{"workspace_id": "w_1", "name": "a"},
+			wantQuery:    "SELECT _id, name, rate, workspace_id, version FROM tag WHERE name = $1 AND workspace_id = $2",
+			wantArgs:     []interface{}{"a", "w_1"},
+		},
+		{
+			name:         "nil matches NULL",
+			searchParams: map[string]interface{}{"workspace_id": nil, "name": "a"},
+			wantQuery:    "SELECT _id, name, rate, workspace_id, version FROM tag WHERE name = $1 AND workspace_id IS NULL",
+			wantArgs:     []interface{}{"a"},
+		},
+		{
+			name:         "list matches any value",
+			searchParams: map[string]interface{}{"_id": []string{"t_1", "t_2"}},
+			wantQuery:    "SELECT _id, name, rate, workspace_id, version FROM tag WHERE _id = ANY($1)",
+			wantArgs:     []interface{}{pq.Array([]string{"t_1", "t_2"})},
+		},
+		{
+			name:         "unknown column",
+			searchParams: map[string]interface{}{"name; DROP TABLE tag": "a"},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := newQueryBuilder("tag", testColumns).selectWhere(tt.searchParams)
+			checkQuery(t, query, args, err, tt.wantQuery, tt.wantArgs, tt.wantErr)
+		})
+	}
+}
+
+func TestQueryBuilderVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     func(qb *queryBuilder) (string, []interface{}, error)
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{
+			name: "update moves the version on",
+			build: func(qb *queryBuilder) (string, []interface{}, error) {
+				return qb.updateWhere(map[string]interface{}{"rate": 2.0, "name": "b"}, map[string]interface{}{"_id": "t_1"})
+			},
+			wantQuery: "UPDATE tag SET name = $1, rate = $2, version = version + 1 WHERE _id = $3",
+			wantArgs:  []interface{}{"b", 2.0, "t_1"},
+		},
+		{
+			name: "update at a version",
+			build: func(qb *queryBuilder) (string, []interface{}, error) {
+				return qb.updateWhere(map[string]interface{}{"name": "b"},
+					map[string]interface{}{"_id": "t_1", "version": 3})
+			},
+			wantQuery: "UPDATE tag SET name = $1, version = version + 1 WHERE _id = $2 AND version = $3",
+			wantArgs:  []interface{}{"b", "t_1", 3},
+		},
+		{
+			name: "update of a table without versions",
+			build: func(qb *queryBuilder) (string, []interface{}, error) {
+				return newQueryBuilder("task_tag", []string{"task_id", "tag_id"}).updateWhere(
+					map[string]interface{}{"tag_id": "tg_2"}, map[string]interface{}{"task_id": "t_1"})
+			},
+			wantQuery: "UPDATE task_tag SET tag_id = $1 WHERE task_id = $2",
+			wantArgs:  []interface{}{"tg_2", "t_1"},
+		},
+		{
+			name: "update of an unknown column",
+			build: func(qb *queryBuilder) (string, []interface{}, error) {
+				return qb.updateWhere(map[string]interface{}{"color": "red"}, map[string]interface{}{"_id": "t_1"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "delete at a version",
+			build: func(qb *queryBuilder) (string, []interface{}, error) {
+				return qb.deleteWhere(map[string]interface{}{"_id": "t_1", "version": 2})
+			},
+			wantQuery: "DELETE FROM tag WHERE _id = $1 AND version = $2",
+			wantArgs:  []interface{}{"t_1", 2},
+		},
+		{
+			name: "delete without conditions",
+			build: func(qb *queryBuilder) (string, []interface{}, error) {
+				return qb.deleteWhere(nil)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := tt.build(newQueryBuilder("tag", testColumns))
+			checkQuery(t, query, args, err, tt.wantQuery, tt.wantArgs, tt.wantErr)
+		})
+	}
+}
+
+func TestQueryBuilderSelectPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		searchParams map[string]interface{}
+		options      *models.ListOptions
+		wantQuery    string
+		wantArgs     []interface{}
+		wantErr      bool
+	}{
+		{
+			name:      "ordered by _id",
+			options:   &models.ListOptions{Limit: 10},
+			wantQuery: "SELECT _id FROM tag ORDER BY _id LIMIT $1 OFFSET $2",
+			wantArgs:  []interface{}{11, 0},
+		},
+		{
+			name:         "sorted and filtered",
+			searchParams: map[string]interface{}{"workspace_id": "w_1"},
+			options: &models.ListOptions{
+				Filter: &models.Filter{Column: "name", Operator: models.FILTER_CONTAINS, Value: "ab"},
+				Sort:   []models.SortField{{Column: "rate", Desc: true}, {Column: "name"}},
+				Limit:  5,
+				Offset: 10,
+			},
+			wantQuery: "SELECT _id, rate, name FROM tag WHERE workspace_id = $1 AND strpos(lower(name), lower($2)) > 0 " +
+				"ORDER BY rate DESC, name, _id LIMIT $3 OFFSET $4",
+			wantArgs: []interface{}{"w_1", "ab", 6, 10},
+		},
+		{
+			name: "after a cursor",
+			options: &models.ListOptions{
+				Sort:  []models.SortField{{Column: "rate", Desc: true}, {Column: "name"}},
+				Limit: 5,
+				After: []interface{}{2.5, "b", "t_1"},
+			},
+			wantQuery: "SELECT _id, rate, name FROM tag WHERE ((rate < $1) OR (rate = $1 AND name > $2) OR " +
+				"(rate = $1 AND name = $2 AND _id > $3)) ORDER BY rate DESC, name, _id LIMIT $4 OFFSET $5",
+			wantArgs: []interface{}{2.5, "b", "t_1", 6, 0},
+		},
+		{
+			name: "cursor of another sort order",
+			options: &models.ListOptions{
+				Sort:  []models.SortField{{Column: "rate"}},
+				Limit: 5,
+				After: []interface{}{"t_1"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort column",
+			options: &models.ListOptions{Sort: []models.SortField{{Column: "color"}}, Limit: 5},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := newQueryBuilder("tag", testColumns).selectPage(tt.searchParams, tt.options)
+			checkQuery(t, query, args, err, tt.wantQuery, tt.wantArgs, tt.wantErr)
+		})
+	}
+}
+
+// checkQuery compares a generated statement and its arguments with the expected ones
+func checkQuery(t *testing.T, query string, args []interface{}, err error, wantQuery string, wantArgs []interface{},
+	wantErr bool) {
+	t.Helper()
+
+	if wantErr {
+		if err == nil {
+			t.Fatalf("expected an error, got %q", query)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if query != wantQuery {
+		t.Errorf("query\n got: %s\nwant: %s", query, wantQuery)
+	}
+
+	if len(args) != 0 || len(wantArgs) != 0 {
+		if !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("args\n got: %#v\nwant: %#v", args, wantArgs)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package dbhandler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// reportGroupColumns holds the key and name a summary report is grouped by for each grouping
+var reportGroupColumns = map[string][2]string{
+	models.REPORT_GROUP_PROJECT: {"COALESCE(t.project_id, '')", "COALESCE(p.name, '')"},
+	models.REPORT_GROUP_CLIENT:  {"COALESCE(p.client_id, '')", "COALESCE(c.name, '')"},
+	models.REPORT_GROUP_USER:    {"COALESCE(t.user_id, '')", "COALESCE(u.name, '')"},
+	models.REPORT_GROUP_TAG:     {"COALESCE(tt.tag_id, '')", "COALESCE(tg.name, '')"},
+}
+
+// reportDuration is the length of a time entry in seconds
+const reportDuration = "EXTRACT(EPOCH FROM (t.end_time - t.start_time))"
+
+// reportRate is what a billable time entry is charged per hour, the billable rate of its project
+// or, when the project has none, of the team member who tracked it. The project rate comes first
+// because it is the price agreed with the client for the work and so has to be the same whoever
+// does it; the rate of a member only prices work on projects without one. Projects and invoices
+// charge by the same rule, so a report amount matches what is invoiced.
+const reportRate = "COALESCE(NULLIF(p.billable_rate, 0), tm.billable_rate, 0)"
+
+// reportFrom joins what report filters and groupings need to a time entry
+const reportFrom = `FROM public.task t
+	LEFT JOIN public.project p ON p._id = t.project_id
+	LEFT JOIN public.client c ON c._id = p.client_id
+	LEFT JOIN public."user" u ON u._id = t.user_id
+	LEFT JOIN public.team_member tm ON tm.user_email = u.email AND tm.workspace_id = t.workspace_id`
+
+// reportRow defines the totals of one combination of group keys
+type reportRow struct {
+	keys   []string
+	names  []string
+	hours  float64
+	amount float64
+}
+
+// getReportWhere returns the conditions of filter and their arguments
+func getReportWhere(filter *models.ReportFilter) (string, []interface{}, error) {
+	args := make([]interface{}, 0)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{
+		"t.end_time IS NOT NULL",
+		"t.workspace_id = " + arg(filter.Workspace),
+		"t.start_time >= " + arg(filter.Start),
+		"t.start_time < " + arg(filter.End),
+	}
+
+	if filter.Client != "" {
+		conditions = append(conditions, "p.client_id = "+arg(filter.Client))
+	}
+	if filter.User != "" {
+		conditions = append(conditions, "t.user_id = "+arg(filter.User))
+	}
+	if filter.Billable != nil {
+		conditions = append(conditions, "t.billable = "+arg(*filter.Billable))
+	}
+	if filter.Invoiced != nil {
+		if *filter.Invoiced {
+			conditions = append(conditions, "t.invoice_item_id IS NOT NULL")
+		} else {
+			conditions = append(conditions, "t.invoice_item_id IS NULL")
+		}
+	}
+
+	// The conditions of the task list name the columns of the task table alone, so they are applied
+	// to it rather than to the joined tables
+	if filter.Filter != nil {
+		qb := newQueryBuilder("task", getColumnsForStruct(models.Task{}))
+		qb.args = args
+
+		condition, err := qb.filter(filter.Filter)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if condition != "" {
+			conditions = append(conditions, "t._id IN (SELECT _id FROM public.task WHERE "+condition+")")
+		}
+		args = qb.args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// GetSummaryReport sums each grouping level in the same snapshot, so that the levels add up
+func (db *dbClient) GetSummaryReport(ctx context.Context, filter *models.ReportFilter, groupBy []string) (*models.SummaryReport, error) {
+	levels := make([][]*reportRow, 0, len(groupBy)+1)
+	err := db.withSnapshot(ctx, func(tx *dbClient) error {
+		for i := 0; i <= len(groupBy); i++ {
+			rows, err := tx.getReportRows(ctx, filter, groupBy[:i])
+			if err != nil {
+				return err
+			}
+
+			levels = append(levels, rows)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetSummaryReport: %w", err)
+	}
+
+	return buildSummaryReport(filter, groupBy, levels), nil
+}
+
+// getReportRows sums the time entries matching filter for each combination of groupBy keys
+func (db *dbClient) getReportRows(ctx context.Context, filter *models.ReportFilter, groupBy []string) ([]*reportRow, error) {
+	selects := make([]string, 0, 2*len(groupBy)+2)
+	groups := make([]string, 0, 2*len(groupBy))
+	from := reportFrom
+	for _, g := range groupBy {
+		columns, ok := reportGroupColumns[g]
+		if !ok {
+			return nil, fmt.Errorf("getReportRows: unknown grouping %s", g)
+		}
+
+		selects = append(selects, columns[0], columns[1])
+		groups = append(groups, columns[0], columns[1])
+
+		if g == models.REPORT_GROUP_TAG {
+			from += fmt.Sprintf(`
+	LEFT JOIN public.%s tt ON tt.task_id = t._id
+	LEFT JOIN public.tag tg ON tg._id = tt.tag_id`, TASK_TAG)
+		}
+	}
+
+	selects = append(selects,
+		fmt.Sprintf("COALESCE(SUM(%s), 0) / 3600", reportDuration),
+		fmt.Sprintf("COALESCE(SUM(CASE WHEN t.billable THEN %s * %s END), 0) / 3600", reportDuration, reportRate))
+
+	where, args, err := getReportWhere(filter)
+	if err != nil {
+		return nil, fmt.Errorf("getReportRows: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s %s%s", strings.Join(selects, ", "), from, where)
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ")
+	}
+
+	rows, err := db.RunSelectQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("getReportRows: %w", err)
+	}
+	defer rows.Close()
+
+	reportRows := make([]*reportRow, 0)
+	for rows.Next() {
+		r := &reportRow{keys: make([]string, len(groupBy)), names: make([]string, len(groupBy))}
+
+		dest := make([]interface{}, 0, len(selects))
+		for i := range groupBy {
+			dest = append(dest, &r.keys[i], &r.names[i])
+		}
+		dest = append(dest, &r.hours, &r.amount)
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			return nil, fmt.Errorf("getReportRows: %w", err)
+		}
+
+		reportRows = append(reportRows, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getReportRows: %w", err)
+	}
+
+	return reportRows, nil
+}
+
+// buildSummaryReport nests the rows of each grouping level under their parent group, levels[i]
+// holds the rows grouped by the first i groupings. Every level is summed on its own rather than
+// from the level below as an entry can be in several tag groups. Groups are sorted by name.
+func buildSummaryReport(filter *models.ReportFilter, groupBy []string, levels [][]*reportRow) *models.SummaryReport {
+	report := &models.SummaryReport{
+		Start:   filter.Start,
+		End:     filter.End,
+		GroupBy: groupBy,
+		Groups:  make([]*models.ReportGroup, 0),
+	}
+
+	for _, r := range levels[0] {
+		report.Hours += r.hours
+		report.Amount += r.amount
+	}
+
+	if len(groupBy) == 0 {
+		return report
+	}
+
+	parents := make(map[string]*models.ReportGroup)
+	for _, r := range levels[1] {
+		parent := &models.ReportGroup{ID: r.keys[0], Name: r.names[0], Hours: r.hours, Amount: r.amount}
+		parents[r.keys[0]] = parent
+		report.Groups = append(report.Groups, parent)
+	}
+
+	if len(groupBy) > 1 {
+		for _, r := range levels[2] {
+			parent, ok := parents[r.keys[0]]
+			if !ok {
+				continue
+			}
+
+			parent.Groups = append(parent.Groups, &models.ReportGroup{ID: r.keys[1], Name: r.names[1],
+				Hours: r.hours, Amount: r.amount})
+		}
+	}
+
+	sortReportGroups(report.Groups)
+	for _, g := range report.Groups {
+		sortReportGroups(g.Groups)
+	}
+
+	return report
+}
+
+func sortReportGroups(groups []*models.ReportGroup) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+
+		return groups[i].ID < groups[j].ID
+	})
+}
+
+// reportEntriesQuery selects the time entries of a detailed report with the names of what they
+// belong to, the conditions and paging are added to it
+const reportEntriesQuery = `SELECT t._id, t.description, COALESCE(t.project_id, ''), COALESCE(p.name, ''),
+		COALESCE(p.client_id, ''), COALESCE(c.name, ''), COALESCE(t.user_id, ''), COALESCE(u.name, ''),
+		ARRAY(SELECT tg.name FROM public.task_tag tt JOIN public.tag tg ON tg._id = tt.tag_id
+			WHERE tt.task_id = t._id ORDER BY tg.name),
+		t.start_time, t.end_time, t.billable, ` + reportRate + `
+	`
+
+// GetDetailedReport reads the totals, the number of entries and the page in one snapshot, so they
+// agree with each other while entries are written
+func (db *dbClient) GetDetailedReport(ctx context.Context, filter *models.ReportFilter, page, pageSize int) (*models.DetailedReport, error) {
+	report := &models.DetailedReport{
+		Start:    filter.Start,
+		End:      filter.End,
+		Page:     page,
+		PageSize: pageSize,
+		Entries:  make([]*models.DetailedEntry, 0),
+	}
+
+	err := db.withSnapshot(ctx, func(tx *dbClient) error {
+		return tx.getDetailedReport(ctx, filter, report)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetDetailedReport: %w", err)
+	}
+
+	return report, nil
+}
+
+// getDetailedReport fills in the totals and the entries of the page of report
+func (db *dbClient) getDetailedReport(ctx context.Context, filter *models.ReportFilter, report *models.DetailedReport) error {
+	totals, err := db.getReportRows(ctx, filter, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range totals {
+		report.Hours += r.hours
+		report.Amount += r.amount
+	}
+
+	where, args, err := getReportWhere(filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.RunSelectQuery(ctx, "SELECT COUNT(*) "+reportFrom+where, args...)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		err = rows.Scan(&report.Total)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+
+	args = append(args, report.PageSize, (report.Page-1)*report.PageSize)
+	query := fmt.Sprintf("%s%s%s ORDER BY t.start_time, t._id LIMIT $%d OFFSET $%d", reportEntriesQuery, reportFrom, where,
+		len(args)-1, len(args))
+
+	rows, err = db.RunSelectQuery(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanDetailedEntry(rows)
+		if err != nil {
+			return err
+		}
+
+		report.Entries = append(report.Entries, e)
+	}
+
+	return rows.Err()
+}
+
+func (db *dbClient) EachDetailedEntry(ctx context.Context, filter *models.ReportFilter, fn func(e *models.DetailedEntry) error) error {
+	where, args, err := getReportWhere(filter)
+	if err != nil {
+		return fmt.Errorf("EachDetailedEntry: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, reportEntriesQuery+reportFrom+where+" ORDER BY t.start_time, t._id", args...)
+	if err != nil {
+		return fmt.Errorf("EachDetailedEntry: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanDetailedEntry(rows)
+		if err != nil {
+			return fmt.Errorf("EachDetailedEntry: %w", err)
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("EachDetailedEntry: %w", err)
+	}
+
+	return nil
+}
+
+// scanDetailedEntry reads the entry at the current row of a reportEntriesQuery
+func scanDetailedEntry(rows *sql.Rows) (*models.DetailedEntry, error) {
+	e := &models.DetailedEntry{}
+
+	err := rows.Scan(&e.ID, &e.Description, &e.ProjectID, &e.ProjectName, &e.ClientID, &e.ClientName, &e.UserID,
+		&e.UserName, pq.Array(&e.Tags), &e.StartTime, &e.EndTime, &e.Billable, &e.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	e.SetAmount()
+	return e, nil
+}
@@ -1,6 +1,7 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,48 +11,49 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddTag(tag *models.Tag) (*models.Tag, int, error) {
+func (db *dbClient) AddTag(ctx context.Context, tag *models.Tag) (*models.Tag, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
 	}
 	tag.ID = fmt.Sprintf("t_%v", id)
+	tag.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*tag)
+	insertQuery, args, err := db.GetInsertQuery(*tag)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTag: %v", err)
+		return nil, -1, fmt.Errorf("AddTag: %w", err)
 	}
 
-	_, err = db.RunInsertQuery(insertQuery)
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTag: %v", err)
+		return nil, -1, fmt.Errorf("AddTag: %w", err)
 	}
 
 	return tag, http.StatusOK, nil
 }
 
-func (db *dbClient) GetAllTags() ([]*models.Tag, error) {
-	tags, err := db.GetTagsWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllTags(ctx context.Context) ([]*models.Tag, error) {
+	tags, err := db.GetTagsWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllTags: %v", err)
+		return nil, fmt.Errorf("GetAllTags: %w", err)
 	}
 
 	return tags, nil
 }
 
-func (db *dbClient) GetTag(tagID string) (*models.Tag, error) {
+func (db *dbClient) GetTag(ctx context.Context, tagID string) (*models.Tag, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = tagID
 
-	tags, err := db.GetTagsWithFilters(selectParams)
+	tags, err := db.GetTagsWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTag: %v", err)
+		return nil, fmt.Errorf("GetTag: %w", err)
 	}
 
 	var tag *models.Tag
 	if tags == nil || len(tags) <= 0 {
-		return nil, fmt.Errorf("GetTag: %v", errors.New("tag with given id not found"))
+		return nil, fmt.Errorf("GetTag: %w", NotFound("tag with given id not found"))
 	} else {
 		tag = tags[0]
 	}
@@ -59,86 +61,86 @@ func (db *dbClient) GetTag(tagID string) (*models.Tag, error) {
 	return tag, nil
 }
 
-func (db *dbClient) GetTagsWithFilters(searchParams map[string]interface{}) ([]*models.Tag, error) {
+func (db *dbClient) GetTagsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Tag, error) {
 	p := models.Tag{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTagsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTagsWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetTagsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTagsWithFilters: %w", err)
 	}
 
-	tags, err := db.GetTagsFromRows(rows)
+	tags, err := db.GetTagsFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetTagsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTagsWithFilters: %w", err)
 	}
 
 	return tags, nil
 }
 
-func (db *dbClient) GetTagsFromRows(rows *sql.Rows) ([]*models.Tag, error) {
+func (db *dbClient) GetTagsFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Tag, error) {
+	defer rows.Close()
+
 	tags := make([]*models.Tag, 0)
 	for rows.Next() {
 		t := models.Tag{}
 
-		err := rows.Scan(&t.ID, &t.Name)
+		var workspaceID sql.NullString
+
+		err := rows.Scan(&t.ID, &t.Name, &workspaceID, &t.Version)
 
 		if err != nil {
-			return nil, fmt.Errorf("GetTagsFromRows: %v", err)
+			return nil, fmt.Errorf("GetTagsFromRows: %w", err)
+		}
+
+		if workspaceID.Valid {
+			t.Workspace = workspaceID.String
 		}
 
 		tags = append(tags, &t)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTagsFromRows: %w", err)
+	}
+
 	return tags, nil
 }
 
-func (db *dbClient) UpdateTag(tagID string, updates map[string]interface{}) (*models.Tag, error) {
-	updateQuery, err := db.GetUpdateQueryForStruct(models.Tag{}, tagID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateTag: %v", err)
-	}
-
+func (db *dbClient) UpdateTag(ctx context.Context, tagID string, updates map[string]interface{}) (*models.Tag, error) {
 	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
+		err := db.updateStruct(ctx, models.Tag{}, tagID, updates)
 		if err != nil {
-			return nil, fmt.Errorf("UpdateTag: %v", err)
+			return nil, fmt.Errorf("UpdateTag: %w", err)
 		}
 	}
 
-	tag, err := db.GetTag(tagID)
+	tag, err := db.GetTag(ctx, tagID)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateTag: %v", err)
+		return nil, fmt.Errorf("UpdateTag: %w", err)
 	}
 
 	return tag, nil
 }
 
-func (db *dbClient) DeleteTag(tagID string) error {
-	deleteParams := make(map[string]interface{})
+func (db *dbClient) DeleteTag(ctx context.Context, tagID string, version int) error {
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		deleteParamsForColumns := make(map[string]interface{})
+		deleteParamsForColumns["tag_id"] = tagID
 
-	deleteParams["_id"] = tagID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.Tag{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteTag: %v", err)
-	}
-
-	_, err = db.RunDeleteQuery(deleteQuery)
-	if err != nil {
-		return fmt.Errorf("DeleteTag: %v", err)
-	}
-
-	deleteParamsForColumns := make(map[string]interface{})
-	deleteParamsForColumns["tag_id"] = tagID
+		_, err := tx.DeleteValuesFromCompositeTable(ctx, TASK_TAG, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteTasksForTag: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(TASK_TAG, deleteParamsForColumns)
+		return tx.deleteStruct(ctx, models.Tag{}, tagID, version)
+	})
 	if err != nil {
-		return fmt.Errorf("DeleteTasksForTag: %v", err)
+		return fmt.Errorf("DeleteTag: %w", err)
 	}
 
 	return nil
@@ -1,45 +1,47 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/qasim-sajid/clockify-api/conf"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddTask(task *models.Task) (*models.Task, int, error) {
+func (db *dbClient) AddTask(ctx context.Context, task *models.Task) (*models.Task, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
 	}
 	task.ID = fmt.Sprintf("t_%v", id)
+	task.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*task)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddTask: %v", err)
-	}
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		insertQuery, args, err := tx.GetInsertQuery(*task)
+		if err != nil {
+			return err
+		}
 
-	_, err = db.RunInsertQuery(insertQuery)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddTask: %v", err)
-	}
+		_, err = tx.RunInsertQuery(ctx, insertQuery, args...)
+		if err != nil {
+			return err
+		}
 
-	err = db.AddTaskTags(task.ID, task.Tags)
+		return tx.AddTaskTags(ctx, task.ID, task.Tags)
+	})
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTask: %v", err)
+		return nil, -1, fmt.Errorf("AddTask: %w", err)
 	}
 
 	return task, http.StatusOK, nil
 }
 
-func (db *dbClient) AddTaskTags(taskID string, tags []string) error {
-	if tags == nil || tags[0] == "" {
+func (db *dbClient) AddTaskTags(ctx context.Context, taskID string, tags []string) error {
+	if len(tags) == 0 {
 		return nil
 	}
 
@@ -49,17 +51,17 @@ func (db *dbClient) AddTaskTags(taskID string, tags []string) error {
 		valuesMap["tag_id"] = t
 
 		//Check if value already exists
-		_, err := db.GetTagForTask(taskID, t)
+		_, err := db.GetTagForTask(ctx, taskID, t)
 		if err != nil {
 			//If value doesn't exist then insert it
-			insertQuery, err := db.GetInsertQueryForCompositeTable(TASK_TAG, valuesMap)
+			insertQuery, args, err := db.GetInsertQueryForCompositeTable(TASK_TAG, valuesMap)
 			if err != nil {
-				return fmt.Errorf("AddTaskTags: %v", err)
+				return fmt.Errorf("AddTaskTags: %w", err)
 			}
 
-			_, err = db.RunInsertQuery(insertQuery)
+			_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 			if err != nil {
-				return fmt.Errorf("AddTaskTags: %v", err)
+				return fmt.Errorf("AddTaskTags: %w", err)
 			}
 		}
 	}
@@ -67,10 +69,10 @@ func (db *dbClient) AddTaskTags(taskID string, tags []string) error {
 	return nil
 }
 
-func (db *dbClient) GetTagForTask(taskID, tagID string) (string, error) {
-	tags, err := db.GetTaskTags(taskID)
+func (db *dbClient) GetTagForTask(ctx context.Context, taskID, tagID string) (string, error) {
+	tags, err := db.GetTaskTags(ctx, taskID)
 	if err != nil {
-		return "", fmt.Errorf("GetTagForTask: %v", err)
+		return "", fmt.Errorf("GetTagForTask: %w", err)
 	}
 
 	for _, t := range tags {
@@ -79,31 +81,31 @@ func (db *dbClient) GetTagForTask(taskID, tagID string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("GetTagForTask: %v", errors.New("tag with given id not found"))
+	return "", fmt.Errorf("GetTagForTask: %w", NotFound("tag with given id not found"))
 }
 
-func (db *dbClient) GetAllTasks() ([]*models.Task, error) {
-	tasks, err := db.GetTasksWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	tasks, err := db.GetTasksWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllTasks: %v", err)
+		return nil, fmt.Errorf("GetAllTasks: %w", err)
 	}
 
 	return tasks, nil
 }
 
-func (db *dbClient) GetTask(taskID string) (*models.Task, error) {
+func (db *dbClient) GetTask(ctx context.Context, taskID string) (*models.Task, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = taskID
 
-	tasks, err := db.GetTasksWithFilters(selectParams)
+	tasks, err := db.GetTasksWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTask: %v", err)
+		return nil, fmt.Errorf("GetTask: %w", err)
 	}
 
 	var task *models.Task
 	if tasks == nil || len(tasks) <= 0 {
-		return nil, fmt.Errorf("GetTask: %v", errors.New("task with given id not found"))
+		return nil, fmt.Errorf("GetTask: %w", NotFound("task with given id not found"))
 	} else {
 		task = tasks[0]
 	}
@@ -111,84 +113,137 @@ func (db *dbClient) GetTask(taskID string) (*models.Task, error) {
 	return task, nil
 }
 
-func (db *dbClient) GetTasksWithFilters(searchParams map[string]interface{}) ([]*models.Task, error) {
+func (db *dbClient) GetTaskForUpdate(ctx context.Context, taskID string) (*models.Task, error) {
+	selectQuery, args, err := db.GetSelectQueryForStruct(models.Task{}, map[string]interface{}{"_id": taskID})
+	if err != nil {
+		return nil, fmt.Errorf("GetTaskForUpdate: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery+" FOR UPDATE", args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetTaskForUpdate: %w", err)
+	}
+
+	tasks, err := db.GetTasksFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetTaskForUpdate: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("GetTaskForUpdate: %w", NotFound("task with given id not found"))
+	}
+
+	return tasks[0], nil
+}
+
+func (db *dbClient) GetTasksWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Task, error) {
 	p := models.Task{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
+	if err != nil {
+		return nil, fmt.Errorf("GetTasksWithFilters: %w", err)
+	}
+
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetTasksWithFilters: %w", err)
+	}
+
+	tasks, err := db.GetTasksFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetTasksWithFilters: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (db *dbClient) GetTasksInRange(ctx context.Context, searchParams map[string]interface{}, start, end time.Time) ([]*models.Task, error) {
+	tableName, err := db.GetTableNameForStruct(models.Task{})
 	if err != nil {
-		return nil, fmt.Errorf("GetTasksWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTasksInRange: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	selectQuery, args, err := newQueryBuilder(tableName, db.GetColumnsForStruct(models.Task{})).selectBetween(searchParams,
+		"start_time", start, end)
 	if err != nil {
-		return nil, fmt.Errorf("GetTasksWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTasksInRange: %w", err)
 	}
 
-	tasks, err := db.GetTasksFromRows(rows)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetTasksWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTasksInRange: %w", err)
+	}
+
+	tasks, err := db.GetTasksFromRows(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetTasksInRange: %w", err)
 	}
 
 	return tasks, nil
 }
 
-func (db *dbClient) GetTasksFromRows(rows *sql.Rows) ([]*models.Task, error) {
+func (db *dbClient) GetTasksFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Task, error) {
+	defer rows.Close()
+
 	tasks := make([]*models.Task, 0)
 	for rows.Next() {
 		t := models.Task{}
 
 		var projectID sql.NullString
-		startTime := ""
-		endTime := ""
-		date := ""
+		var userID sql.NullString
+		var workspaceID sql.NullString
+		var invoiceItemID sql.NullString
+		var endTime sql.NullTime
 
-		err := rows.Scan(&t.ID, &t.Description, &t.Billable, &startTime, &endTime, &date, &t.IsActive, &projectID)
+		err := rows.Scan(&t.ID, &t.Description, &t.Billable, &t.StartTime, &endTime, &t.Date, &t.IsActive, &t.IsLocked, &projectID,
+			&userID, &workspaceID, &invoiceItemID, &t.Version)
 
 		if err != nil {
-			return nil, fmt.Errorf("GetTasksFromRows: %v", err)
+			return nil, fmt.Errorf("GetTasksFromRows: %w", err)
 		}
 
-		t.StartTime, err = time.Parse(conf.TIME_LAYOUT, startTime)
-		if err != nil {
-			return nil, fmt.Errorf("GetTasksFromRows: %v", err)
+		if endTime.Valid {
+			t.EndTime = &endTime.Time
 		}
 
-		t.EndTime, err = time.Parse(conf.TIME_LAYOUT, endTime)
-		if err != nil {
-			return nil, fmt.Errorf("GetTasksFromRows: %v", err)
-		}
+		t.Project = projectID.String
+		t.User = userID.String
+		t.Workspace = workspaceID.String
+		t.InvoiceItem = invoiceItemID.String
 
-		t.Date, err = time.Parse(conf.TIME_LAYOUT, date)
-		if err != nil {
-			return nil, fmt.Errorf("GetTasksFromRows: %v", err)
-		}
+		tasks = append(tasks, &t)
+	}
 
-		t.Project = projectID.String
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTasksFromRows: %w", err)
+	}
 
-		t.Tags, err = db.GetTaskTags(t.ID)
+	//Relations are loaded after rows are drained as a transaction runs one query at a time
+	var err error
+	for _, t := range tasks {
+		t.Tags, err = db.GetTaskTags(ctx, t.ID)
 		if err != nil {
-			return nil, fmt.Errorf("GetTasksFromRows: %v", err)
+			return nil, fmt.Errorf("GetTasksFromRows: %w", err)
 		}
-
-		tasks = append(tasks, &t)
 	}
 
 	return tasks, nil
 }
 
-func (db *dbClient) GetTaskTags(taskID string) ([]string, error) {
+func (db *dbClient) GetTaskTags(ctx context.Context, taskID string) ([]string, error) {
 	searchParams := make(map[string]interface{})
 	searchParams["task_id"] = taskID
 
-	selectQuery, err := db.GetSelectQueryForCompositeTable(TASK_TAG, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForCompositeTable(TASK_TAG, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTaskTags: %v", err)
+		return nil, fmt.Errorf("GetTaskTags: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetTaskTags: %v", err)
+		return nil, fmt.Errorf("GetTaskTags: %w", err)
 	}
+	defer rows.Close()
 
 	tags := make([]string, 0)
 	for rows.Next() {
@@ -196,7 +251,7 @@ func (db *dbClient) GetTaskTags(taskID string) ([]string, error) {
 
 		err := rows.Scan(&taskID, &tagID)
 		if err != nil {
-			return nil, fmt.Errorf("GetTaskTags: %v", err)
+			return nil, fmt.Errorf("GetTaskTags: %w", err)
 		}
 
 		tags = append(tags, tagID)
@@ -205,75 +260,101 @@ func (db *dbClient) GetTaskTags(taskID string) ([]string, error) {
 	return tags, nil
 }
 
-func (db *dbClient) UpdateTask(taskID string, updates map[string]interface{}) (*models.Task, error) {
-	if v, ok := updates["tags"]; ok {
-		tagIDs := strings.Split(v.(string), ",")
-		if len(tagIDs) > 0 && tagIDs[0] != "" {
-			err := db.UpdateTaskTags(taskID, tagIDs)
+func (db *dbClient) UpdateTask(ctx context.Context, taskID string, updates map[string]interface{}) (*models.Task, error) {
+	var task *models.Task
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		if tagIDs, ok := updates["tags"].([]string); ok {
+			err := tx.UpdateTaskTags(ctx, taskID, tagIDs)
 			if err != nil {
-				return nil, fmt.Errorf("UpdateTask: %v", err)
+				return err
 			}
+			delete(updates, "tags")
 		}
-		delete(updates, "tags")
-	}
 
-	updateQuery, err := db.GetUpdateQueryForStruct(models.Task{}, taskID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateTask: %v", err)
-	}
-
-	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
-		if err != nil {
-			return nil, fmt.Errorf("UpdateTask: %v", err)
+		if len(updates) > 0 {
+			err := tx.updateStruct(ctx, models.Task{}, taskID, updates)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	task, err := db.GetTask(taskID)
+		var err error
+		task, err = tx.GetTask(ctx, taskID)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("UpdateTask: %v", err)
+		return nil, fmt.Errorf("UpdateTask: %w", err)
 	}
 
 	return task, nil
 }
 
-func (db *dbClient) UpdateTaskTags(taskID string, tags []string) error {
+func (db *dbClient) UpdateTaskTags(ctx context.Context, taskID string, tags []string) error {
 	deleteParams := make(map[string]interface{})
 	deleteParams["task_id"] = taskID
-	_, err := db.DeleteValuesFromCompositeTable(TASK_TAG, deleteParams)
+	_, err := db.DeleteValuesFromCompositeTable(ctx, TASK_TAG, deleteParams)
 	if err != nil {
-		return fmt.Errorf("UpdateTaskTags: %v", err)
+		return fmt.Errorf("UpdateTaskTags: %w", err)
 	}
 
-	err = db.AddTaskTags(taskID, tags)
+	err = db.AddTaskTags(ctx, taskID, tags)
 	if err != nil {
-		return fmt.Errorf("UpdateTaskTags: %v", err)
+		return fmt.Errorf("UpdateTaskTags: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) DeleteTask(taskID string) error {
-	deleteParams := make(map[string]interface{})
+func (db *dbClient) DeleteTask(ctx context.Context, taskID string, version int) error {
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		deleteParamsForColumns := make(map[string]interface{})
+		deleteParamsForColumns["task_id"] = taskID
+
+		_, err := tx.DeleteValuesFromCompositeTable(ctx, TASK_TAG, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteTagsForTask: %w", err)
+		}
+
+		return tx.deleteStruct(ctx, models.Task{}, taskID, version)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteTask: %w", err)
+	}
 
-	deleteParams["_id"] = taskID
+	return nil
+}
+
+// InvoiceTasks sets the invoice item of the tasks that are not invoiced yet. Tasks invoiced by
+// another transaction in the meantime are left alone and fail it instead of being billed twice.
+func (db *dbClient) InvoiceTasks(ctx context.Context, invoiceItemID string, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
 
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.Task{}, deleteParams)
+	tableName, err := db.GetTableNameForStruct(models.Task{})
 	if err != nil {
-		return fmt.Errorf("DeleteTask: %v", err)
+		return fmt.Errorf("InvoiceTasks: %w", err)
 	}
 
-	_, err = db.RunDeleteQuery(deleteQuery)
+	updateQuery, args, err := newQueryBuilder(tableName, db.GetColumnsForStruct(models.Task{})).updateWhere(
+		map[string]interface{}{"invoice_item_id": invoiceItemID},
+		map[string]interface{}{"_id": taskIDs, "invoice_item_id": nil})
 	if err != nil {
-		return fmt.Errorf("DeleteTask: %v", err)
+		return fmt.Errorf("InvoiceTasks: %w", err)
 	}
 
-	deleteParamsForColumns := make(map[string]interface{})
-	deleteParamsForColumns["task_id"] = taskID
+	result, err := db.RunUpdateQuery(ctx, updateQuery, args...)
+	if err != nil {
+		return fmt.Errorf("InvoiceTasks: %w", err)
+	}
 
-	_, err = db.DeleteValuesFromCompositeTable(TASK_TAG, deleteParamsForColumns)
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("DeleteTagsForTask: %v", err)
+		return fmt.Errorf("InvoiceTasks: %w", err)
+	}
+
+	if rows != int64(len(taskIDs)) {
+		return fmt.Errorf("InvoiceTasks: %w", errTasksInvoiced)
 	}
 
 	return nil
@@ -1,43 +1,46 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddTeamGroup(teamGroup *models.TeamGroup) (*models.TeamGroup, int, error) {
+func (db *dbClient) AddTeamGroup(ctx context.Context, teamGroup *models.TeamGroup) (*models.TeamGroup, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
 	}
 	teamGroup.ID = fmt.Sprintf("tg_%v", id)
+	teamGroup.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*teamGroup)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamGroup: %v", err)
-	}
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		insertQuery, args, err := tx.GetInsertQuery(*teamGroup)
+		if err != nil {
+			return err
+		}
 
-	_, err = db.RunInsertQuery(insertQuery)
-	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamGroup: %v", err)
-	}
+		_, err = tx.RunInsertQuery(ctx, insertQuery, args...)
+		if err != nil {
+			return err
+		}
 
-	err = db.AddTeamGroupTeamMembers(teamGroup.ID, teamGroup.TeamMembers)
+		return tx.AddTeamGroupTeamMembers(ctx, teamGroup.ID, teamGroup.TeamMembers)
+	})
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamGroup: %v", err)
+		return nil, -1, fmt.Errorf("AddTeamGroup: %w", err)
 	}
 
 	return teamGroup, http.StatusOK, nil
 }
 
-func (db *dbClient) AddTeamGroupTeamMembers(teamGroupID string, teamMembers []string) error {
-	if teamMembers == nil || teamMembers[0] == "" {
+func (db *dbClient) AddTeamGroupTeamMembers(ctx context.Context, teamGroupID string, teamMembers []string) error {
+	if len(teamMembers) == 0 {
 		return nil
 	}
 
@@ -47,17 +50,17 @@ func (db *dbClient) AddTeamGroupTeamMembers(teamGroupID string, teamMembers []st
 		valuesMap["team_member_id"] = tm
 
 		//Check if value already exists
-		_, err := db.GetTeamMemberForTeamGroup(teamGroupID, tm)
+		_, err := db.GetTeamMemberForTeamGroup(ctx, teamGroupID, tm)
 		if err != nil {
 			//If value doesn't exist then insert it
-			insertQuery, err := db.GetInsertQueryForCompositeTable(TEAM_GROUP_TEAM_MEMBER, valuesMap)
+			insertQuery, args, err := db.GetInsertQueryForCompositeTable(TEAM_GROUP_TEAM_MEMBER, valuesMap)
 			if err != nil {
-				return fmt.Errorf("AddTeamGroupTeamMembers: %v", err)
+				return fmt.Errorf("AddTeamGroupTeamMembers: %w", err)
 			}
 
-			_, err = db.RunInsertQuery(insertQuery)
+			_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 			if err != nil {
-				return fmt.Errorf("AddTeamGroupTeamMembers: %v", err)
+				return fmt.Errorf("AddTeamGroupTeamMembers: %w", err)
 			}
 		}
 	}
@@ -65,10 +68,10 @@ func (db *dbClient) AddTeamGroupTeamMembers(teamGroupID string, teamMembers []st
 	return nil
 }
 
-func (db *dbClient) GetTeamMemberForTeamGroup(teamGroupID, teamMemberID string) (string, error) {
-	teamMembers, err := db.GetTeamGroupTeamMembers(teamGroupID)
+func (db *dbClient) GetTeamMemberForTeamGroup(ctx context.Context, teamGroupID, teamMemberID string) (string, error) {
+	teamMembers, err := db.GetTeamGroupTeamMembers(ctx, teamGroupID)
 	if err != nil {
-		return "", fmt.Errorf("GetTeamMemberForTeamGroup: %v", err)
+		return "", fmt.Errorf("GetTeamMemberForTeamGroup: %w", err)
 	}
 
 	for _, tm := range teamMembers {
@@ -77,31 +80,31 @@ func (db *dbClient) GetTeamMemberForTeamGroup(teamGroupID, teamMemberID string)
 		}
 	}
 
-	return "", fmt.Errorf("GetTeamMemberForTeamGroup: %v", errors.New("team member with given id not found"))
+	return "", fmt.Errorf("GetTeamMemberForTeamGroup: %w", NotFound("team member with given id not found"))
 }
 
-func (db *dbClient) GetAllTeamGroups() ([]*models.TeamGroup, error) {
-	teamGroup, err := db.GetTeamGroupsWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllTeamGroups(ctx context.Context) ([]*models.TeamGroup, error) {
+	teamGroup, err := db.GetTeamGroupsWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllTeamGroups: %v", err)
+		return nil, fmt.Errorf("GetAllTeamGroups: %w", err)
 	}
 
 	return teamGroup, nil
 }
 
-func (db *dbClient) GetTeamGroup(teamGroupID string) (*models.TeamGroup, error) {
+func (db *dbClient) GetTeamGroup(ctx context.Context, teamGroupID string) (*models.TeamGroup, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = teamGroupID
 
-	teamGroups, err := db.GetTeamGroupsWithFilters(selectParams)
+	teamGroups, err := db.GetTeamGroupsWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamGroup: %v", err)
+		return nil, fmt.Errorf("GetTeamGroup: %w", err)
 	}
 
 	var teamGroup *models.TeamGroup
 	if teamGroups == nil || len(teamGroups) <= 0 {
-		return nil, fmt.Errorf("GetTeamGroup: %v", errors.New("team group with given id not found"))
+		return nil, fmt.Errorf("GetTeamGroup: %w", NotFound("team group with given id not found"))
 	} else {
 		teamGroup = teamGroups[0]
 	}
@@ -109,62 +112,73 @@ func (db *dbClient) GetTeamGroup(teamGroupID string) (*models.TeamGroup, error)
 	return teamGroup, nil
 }
 
-func (db *dbClient) GetTeamGroupsWithFilters(searchParams map[string]interface{}) ([]*models.TeamGroup, error) {
+func (db *dbClient) GetTeamGroupsWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamGroup, error) {
 	p := models.TeamGroup{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %w", err)
 	}
 
-	teamGroups, err := db.GetTeamGroupsFromRows(rows)
+	teamGroups, err := db.GetTeamGroupsFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamGroupsWithFilters: %w", err)
 	}
 
 	return teamGroups, nil
 }
 
-func (db *dbClient) GetTeamGroupsFromRows(rows *sql.Rows) ([]*models.TeamGroup, error) {
+func (db *dbClient) GetTeamGroupsFromRows(ctx context.Context, rows *sql.Rows) ([]*models.TeamGroup, error) {
+	defer rows.Close()
+
 	teamGroups := make([]*models.TeamGroup, 0)
 	for rows.Next() {
 		tg := models.TeamGroup{}
 
 		var workspaceID sql.NullString
 
-		err := rows.Scan(&tg.ID, &tg.Name, &workspaceID)
+		err := rows.Scan(&tg.ID, &tg.Name, &workspaceID, &tg.Version)
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamGroupsFromRows: %v", err)
+			return nil, fmt.Errorf("GetTeamGroupsFromRows: %w", err)
 		}
 
 		if workspaceID.Valid {
 			tg.Workspace = workspaceID.String
 		}
 
-		tg.TeamMembers, err = db.GetTeamGroupTeamMembers(tg.ID)
+		teamGroups = append(teamGroups, &tg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTeamGroupsFromRows: %w", err)
+	}
+
+	//Relations are loaded after rows are drained as a transaction runs one query at a time
+	var err error
+	for _, tg := range teamGroups {
+		tg.TeamMembers, err = db.GetTeamGroupTeamMembers(ctx, tg.ID)
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamGroupsFromRows: %v", err)
+			return nil, fmt.Errorf("GetTeamGroupsFromRows: %w", err)
 		}
-
-		teamGroups = append(teamGroups, &tg)
 	}
 
 	return teamGroups, nil
 }
 
-func (db *dbClient) GetTeamGroupTeamMembers(teamGroupID string) ([]string, error) {
+func (db *dbClient) GetTeamGroupTeamMembers(ctx context.Context, teamGroupID string) ([]string, error) {
 	searchParams := make(map[string]interface{})
 	searchParams["team_group_id"] = teamGroupID
 
-	rows, err := db.GetValuesFromCompositeTable(TEAM_GROUP_TEAM_MEMBER, searchParams)
+	rows, err := db.GetValuesFromCompositeTable(ctx, TEAM_GROUP_TEAM_MEMBER, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamGroupTeamMembers: %v", err)
+		return nil, fmt.Errorf("GetTeamGroupTeamMembers: %w", err)
 	}
+	defer rows.Close()
 
 	teamMembers := make([]string, 0)
 	for rows.Next() {
@@ -172,7 +186,7 @@ func (db *dbClient) GetTeamGroupTeamMembers(teamGroupID string) ([]string, error
 
 		err := rows.Scan(&teamGroupID, &teamMemberID)
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamGroupTeamMembers: %v", err)
+			return nil, fmt.Errorf("GetTeamGroupTeamMembers: %w", err)
 		}
 
 		teamMembers = append(teamMembers, teamMemberID)
@@ -181,80 +195,70 @@ func (db *dbClient) GetTeamGroupTeamMembers(teamGroupID string) ([]string, error
 	return teamMembers, nil
 }
 
-func (db *dbClient) UpdateTeamGroup(teamGroupID string, updates map[string]interface{}) (*models.TeamGroup, error) {
-	if v, ok := updates["team_members"]; ok {
-		teamMembers := strings.Split(v.(string), ",")
-		if len(teamMembers) > 0 && teamMembers[0] != "" {
-			err := db.UpdateTeamGroupTeamMembers(teamGroupID, teamMembers)
+func (db *dbClient) UpdateTeamGroup(ctx context.Context, teamGroupID string, updates map[string]interface{}) (*models.TeamGroup, error) {
+	var teamGroup *models.TeamGroup
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		if teamMembers, ok := updates["team_members"].([]string); ok {
+			err := tx.UpdateTeamGroupTeamMembers(ctx, teamGroupID, teamMembers)
 			if err != nil {
-				return nil, fmt.Errorf("UpdateTeamGroup: %v", err)
+				return err
 			}
+			delete(updates, "team_members")
 		}
-		delete(updates, "team_members")
-	}
 
-	updateQuery, err := db.GetUpdateQueryForStruct(models.TeamGroup{}, teamGroupID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateTeamGroup: %v", err)
-	}
-
-	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
-		if err != nil {
-			return nil, fmt.Errorf("UpdateTeamGroup: %v", err)
+		if len(updates) > 0 {
+			err := tx.updateStruct(ctx, models.TeamGroup{}, teamGroupID, updates)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	teamGroup, err := db.GetTeamGroup(teamGroupID)
+		var err error
+		teamGroup, err = tx.GetTeamGroup(ctx, teamGroupID)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("UpdateTeamGroup: %v", err)
+		return nil, fmt.Errorf("UpdateTeamGroup: %w", err)
 	}
 
 	return teamGroup, nil
 }
 
-func (db *dbClient) UpdateTeamGroupTeamMembers(teamGroupID string, teamMembers []string) error {
+func (db *dbClient) UpdateTeamGroupTeamMembers(ctx context.Context, teamGroupID string, teamMembers []string) error {
 	deleteParams := make(map[string]interface{})
 	deleteParams["team_group_id"] = teamGroupID
-	_, err := db.DeleteValuesFromCompositeTable(TEAM_GROUP_TEAM_MEMBER, deleteParams)
+	_, err := db.DeleteValuesFromCompositeTable(ctx, TEAM_GROUP_TEAM_MEMBER, deleteParams)
 	if err != nil {
-		return fmt.Errorf("UpdateTeamGroupTeamMembers: %v", err)
+		return fmt.Errorf("UpdateTeamGroupTeamMembers: %w", err)
 	}
 
-	err = db.AddTeamGroupTeamMembers(teamGroupID, teamMembers)
+	err = db.AddTeamGroupTeamMembers(ctx, teamGroupID, teamMembers)
 	if err != nil {
-		return fmt.Errorf("UpdateTeamGroupTeamMembers: %v", err)
+		return fmt.Errorf("UpdateTeamGroupTeamMembers: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) DeleteTeamGroup(teamGroupID string) error {
-	deleteParams := make(map[string]interface{})
-
-	deleteParams["_id"] = teamGroupID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.TeamGroup{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteTeamGroup: %v", err)
-	}
+func (db *dbClient) DeleteTeamGroup(ctx context.Context, teamGroupID string, version int) error {
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		deleteParamsForColumns := make(map[string]interface{})
+		deleteParamsForColumns["team_group_id"] = teamGroupID
 
-	_, err = db.RunDeleteQuery(deleteQuery)
-	if err != nil {
-		return fmt.Errorf("DeleteTeamGroup: %v", err)
-	}
-
-	deleteParamsForColumns := make(map[string]interface{})
-	deleteParamsForColumns["team_group_id"] = teamGroupID
+		_, err := tx.DeleteValuesFromCompositeTable(ctx, TEAM_GROUP_TEAM_MEMBER, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteTeamMemebersForTeamGroup: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(TEAM_GROUP_TEAM_MEMBER, deleteParamsForColumns)
-	if err != nil {
-		return fmt.Errorf("DeleteTeamMemebersForTeamGroup: %v", err)
-	}
+		_, err = tx.DeleteValuesFromCompositeTable(ctx, PROJECT_TEAM_GROUP, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteProjectsForTeamGroup: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(PROJECT_TEAM_GROUP, deleteParamsForColumns)
+		return tx.deleteStruct(ctx, models.TeamGroup{}, teamGroupID, version)
+	})
 	if err != nil {
-		return fmt.Errorf("DeleteProjectsForTeamGroup: %v", err)
+		return fmt.Errorf("DeleteTeamGroup: %w", err)
 	}
 
 	return nil
@@ -1,20 +1,20 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddTeamMember(teamMember *models.TeamMember) (*models.TeamMember, int, error) {
-	err := db.checkForDuplicateTeamMember(teamMember)
+func (db *dbClient) AddTeamMember(ctx context.Context, teamMember *models.TeamMember) (*models.TeamMember, int, error) {
+	err := db.checkForDuplicateTeamMember(ctx, teamMember)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("AddTeamMember: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("AddTeamMember: %w", err)
 	}
 
 	id := uuid.New().String()
@@ -22,42 +22,44 @@ func (db *dbClient) AddTeamMember(teamMember *models.TeamMember) (*models.TeamMe
 		return nil, http.StatusInternalServerError, fmt.Errorf("AddTeamMember: %v", errors.New("unable to generate id"))
 	}
 	teamMember.ID = fmt.Sprintf("tm_%v", id)
+	teamMember.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*teamMember)
+	insertQuery, args, err := db.GetInsertQuery(*teamMember)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamMember: %v", err)
+		return nil, -1, fmt.Errorf("AddTeamMember: %w", err)
 	}
 
-	_, err = db.RunInsertQuery(insertQuery)
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamMember: %v", err)
+		return nil, -1, fmt.Errorf("AddTeamMember: %w", err)
 	}
 
 	return teamMember, http.StatusOK, nil
 }
 
-func (db *dbClient) checkForDuplicateTeamMember(teamMember *models.TeamMember) error {
+func (db *dbClient) checkForDuplicateTeamMember(ctx context.Context, teamMember *models.TeamMember) error {
 	searchParams := make(map[string]interface{})
 	searchParams["user_email"] = teamMember.User
-	teamMembers, _ := db.GetTeamMembersWithFilters(searchParams)
+	searchParams["workspace_id"] = teamMember.Workspace
+	teamMembers, _ := db.GetTeamMembersWithFilters(ctx, searchParams)
 	if len(teamMembers) > 0 {
-		return errors.New("team member with this user email already exists")
+		return Conflict("team member with this user email already exists in this workspace")
 	}
 
 	return nil
 }
 
-func (db *dbClient) GetAllTeamMembers() ([]*models.TeamMember, error) {
-	teamMembers, err := db.GetTeamMembersWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllTeamMembers(ctx context.Context) ([]*models.TeamMember, error) {
+	teamMembers, err := db.GetTeamMembersWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllTeamMembers: %v", err)
+		return nil, fmt.Errorf("GetAllTeamMembers: %w", err)
 	}
 
 	return teamMembers, nil
 }
 
-func (db *dbClient) AddTeamMemberTeamGroups(teamMemberID string, teamGroups []string) error {
-	if teamGroups == nil || teamGroups[0] == "" {
+func (db *dbClient) AddTeamMemberTeamGroups(ctx context.Context, teamMemberID string, teamGroups []string) error {
+	if len(teamGroups) == 0 {
 		return nil
 	}
 
@@ -67,17 +69,17 @@ func (db *dbClient) AddTeamMemberTeamGroups(teamMemberID string, teamGroups []st
 		valuesMap["team_member_id"] = teamMemberID
 
 		//Check if value already exists
-		_, err := db.GetTeamGroupForTeamMember(teamMemberID, tg)
+		_, err := db.GetTeamGroupForTeamMember(ctx, teamMemberID, tg)
 		if err != nil {
 			//If value doesn't exist then insert it
-			insertQuery, err := db.GetInsertQueryForCompositeTable(TEAM_GROUP_TEAM_MEMBER, valuesMap)
+			insertQuery, args, err := db.GetInsertQueryForCompositeTable(TEAM_GROUP_TEAM_MEMBER, valuesMap)
 			if err != nil {
-				return fmt.Errorf("AddTeamGroupTeamMembers: %v", err)
+				return fmt.Errorf("AddTeamGroupTeamMembers: %w", err)
 			}
 
-			_, err = db.RunInsertQuery(insertQuery)
+			_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 			if err != nil {
-				return fmt.Errorf("AddTeamGroupTeamMembers: %v", err)
+				return fmt.Errorf("AddTeamGroupTeamMembers: %w", err)
 			}
 		}
 	}
@@ -85,10 +87,10 @@ func (db *dbClient) AddTeamMemberTeamGroups(teamMemberID string, teamGroups []st
 	return nil
 }
 
-func (db *dbClient) GetTeamGroupForTeamMember(teamMemberID, teamGroupID string) (string, error) {
-	teamGroups, err := db.GetTeamMemberTeamGroups(teamMemberID)
+func (db *dbClient) GetTeamGroupForTeamMember(ctx context.Context, teamMemberID, teamGroupID string) (string, error) {
+	teamGroups, err := db.GetTeamMemberTeamGroups(ctx, teamMemberID)
 	if err != nil {
-		return "", fmt.Errorf("GetTeamGroupForTeamMember: %v", err)
+		return "", fmt.Errorf("GetTeamGroupForTeamMember: %w", err)
 	}
 
 	for _, tg := range teamGroups {
@@ -97,22 +99,22 @@ func (db *dbClient) GetTeamGroupForTeamMember(teamMemberID, teamGroupID string)
 		}
 	}
 
-	return "", fmt.Errorf("GetTeamGroupForTeamMember: %v", errors.New("team group with given id not found"))
+	return "", fmt.Errorf("GetTeamGroupForTeamMember: %w", NotFound("team group with given id not found"))
 }
 
-func (db *dbClient) GetTeamMember(teamMemberID string) (*models.TeamMember, error) {
+func (db *dbClient) GetTeamMember(ctx context.Context, teamMemberID string) (*models.TeamMember, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = teamMemberID
 
-	teamMembers, err := db.GetTeamMembersWithFilters(selectParams)
+	teamMembers, err := db.GetTeamMembersWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamMember: %v", err)
+		return nil, fmt.Errorf("GetTeamMember: %w", err)
 	}
 
 	var teamMember *models.TeamMember
 	if teamMembers == nil || len(teamMembers) <= 0 {
-		return nil, fmt.Errorf("GetTeamMember: %v", errors.New("team member with given id not found"))
+		return nil, fmt.Errorf("GetTeamMember: %w", NotFound("team member with given id not found"))
 	} else {
 		teamMember = teamMembers[0]
 	}
@@ -120,28 +122,30 @@ func (db *dbClient) GetTeamMember(teamMemberID string) (*models.TeamMember, erro
 	return teamMember, nil
 }
 
-func (db *dbClient) GetTeamMembersWithFilters(searchParams map[string]interface{}) ([]*models.TeamMember, error) {
+func (db *dbClient) GetTeamMembersWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamMember, error) {
 	p := models.TeamMember{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamMembersWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamMembersWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamMembersWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamMembersWithFilters: %w", err)
 	}
 
-	teamMembers, err := db.GetTeamMembersFromRows(rows)
+	teamMembers, err := db.GetTeamMembersFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamMembersWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamMembersWithFilters: %w", err)
 	}
 
 	return teamMembers, nil
 }
 
-func (db *dbClient) GetTeamMembersFromRows(rows *sql.Rows) ([]*models.TeamMember, error) {
+func (db *dbClient) GetTeamMembersFromRows(ctx context.Context, rows *sql.Rows) ([]*models.TeamMember, error) {
+	defer rows.Close()
+
 	teamMembers := make([]*models.TeamMember, 0)
 	for rows.Next() {
 		tm := models.TeamMember{}
@@ -150,9 +154,9 @@ func (db *dbClient) GetTeamMembersFromRows(rows *sql.Rows) ([]*models.TeamMember
 		var userEmail sql.NullString
 		var teamRoleID sql.NullString
 
-		err := rows.Scan(&tm.ID, &tm.BillableRate, &workspaceID, &userEmail, &teamRoleID)
+		err := rows.Scan(&tm.ID, &tm.BillableRate, &workspaceID, &userEmail, &teamRoleID, &tm.Version)
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamMembersFromRows: %v", err)
+			return nil, fmt.Errorf("GetTeamMembersFromRows: %w", err)
 		}
 
 		if workspaceID.Valid {
@@ -167,25 +171,34 @@ func (db *dbClient) GetTeamMembersFromRows(rows *sql.Rows) ([]*models.TeamMember
 			tm.TeamRole = teamRoleID.String
 		}
 
-		tm.TeamGroups, err = db.GetTeamMemberTeamGroups(tm.ID)
+		teamMembers = append(teamMembers, &tm)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTeamMembersFromRows: %w", err)
+	}
+
+	//Relations are loaded after rows are drained as a transaction runs one query at a time
+	var err error
+	for _, tm := range teamMembers {
+		tm.TeamGroups, err = db.GetTeamMemberTeamGroups(ctx, tm.ID)
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamMemberTeamGroups: %v", err)
+			return nil, fmt.Errorf("GetTeamMemberTeamGroups: %w", err)
 		}
-
-		teamMembers = append(teamMembers, &tm)
 	}
 
 	return teamMembers, nil
 }
 
-func (db *dbClient) GetTeamMemberTeamGroups(teamMemberID string) ([]string, error) {
+func (db *dbClient) GetTeamMemberTeamGroups(ctx context.Context, teamMemberID string) ([]string, error) {
 	searchParams := make(map[string]interface{})
 	searchParams["team_member_id"] = teamMemberID
 
-	rows, err := db.GetValuesFromCompositeTable(TEAM_GROUP_TEAM_MEMBER, searchParams)
+	rows, err := db.GetValuesFromCompositeTable(ctx, TEAM_GROUP_TEAM_MEMBER, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamMemberTeamGroups: %v", err)
+		return nil, fmt.Errorf("GetTeamMemberTeamGroups: %w", err)
 	}
+	defer rows.Close()
 
 	teamGroups := make([]string, 0)
 	for rows.Next() {
@@ -193,7 +206,7 @@ func (db *dbClient) GetTeamMemberTeamGroups(teamMemberID string) ([]string, erro
 
 		err := rows.Scan(&teamGroupID, &teamMemberID)
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamMemberTeamGroups: %v", err)
+			return nil, fmt.Errorf("GetTeamMemberTeamGroups: %w", err)
 		}
 
 		teamGroups = append(teamGroups, teamGroupID)
@@ -202,80 +215,70 @@ func (db *dbClient) GetTeamMemberTeamGroups(teamMemberID string) ([]string, erro
 	return teamGroups, nil
 }
 
-func (db *dbClient) UpdateTeamMember(teamMemberID string, updates map[string]interface{}) (*models.TeamMember, error) {
-	if v, ok := updates["team_groups"]; ok {
-		teamGroups := strings.Split(v.(string), ",")
-		if len(teamGroups) > 0 && teamGroups[0] != "" {
-			err := db.UpdateTeamMemberTeamGroups(teamMemberID, teamGroups)
+func (db *dbClient) UpdateTeamMember(ctx context.Context, teamMemberID string, updates map[string]interface{}) (*models.TeamMember, error) {
+	var teamMember *models.TeamMember
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		if teamGroups, ok := updates["team_groups"].([]string); ok {
+			err := tx.UpdateTeamMemberTeamGroups(ctx, teamMemberID, teamGroups)
 			if err != nil {
-				return nil, fmt.Errorf("UpdateTeamMember: %v", err)
+				return err
 			}
+			delete(updates, "team_groups")
 		}
-		delete(updates, "team_groups")
-	}
-
-	updateQuery, err := db.GetUpdateQueryForStruct(models.TeamMember{}, teamMemberID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateTeamMember: %v", err)
-	}
 
-	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
-		if err != nil {
-			return nil, fmt.Errorf("UpdateTeamMember: %v", err)
+		if len(updates) > 0 {
+			err := tx.updateStruct(ctx, models.TeamMember{}, teamMemberID, updates)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	teamMember, err := db.GetTeamMember(teamMemberID)
+		var err error
+		teamMember, err = tx.GetTeamMember(ctx, teamMemberID)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("UpdateTeamMember: %v", err)
+		return nil, fmt.Errorf("UpdateTeamMember: %w", err)
 	}
 
 	return teamMember, nil
 }
 
-func (db *dbClient) UpdateTeamMemberTeamGroups(teamMemberID string, teamGroups []string) error {
+func (db *dbClient) UpdateTeamMemberTeamGroups(ctx context.Context, teamMemberID string, teamGroups []string) error {
 	deleteParams := make(map[string]interface{})
 	deleteParams["team_member_id"] = teamMemberID
-	_, err := db.DeleteValuesFromCompositeTable(TEAM_GROUP_TEAM_MEMBER, deleteParams)
+	_, err := db.DeleteValuesFromCompositeTable(ctx, TEAM_GROUP_TEAM_MEMBER, deleteParams)
 	if err != nil {
-		return fmt.Errorf("UpdateTeamMemberTeamGroups: %v", err)
+		return fmt.Errorf("UpdateTeamMemberTeamGroups: %w", err)
 	}
 
-	err = db.AddTeamMemberTeamGroups(teamMemberID, teamGroups)
+	err = db.AddTeamMemberTeamGroups(ctx, teamMemberID, teamGroups)
 	if err != nil {
-		return fmt.Errorf("UpdateTeamMemberTeamGroups: %v", err)
+		return fmt.Errorf("UpdateTeamMemberTeamGroups: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) DeleteTeamMember(teamMemberID string) error {
-	deleteParams := make(map[string]interface{})
-
-	deleteParams["_id"] = teamMemberID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.TeamMember{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteTeamMember: %v", err)
-	}
+func (db *dbClient) DeleteTeamMember(ctx context.Context, teamMemberID string, version int) error {
+	err := db.withTx(ctx, func(tx *dbClient) error {
+		deleteParamsForColumns := make(map[string]interface{})
+		deleteParamsForColumns["team_member_id"] = teamMemberID
 
-	_, err = db.RunDeleteQuery(deleteQuery)
-	if err != nil {
-		return fmt.Errorf("DeleteTeamMember: %v", err)
-	}
-
-	deleteParamsForColumns := make(map[string]interface{})
-	deleteParamsForColumns["team_member_id"] = teamMemberID
+		_, err := tx.DeleteValuesFromCompositeTable(ctx, PROJECT_TEAM_MEMBER, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteProjectsForTeamMember: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(PROJECT_TEAM_MEMBER, deleteParamsForColumns)
-	if err != nil {
-		return fmt.Errorf("DeleteProjectsForTeamMember: %v", err)
-	}
+		_, err = tx.DeleteValuesFromCompositeTable(ctx, TEAM_GROUP_TEAM_MEMBER, deleteParamsForColumns)
+		if err != nil {
+			return fmt.Errorf("DeleteTeamGroupsForTeamMember: %w", err)
+		}
 
-	_, err = db.DeleteValuesFromCompositeTable(TEAM_GROUP_TEAM_MEMBER, deleteParamsForColumns)
+		return tx.deleteStruct(ctx, models.TeamMember{}, teamMemberID, version)
+	})
 	if err != nil {
-		return fmt.Errorf("DeleteTeamGroupsForTeamMember: %v", err)
+		return fmt.Errorf("DeleteTeamMember: %w", err)
 	}
 
 	return nil
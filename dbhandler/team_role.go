@@ -1,6 +1,7 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,48 +11,49 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddTeamRole(teamRole *models.TeamRole) (*models.TeamRole, int, error) {
+func (db *dbClient) AddTeamRole(ctx context.Context, teamRole *models.TeamRole) (*models.TeamRole, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
 	}
 	teamRole.ID = fmt.Sprintf("tr_%v", id)
+	teamRole.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*teamRole)
+	insertQuery, args, err := db.GetInsertQuery(*teamRole)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamRole: %v", err)
+		return nil, -1, fmt.Errorf("AddTeamRole: %w", err)
 	}
 
-	_, err = db.RunInsertQuery(insertQuery)
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddTeamRole: %v", err)
+		return nil, -1, fmt.Errorf("AddTeamRole: %w", err)
 	}
 
 	return teamRole, http.StatusOK, nil
 }
 
-func (db *dbClient) GetAllTeamRoles() ([]*models.TeamRole, error) {
-	teamRoles, err := db.GetTeamRolesWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllTeamRoles(ctx context.Context) ([]*models.TeamRole, error) {
+	teamRoles, err := db.GetTeamRolesWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllTeamRoles: %v", err)
+		return nil, fmt.Errorf("GetAllTeamRoles: %w", err)
 	}
 
 	return teamRoles, nil
 }
 
-func (db *dbClient) GetTeamRole(teamRoleID string) (*models.TeamRole, error) {
+func (db *dbClient) GetTeamRole(ctx context.Context, teamRoleID string) (*models.TeamRole, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = teamRoleID
 
-	teamRoles, err := db.GetTeamRolesWithFilters(selectParams)
+	teamRoles, err := db.GetTeamRolesWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamRole: %v", err)
+		return nil, fmt.Errorf("GetTeamRole: %w", err)
 	}
 
 	var teamRole *models.TeamRole
 	if teamRoles == nil || len(teamRoles) <= 0 {
-		return nil, fmt.Errorf("GetTeamRole: %v", errors.New("team role with given id not found"))
+		return nil, fmt.Errorf("GetTeamRole: %w", NotFound("team role with given id not found"))
 	} else {
 		teamRole = teamRoles[0]
 	}
@@ -59,78 +61,79 @@ func (db *dbClient) GetTeamRole(teamRoleID string) (*models.TeamRole, error) {
 	return teamRole, nil
 }
 
-func (db *dbClient) GetTeamRolesWithFilters(searchParams map[string]interface{}) ([]*models.TeamRole, error) {
+func (db *dbClient) GetTeamRolesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.TeamRole, error) {
 	p := models.TeamRole{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamRolesWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamRolesWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamRolesWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamRolesWithFilters: %w", err)
 	}
 
-	teamRoles, err := db.GetTeamRolesFromRows(rows)
+	teamRoles, err := db.GetTeamRolesFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetTeamRolesWithFilters: %v", err)
+		return nil, fmt.Errorf("GetTeamRolesWithFilters: %w", err)
 	}
 
 	return teamRoles, nil
 }
 
-func (db *dbClient) GetTeamRolesFromRows(rows *sql.Rows) ([]*models.TeamRole, error) {
+func (db *dbClient) GetTeamRolesFromRows(ctx context.Context, rows *sql.Rows) ([]*models.TeamRole, error) {
+	defer rows.Close()
+
 	teamRoles := make([]*models.TeamRole, 0)
 	for rows.Next() {
 		tr := models.TeamRole{}
 
-		err := rows.Scan(&tr.ID, &tr.Role)
+		err := rows.Scan(&tr.ID, &tr.Role, &tr.Version)
 
 		if err != nil {
-			return nil, fmt.Errorf("GetTeamRolesFromRows: %v", err)
+			return nil, fmt.Errorf("GetTeamRolesFromRows: %w", err)
 		}
 
 		teamRoles = append(teamRoles, &tr)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTeamRolesFromRows: %w", err)
+	}
+
 	return teamRoles, nil
 }
 
-func (db *dbClient) UpdateTeamRole(teamRoleID string, updates map[string]interface{}) (*models.TeamRole, error) {
-	updateQuery, err := db.GetUpdateQueryForStruct(models.TeamRole{}, teamRoleID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateTeamRole: %v", err)
-	}
-
+func (db *dbClient) UpdateTeamRole(ctx context.Context, teamRoleID string, updates map[string]interface{}) (*models.TeamRole, error) {
 	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
+		err := db.updateStruct(ctx, models.TeamRole{}, teamRoleID, updates)
 		if err != nil {
-			return nil, fmt.Errorf("UpdateTeamRole: %v", err)
+			return nil, fmt.Errorf("UpdateTeamRole: %w", err)
 		}
 	}
 
-	teamRole, err := db.GetTeamRole(teamRoleID)
+	teamRole, err := db.GetTeamRole(ctx, teamRoleID)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateTeamRole: %v", err)
+		return nil, fmt.Errorf("UpdateTeamRole: %w", err)
 	}
 
 	return teamRole, nil
 }
 
-func (db *dbClient) DeleteTeamRole(teamRoleID string) error {
+func (db *dbClient) DeleteTeamRole(ctx context.Context, teamRoleID string) error {
 	deleteParams := make(map[string]interface{})
 
 	deleteParams["_id"] = teamRoleID
 
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.TeamRole{}, deleteParams)
+	deleteQuery, args, err := db.GetDeleteQueryForStruct(models.TeamRole{}, deleteParams)
 	if err != nil {
-		return fmt.Errorf("DeleteTeamRole: %v", err)
+		return fmt.Errorf("DeleteTeamRole: %w", err)
 	}
 
-	_, err = db.RunDeleteQuery(deleteQuery)
+	_, err = db.RunDeleteQuery(ctx, deleteQuery, args...)
 	if err != nil {
-		return fmt.Errorf("DeleteTeamRole: %v", err)
+		return fmt.Errorf("DeleteTeamRole: %w", err)
 	}
 
 	return nil
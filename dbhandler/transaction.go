@@ -0,0 +1,66 @@
+package dbhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// WithTx runs fn inside a single database transaction. The transaction is committed when
+// fn returns nil and rolled back when it returns an error or panics. Calling WithTx on a
+// handler that is already part of a transaction joins that transaction. Cancelling ctx
+// rolls the transaction back.
+func (db *dbClient) WithTx(ctx context.Context, fn func(tx DbHandler) error) error {
+	return db.withTx(ctx, func(tx *dbClient) error {
+		return fn(tx)
+	})
+}
+
+func (db *dbClient) withTx(ctx context.Context, fn func(tx *dbClient) error) error {
+	return db.withTxOptions(ctx, nil, fn)
+}
+
+// withSnapshot runs fn in a read-only REPEATABLE READ transaction, so that all of its queries see
+// the data as it was at the first one. Inside a transaction fn joins it instead.
+func (db *dbClient) withSnapshot(ctx context.Context, fn func(tx *dbClient) error) error {
+	return db.withTxOptions(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func (db *dbClient) withTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(tx *dbClient) error) (err error) {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	if dbConnection == nil {
+		return fmt.Errorf("WithTx: %v", errors.New("database is not set up"))
+	}
+
+	sqlTx, err := dbConnection.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("WithTx: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(&dbClient{dbName: db.dbName, tx: sqlTx})
+	if err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			return fmt.Errorf("WithTx: %w, rollback failed: %v", err, rbErr)
+		}
+
+		return err
+	}
+
+	err = sqlTx.Commit()
+	if err != nil {
+		return fmt.Errorf("WithTx: %w", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -9,15 +10,16 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/password"
 )
 
-func (db *dbClient) AddUser(user *models.User) (*models.User, int, error) {
-	if status, err := db.CheckForDuplicateUser(user.Email); err != nil {
-		return nil, status, fmt.Errorf("AddUser: %v", err)
+func (db *dbClient) AddUser(ctx context.Context, user *models.User) (*models.User, int, error) {
+	if status, err := db.CheckForDuplicateUser(ctx, user.Email); err != nil {
+		return nil, status, fmt.Errorf("AddUser: %w", err)
 	}
 
-	if status, err := db.CheckForDuplicateUser(user.Username); err != nil {
-		return nil, status, fmt.Errorf("AddUser: %v", err)
+	if status, err := db.CheckForDuplicateUser(ctx, user.Username); err != nil {
+		return nil, status, fmt.Errorf("AddUser: %w", err)
 	}
 
 	id := uuid.New().String()
@@ -25,50 +27,57 @@ func (db *dbClient) AddUser(user *models.User) (*models.User, int, error) {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
 	}
 	user.ID = fmt.Sprintf("u_%v", id)
+	user.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*user)
+	if user.Timezone == "" {
+		user.Timezone = DEFAULT_TIMEZONE
+	}
+
+	err := hashUserPassword(user)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("AddUser: %w", err)
+	}
+
+	insertQuery, args, err := db.GetInsertQuery(*user)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddUser: %v", err)
+		return nil, -1, fmt.Errorf("AddUser: %w", err)
 	}
 
-	_, err = db.RunInsertQuery(insertQuery)
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddUser: %v", err)
+		return nil, -1, fmt.Errorf("AddUser: %w", err)
 	}
 
 	return user, http.StatusOK, nil
 }
 
-func (db *dbClient) GetAllUsers() ([]*models.User, error) {
-	users, err := db.GetUsersWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllUsers(ctx context.Context) ([]*models.User, error) {
+	users, err := db.GetUsersWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllUsers: %v", err)
+		return nil, fmt.Errorf("GetAllUsers: %w", err)
 	}
 
 	return users, nil
 }
 
-func (db *dbClient) GetUser(userID string) (*models.User, error) {
+func (db *dbClient) GetUser(ctx context.Context, userID string) (*models.User, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = userID
 
-	users, err := db.GetUsersWithFilters(selectParams)
+	users, err := db.GetUsersWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetUser: %v", err)
+		return nil, fmt.Errorf("GetUser: %w", err)
 	}
 
-	var user *models.User
-	if users == nil || len(users) <= 0 {
-		return nil, nil //fmt.Errorf("GetUser: %v", errors.New("User with given ID not found!"))
-	} else {
-		user = users[0]
+	if len(users) <= 0 {
+		return nil, fmt.Errorf("GetUser: %w", NotFound("user with given id not found"))
 	}
 
-	return user, nil
+	return users[0], nil
 }
 
-func (db *dbClient) GetUserWithIdentity(identity string) (*models.User, error) {
+func (db *dbClient) GetUserWithIdentity(ctx context.Context, identity string) (*models.User, error) {
 	searchParams := make(map[string]interface{})
 	if strings.Contains(identity, "@") {
 		searchParams["email"] = identity
@@ -76,99 +85,93 @@ func (db *dbClient) GetUserWithIdentity(identity string) (*models.User, error) {
 		searchParams["username"] = identity
 	}
 
-	users, err := db.GetUsersWithFilters(searchParams)
+	users, err := db.GetUsersWithFilters(ctx, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetUser: %v", err)
+		return nil, fmt.Errorf("GetUser: %w", err)
 	}
 
-	var user *models.User
-	if users == nil || len(users) <= 0 {
-		return nil, nil //fmt.Errorf("GetUser: %v", errors.New("User with given ID not found!"))
-	} else {
-		user = users[0]
+	if len(users) <= 0 {
+		return nil, fmt.Errorf("GetUser: %w", NotFound("user with given identity not found"))
 	}
 
-	return user, nil
+	return users[0], nil
 }
 
-func (db *dbClient) GetUsersWithFilters(searchParams map[string]interface{}) ([]*models.User, error) {
+func (db *dbClient) GetUsersWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.User, error) {
 	p := models.User{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetUsersWithFilters: %v", err)
+		return nil, fmt.Errorf("GetUsersWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetUsersWithFilters: %v", err)
+		return nil, fmt.Errorf("GetUsersWithFilters: %w", err)
 	}
 
-	users, err := db.GetUsersFromRows(rows)
+	users, err := db.GetUsersFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetUsersWithFilters: %v", err)
+		return nil, fmt.Errorf("GetUsersWithFilters: %w", err)
 	}
 
 	return users, nil
 }
 
-func (db *dbClient) GetUsersFromRows(rows *sql.Rows) ([]*models.User, error) {
+func (db *dbClient) GetUsersFromRows(ctx context.Context, rows *sql.Rows) ([]*models.User, error) {
+	defer rows.Close()
+
 	users := make([]*models.User, 0)
 	for rows.Next() {
 		u := models.User{}
 
-		err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.Username, &u.Password)
+		err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Username, &u.Password, &u.Timezone, &u.Version)
 
 		if err != nil {
-			return nil, fmt.Errorf("GetUsersFromRows: %v", err)
+			return nil, fmt.Errorf("GetUsersFromRows: %w", err)
 		}
 
 		users = append(users, &u)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUsersFromRows: %w", err)
+	}
+
 	return users, nil
 }
 
-func (db *dbClient) UpdateUser(userID string, updates map[string]interface{}) (*models.User, error) {
-	updateQuery, err := db.GetUpdateQueryForStruct(models.User{}, userID, updates)
+func (db *dbClient) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) (*models.User, error) {
+	err := hashPasswordUpdate(updates)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateUser: %v", err)
+		return nil, fmt.Errorf("UpdateUser: %w", err)
 	}
 
 	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
+		err := db.updateStruct(ctx, models.User{}, userID, updates)
 		if err != nil {
-			return nil, fmt.Errorf("UpdateUser: %v", err)
+			return nil, fmt.Errorf("UpdateUser: %w", err)
 		}
 	}
 
-	user, err := db.GetUser(userID)
+	user, err := db.GetUser(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateUser: %v", err)
+		return nil, fmt.Errorf("UpdateUser: %w", err)
 	}
 
 	return user, nil
 }
 
-func (db *dbClient) DeleteUser(userID string) error {
-	deleteParams := make(map[string]interface{})
-
-	deleteParams["_id"] = userID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.User{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteUser: %v", err)
-	}
-
-	_, err = db.RunDeleteQuery(deleteQuery)
+func (db *dbClient) DeleteUser(ctx context.Context, userID string, version int) error {
+	err := db.deleteStruct(ctx, models.User{}, userID, version)
 	if err != nil {
-		return fmt.Errorf("DeleteUser: %v", err)
+		return fmt.Errorf("DeleteUser: %w", err)
 	}
 
 	return nil
 }
 
-func (db *dbClient) CheckForDuplicateUser(identity string) (int, error) {
+func (db *dbClient) CheckForDuplicateUser(ctx context.Context, identity string) (int, error) {
 	searchParams := make(map[string]interface{})
 	searchKey := ""
 	if strings.Contains(identity, "@") {
@@ -178,31 +181,82 @@ func (db *dbClient) CheckForDuplicateUser(identity string) (int, error) {
 	}
 	searchParams[searchKey] = identity
 
-	users, err := db.GetUsersWithFilters(searchParams)
+	users, err := db.GetUsersWithFilters(ctx, searchParams)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("CheckForDuplicateUser: %v", err)
+		return http.StatusInternalServerError, fmt.Errorf("CheckForDuplicateUser: %w", err)
 	}
 	if len(users) > 0 {
-		return http.StatusBadRequest,
-			fmt.Errorf("CheckForDuplicateUser: user with this %v already exists", searchKey)
+		return http.StatusConflict,
+			fmt.Errorf("CheckForDuplicateUser: %w", Conflict("user with this %v already exists", searchKey))
 	}
 
 	return http.StatusOK, nil
 }
 
-func (db *dbClient) CheckUserLogin(identity, password string) (*models.User, error) {
-	user, err := db.GetUserWithIdentity(identity)
+func (db *dbClient) CheckUserLogin(ctx context.Context, identity, plain string) (*models.User, error) {
+	user, err := db.GetUserWithIdentity(ctx, identity)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, err
+	}
+
+	needsRehash, err := verifyUserPassword(user, plain)
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, errors.New("user with these details does not exist")
+	if needsRehash {
+		_, err = db.UpdateUser(ctx, user.ID, map[string]interface{}{"password": plain})
+		if err != nil {
+			return nil, fmt.Errorf("CheckUserLogin: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// DEFAULT_TIMEZONE is given to users who did not pick a time zone
+const DEFAULT_TIMEZONE = "UTC"
+
+// hashUserPassword replaces the plaintext password of user with its hash before it is stored
+func hashUserPassword(user *models.User) error {
+	hash, err := password.Hash(user.Password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+
+	return nil
+}
+
+// hashPasswordUpdate hashes the password in updates, if there is one
+func hashPasswordUpdate(updates map[string]interface{}) error {
+	v, ok := updates["password"]
+	if !ok {
+		return nil
+	}
+
+	hash, err := password.Hash(fmt.Sprint(v))
+	if err != nil {
+		return err
+	}
+	updates["password"] = hash
+
+	return nil
+}
+
+// verifyUserPassword checks plain against the stored password of user and reports whether the
+// stored value should be rehashed, which is the case for rows saved before hashing was added
+func verifyUserPassword(user *models.User, plain string) (bool, error) {
+	match, needsRehash, err := password.Verify(user.Password, plain)
+	if err != nil {
+		return false, err
 	}
 
-	if strings.EqualFold(user.Password, password) {
-		return user, nil
+	if !match {
+		return false, ErrInvalidCredentials
 	}
 
-	return nil, errors.New("invalid credentials")
+	return needsRehash, nil
 }
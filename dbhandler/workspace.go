@@ -1,6 +1,7 @@
 package dbhandler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,48 +11,49 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func (db *dbClient) AddWorkspace(workspace *models.Workspace) (*models.Workspace, int, error) {
+func (db *dbClient) AddWorkspace(ctx context.Context, workspace *models.Workspace) (*models.Workspace, int, error) {
 	id := uuid.New().String()
 	if id == "" {
 		return nil, http.StatusInternalServerError, errors.New("unable to generate id")
 	}
 	workspace.ID = fmt.Sprintf("w_%v", id)
+	workspace.Version = 1
 
-	insertQuery, err := db.GetInsertQuery(*workspace)
+	insertQuery, args, err := db.GetInsertQuery(*workspace)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddWorkspace: %v", err)
+		return nil, -1, fmt.Errorf("AddWorkspace: %w", err)
 	}
 
-	_, err = db.RunInsertQuery(insertQuery)
+	_, err = db.RunInsertQuery(ctx, insertQuery, args...)
 	if err != nil {
-		return nil, -1, fmt.Errorf("AddWorkspace: %v", err)
+		return nil, -1, fmt.Errorf("AddWorkspace: %w", err)
 	}
 
 	return workspace, http.StatusOK, nil
 }
 
-func (db *dbClient) GetAllWorkspaces() ([]*models.Workspace, error) {
-	workspaces, err := db.GetWorkspacesWithFilters(make(map[string]interface{}))
+func (db *dbClient) GetAllWorkspaces(ctx context.Context) ([]*models.Workspace, error) {
+	workspaces, err := db.GetWorkspacesWithFilters(ctx, make(map[string]interface{}))
 	if err != nil {
-		return nil, fmt.Errorf("GetAllWorkspaces: %v", err)
+		return nil, fmt.Errorf("GetAllWorkspaces: %w", err)
 	}
 
 	return workspaces, nil
 }
 
-func (db *dbClient) GetWorkspace(workspaceID string) (*models.Workspace, error) {
+func (db *dbClient) GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error) {
 	selectParams := make(map[string]interface{})
 
 	selectParams["_id"] = workspaceID
 
-	workspaces, err := db.GetWorkspacesWithFilters(selectParams)
+	workspaces, err := db.GetWorkspacesWithFilters(ctx, selectParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetWorkspace: %v", err)
+		return nil, fmt.Errorf("GetWorkspace: %w", err)
 	}
 
 	var workspace *models.Workspace
 	if workspaces == nil || len(workspaces) <= 0 {
-		return nil, fmt.Errorf("GetWorkspace: workspace with given id not found")
+		return nil, fmt.Errorf("GetWorkspace: %w", NotFound("workspace with given id not found"))
 	} else {
 		workspace = workspaces[0]
 	}
@@ -59,77 +61,70 @@ func (db *dbClient) GetWorkspace(workspaceID string) (*models.Workspace, error)
 	return workspace, nil
 }
 
-func (db *dbClient) GetWorkspacesWithFilters(searchParams map[string]interface{}) ([]*models.Workspace, error) {
+func (db *dbClient) GetWorkspacesWithFilters(ctx context.Context, searchParams map[string]interface{}) ([]*models.Workspace, error) {
 	p := models.Workspace{}
 
-	selectQuery, err := db.GetSelectQueryForStruct(p, searchParams)
+	selectQuery, args, err := db.GetSelectQueryForStruct(p, searchParams)
 	if err != nil {
-		return nil, fmt.Errorf("GetWorkspacesWithFilters: %v", err)
+		return nil, fmt.Errorf("GetWorkspacesWithFilters: %w", err)
 	}
 
-	rows, err := db.RunSelectQuery(selectQuery)
+	rows, err := db.RunSelectQuery(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetWorkspacesWithFilters: %v", err)
+		return nil, fmt.Errorf("GetWorkspacesWithFilters: %w", err)
 	}
 
-	workspaces, err := db.GetWorkspacesFromRows(rows)
+	workspaces, err := db.GetWorkspacesFromRows(ctx, rows)
 	if err != nil {
-		return nil, fmt.Errorf("GetWorkspacesWithFilters: %v", err)
+		return nil, fmt.Errorf("GetWorkspacesWithFilters: %w", err)
 	}
 
 	return workspaces, nil
 }
 
-func (db *dbClient) GetWorkspacesFromRows(rows *sql.Rows) ([]*models.Workspace, error) {
+func (db *dbClient) GetWorkspacesFromRows(ctx context.Context, rows *sql.Rows) ([]*models.Workspace, error) {
+	defer rows.Close()
+
 	workspaces := make([]*models.Workspace, 0)
 	for rows.Next() {
 		w := models.Workspace{}
 
-		err := rows.Scan(&w.ID, &w.Name)
+		err := rows.Scan(&w.ID, &w.Name, &w.Version)
 
 		if err != nil {
-			return nil, fmt.Errorf("GetWorkspacesFromRows: %v", err)
+			return nil, fmt.Errorf("GetWorkspacesFromRows: %w", err)
 		}
 
 		workspaces = append(workspaces, &w)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetWorkspacesFromRows: %w", err)
+	}
+
 	return workspaces, nil
 }
 
-func (db *dbClient) UpdateWorkspace(workspaceID string, updates map[string]interface{}) (*models.Workspace, error) {
-	updateQuery, err := db.GetUpdateQueryForStruct(models.Workspace{}, workspaceID, updates)
-	if err != nil {
-		return nil, fmt.Errorf("UpdateWorkspace: %v", err)
-	}
-
+func (db *dbClient) UpdateWorkspace(ctx context.Context, workspaceID string, updates map[string]interface{}) (*models.Workspace, error) {
 	if len(updates) > 0 {
-		_, err = db.RunUpdateQuery(updateQuery)
+		err := db.updateStruct(ctx, models.Workspace{}, workspaceID, updates)
 		if err != nil {
-			return nil, fmt.Errorf("UpdateWorkspace: %v", err)
+			return nil, fmt.Errorf("UpdateWorkspace: %w", err)
 		}
 	}
 
-	workspace, err := db.GetWorkspace(workspaceID)
+	workspace, err := db.GetWorkspace(ctx, workspaceID)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateWorkspace: %v", err)
+		return nil, fmt.Errorf("UpdateWorkspace: %w", err)
 	}
 
 	return workspace, nil
 }
 
-func (db *dbClient) DeleteWorkspace(workspaceID string) error {
-	deleteParams := make(map[string]interface{})
-	deleteParams["_id"] = workspaceID
-
-	deleteQuery, err := db.GetDeleteQueryForStruct(models.Workspace{}, deleteParams)
-	if err != nil {
-		return fmt.Errorf("DeleteWorkspace: %v", err)
-	}
-
-	_, err = db.RunDeleteQuery(deleteQuery)
+func (db *dbClient) DeleteWorkspace(ctx context.Context, workspaceID string, version int) error {
+	err := db.deleteStruct(ctx, models.Workspace{}, workspaceID, version)
 	if err != nil {
-		return fmt.Errorf("DeleteWorkspace: %v", err)
+		return fmt.Errorf("DeleteWorkspace: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfMatch(t *testing.T) {
+	s := newTestServer(t)
+
+	owner := s.signUp("owner")
+	workspace := s.addWorkspace(owner)
+
+	tests := []struct {
+		name   string
+		add    string
+		path   string
+		body   gin.H
+		change gin.H
+	}{
+		{
+			name:   "client",
+			add:    "/client",
+			path:   "/clients/",
+			body:   gin.H{"name": "Acme"},
+			change: gin.H{"name": "Acme Ltd"},
+		},
+		{
+			name:   "project",
+			add:    "/project",
+			path:   "/projects/",
+			body:   gin.H{"name": "Alpha"},
+			change: gin.H{"name": "Beta"},
+		},
+		{
+			name:   "tag",
+			add:    "/tag",
+			path:   "/tags/",
+			body:   gin.H{"name": "red"},
+			change: gin.H{"name": "blue"},
+		},
+		{
+			name:   "time entry",
+			add:    "/task",
+			path:   "/tasks/",
+			body:   gin.H{"start_time": "2022-03-01T09:00:00Z", "end_time": "2022-03-01T10:00:00Z"},
+			change: gin.H{"description": "Design"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := s.in(t)
+
+			path := workspace + tt.path + s.do(owner, http.MethodPost, workspace+tt.add, tt.body, nil).
+				expect(http.StatusOK).addedID()
+
+			etag := s.do(owner, http.MethodGet, path, nil, nil).expect(http.StatusOK).Header().Get("ETag")
+			if etag != `"1"` {
+				t.Fatalf("got the ETag %s, want \"1\"", etag)
+			}
+
+			s.do(owner, http.MethodPatch, path, tt.change, nil).expect(http.StatusPreconditionRequired)
+			s.do(owner, http.MethodPatch, path, tt.change, ifMatch(2)).expect(http.StatusPreconditionFailed)
+
+			etag = s.do(owner, http.MethodPatch, path, tt.change, ifMatch(1)).expect(http.StatusOK).Header().Get("ETag")
+			if etag != `"2"` {
+				t.Fatalf("got the ETag %s after a change, want \"2\"", etag)
+			}
+
+			// The ETag read before the change is stale now, * matches any version
+			s.do(owner, http.MethodPatch, path, tt.change, ifMatch(1)).expect(http.StatusPreconditionFailed)
+			s.do(owner, http.MethodPatch, path, tt.change, http.Header{"If-Match": {"*"}}).expect(http.StatusOK)
+
+			s.do(owner, http.MethodDelete, path, nil, nil).expect(http.StatusPreconditionRequired)
+			s.do(owner, http.MethodDelete, path, nil, ifMatch(2)).expect(http.StatusPreconditionFailed)
+			s.do(owner, http.MethodDelete, path, nil, http.Header{"If-Match": {`"1", "3"`}}).expect(http.StatusOK)
+			s.do(owner, http.MethodGet, path, nil, nil).expect(http.StatusNotFound)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) WriteRow(cells ...interface{}) error {
+	record := make([]string, 0, len(cells))
+	for _, c := range cells {
+		switch c.(type) {
+		case float64, int:
+			record = append(record, formatCell(c))
+		default:
+			record = append(record, formatText(c))
+		}
+	}
+
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
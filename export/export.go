@@ -0,0 +1,95 @@
+// Package export writes tables of rows as CSV, XLSX or PDF files and invoices as PDF. CSV and XLSX
+// rows are streamed to the output as they are written, a PDF is laid out in memory and written on
+// Close.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FORMAT_CSV writes comma separated values
+	FORMAT_CSV = "csv"
+	// FORMAT_XLSX writes an Excel workbook with a single sheet
+	FORMAT_XLSX = "xlsx"
+	// FORMAT_PDF writes a landscape A4 table
+	FORMAT_PDF = "pdf"
+)
+
+// Writer writes the rows of a table to a file, Close has to be called to finish it
+type Writer interface {
+	// WriteRow writes one row, cells are strings, float64 or int values
+	WriteRow(cells ...interface{}) error
+	Close() error
+}
+
+// NewWriter returns a Writer of format writing to w, title heads PDF pages and header is the
+// first row of the table
+func NewWriter(format string, w io.Writer, title string, header []string) (Writer, error) {
+	var writer Writer
+	switch format {
+	case FORMAT_CSV:
+		writer = newCSVWriter(w)
+	case FORMAT_XLSX:
+		writer = newXLSXWriter(w)
+	case FORMAT_PDF:
+		writer = newPDFWriter(w, title, header)
+		return writer, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %s", format)
+	}
+
+	cells := make([]interface{}, 0, len(header))
+	for _, h := range header {
+		cells = append(cells, h)
+	}
+
+	err := writer.WriteRow(cells...)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// ContentType returns the MIME type of files of format
+func ContentType(format string) string {
+	switch format {
+	case FORMAT_CSV:
+		return "text/csv; charset=utf-8"
+	case FORMAT_XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FORMAT_PDF:
+		return "application/pdf"
+	}
+
+	return "application/octet-stream"
+}
+
+// formatCell returns the text of a cell, numbers keep two decimals
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case int:
+		return strconv.Itoa(v)
+	}
+
+	return fmt.Sprint(cell)
+}
+
+// formatText returns the text of a text cell. Spreadsheets run text starting with =, +, - or @ as
+// a formula, so a description like "=HYPERLINK(...)" is prefixed with ' to stay text.
+func formatText(cell interface{}) string {
+	text := formatCell(cell)
+	if text != "" && strings.ContainsRune("=+-@", rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
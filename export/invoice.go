@@ -0,0 +1,392 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/pdf"
+)
+
+const (
+	invoiceMargin     = 50.0
+	invoiceFontSize   = 9.0
+	invoiceLine       = 12.0
+	invoiceRow        = 18.0
+	invoiceLogoWidth  = 150.0
+	invoiceLogoHeight = 50.0
+	invoiceDateLayout = "2006-01-02"
+)
+
+// InvoiceData is what the title, header and footer of an invoice template are filled in with,
+// e.g. "Invoice {{.Number}}" or "Please pay by {{.DueDate}}"
+type InvoiceData struct {
+	Number      int
+	Status      string
+	Currency    string
+	IssueDate   string
+	DueDate     string
+	PeriodStart string
+	PeriodEnd   string
+	Total       string
+	Client      *models.Client
+	Workspace   *models.Workspace
+}
+
+// NewInvoiceData returns the template data of invoice, dates are written in the location they
+// are given in
+func NewInvoiceData(invoice *models.Invoice, client *models.Client, workspace *models.Workspace) *InvoiceData {
+	data := &InvoiceData{
+		Number:      invoice.Number,
+		Status:      invoice.Status,
+		Currency:    invoice.Currency,
+		IssueDate:   invoice.IssueDate.Format(invoiceDateLayout),
+		PeriodStart: invoice.PeriodStart.Format(invoiceDateLayout),
+		PeriodEnd:   invoice.PeriodEnd.AddDate(0, 0, -1).Format(invoiceDateLayout),
+		Total:       formatMoney(invoice.Total),
+		Client:      client,
+		Workspace:   workspace,
+	}
+
+	if invoice.DueDate != nil {
+		data.DueDate = invoice.DueDate.Format(invoiceDateLayout)
+	}
+
+	return data
+}
+
+// CheckInvoiceTemplate makes sure the texts of t can be filled in and its color and logo can be
+// drawn
+func CheckInvoiceTemplate(t *models.InvoiceTemplate) error {
+	now := time.Now()
+	invoice := &models.Invoice{Number: 1, Status: models.INVOICE_DRAFT, IssueDate: now, DueDate: &now,
+		PeriodStart: now, PeriodEnd: now}
+	data := NewInvoiceData(invoice, &models.Client{}, &models.Workspace{})
+
+	_, err := newInvoiceTexts(t, data)
+	if err != nil {
+		return err
+	}
+
+	_, _, _, err = pdf.ParseColor(t.AccentColor)
+	if err != nil {
+		return fmt.Errorf("accent_color: %v", err)
+	}
+
+	_, err = decodeLogo(t.Logo)
+	return err
+}
+
+// WriteInvoice writes invoice to w as an A4 PDF laid out with t. Its items continue on further
+// pages when they do not fit one.
+func WriteInvoice(w io.Writer, invoice *models.Invoice, client *models.Client, workspace *models.Workspace,
+	t *models.InvoiceTemplate) error {
+	texts, err := newInvoiceTexts(t, NewInvoiceData(invoice, client, workspace))
+	if err != nil {
+		return err
+	}
+
+	iw := &invoiceWriter{doc: pdf.New(pdf.A4_WIDTH, pdf.A4_HEIGHT), invoice: invoice, texts: texts}
+	iw.accent[0], iw.accent[1], iw.accent[2], err = pdf.ParseColor(t.AccentColor)
+	if err != nil {
+		return fmt.Errorf("accent_color: %v", err)
+	}
+
+	logo, err := decodeLogo(t.Logo)
+	if err != nil {
+		return err
+	}
+
+	iw.doc.AddPage()
+	iw.writeHeading(logo, workspace)
+	iw.writeClient(client)
+	iw.writeItems()
+	iw.writeTotals()
+	iw.writeNote()
+	iw.writeFooter()
+
+	_, err = iw.doc.WriteTo(w)
+	return err
+}
+
+// invoiceTexts are the texts of an invoice template filled in for one invoice
+type invoiceTexts struct {
+	title  string
+	header []string
+	footer []string
+}
+
+func newInvoiceTexts(t *models.InvoiceTemplate, data *InvoiceData) (*invoiceTexts, error) {
+	texts := &invoiceTexts{}
+	for name, text := range map[string]string{"title": t.Title, "header": t.Header, "footer": t.Footer} {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		err = tmpl.Execute(&buf, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		switch name {
+		case "title":
+			texts.title = buf.String()
+		case "header":
+			texts.header = splitLines(buf.String())
+		case "footer":
+			texts.footer = splitLines(buf.String())
+		}
+	}
+
+	return texts, nil
+}
+
+func decodeLogo(logo string) (*pdf.Image, error) {
+	if logo == "" {
+		return nil, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(logo)
+	if err != nil {
+		return nil, fmt.Errorf("logo: %v", err)
+	}
+
+	img, err := pdf.NewJPEG(data)
+	if err != nil {
+		return nil, fmt.Errorf("logo: %v", err)
+	}
+
+	return img, nil
+}
+
+// invoiceWriter lays out an invoice from the top of the page down, y is where the next line goes
+type invoiceWriter struct {
+	doc     *pdf.Document
+	invoice *models.Invoice
+	texts   *invoiceTexts
+	accent  [3]float64
+	y       float64
+}
+
+// writeHeading writes the logo, workspace name and header on the left and the title with the
+// dates of the invoice on the right
+func (iw *invoiceWriter) writeHeading(logo *pdf.Image, workspace *models.Workspace) {
+	top := iw.doc.Height() - invoiceMargin
+	right := iw.doc.Width() - invoiceMargin
+
+	left := top
+	if logo != nil {
+		width, height := logo.Size()
+		scale := math.Min(invoiceLogoWidth/float64(width), invoiceLogoHeight/float64(height))
+		iw.doc.Image(logo, invoiceMargin, top-float64(height)*scale, float64(width)*scale, float64(height)*scale)
+		left -= float64(height)*scale + 8
+	}
+
+	left -= 14
+	iw.doc.Text(invoiceMargin, left, 14, true, pdf.Truncate(workspace.Name, right/2-invoiceMargin, 14, true))
+	for _, line := range iw.texts.header {
+		left -= invoiceLine
+		iw.doc.Text(invoiceMargin, left, invoiceFontSize, false, pdf.Truncate(line, right/2-invoiceMargin,
+			invoiceFontSize, false))
+	}
+
+	iw.doc.SetColor(iw.accent[0], iw.accent[1], iw.accent[2])
+	iw.doc.TextRight(right, top-20, 20, true, pdf.Truncate(iw.texts.title, right/2, 20, true))
+	iw.doc.SetColor(0, 0, 0)
+
+	meta := [][2]string{
+		{"Invoice number", strconv.Itoa(iw.invoice.Number)},
+		{"Issue date", iw.invoice.IssueDate.Format(invoiceDateLayout)},
+	}
+	if iw.invoice.DueDate != nil {
+		meta = append(meta, [2]string{"Due date", iw.invoice.DueDate.Format(invoiceDateLayout)})
+	}
+	meta = append(meta, [2]string{"Period", fmt.Sprintf("%s - %s", iw.invoice.PeriodStart.Format(invoiceDateLayout),
+		iw.invoice.PeriodEnd.AddDate(0, 0, -1).Format(invoiceDateLayout))})
+
+	y := top - 20 - 8
+	for _, m := range meta {
+		y -= invoiceLine
+		iw.doc.Text(right-200, y, invoiceFontSize, true, m[0])
+		iw.doc.TextRight(right, y, invoiceFontSize, false, m[1])
+	}
+
+	iw.y = math.Min(left, y) - 30
+}
+
+// writeClient writes who the invoice is billed to
+func (iw *invoiceWriter) writeClient(client *models.Client) {
+	width := iw.doc.Width()/2 - invoiceMargin
+
+	iw.doc.SetColor(iw.accent[0], iw.accent[1], iw.accent[2])
+	iw.doc.Text(invoiceMargin, iw.y, 10, true, "Bill to")
+	iw.doc.SetColor(0, 0, 0)
+
+	iw.y -= invoiceLine + 2
+	iw.doc.Text(invoiceMargin, iw.y, 11, true, pdf.Truncate(client.Name, width, 11, true))
+	for _, line := range splitLines(client.Address) {
+		iw.y -= invoiceLine
+		iw.doc.Text(invoiceMargin, iw.y, invoiceFontSize, false, pdf.Truncate(line, width, invoiceFontSize, false))
+	}
+
+	iw.y -= 30
+}
+
+// writeItems writes the items as a table, its header is repeated on every page
+func (iw *invoiceWriter) writeItems() {
+	iw.writeItemsHeader()
+	for _, item := range iw.invoice.Items {
+		if iw.y < iw.bottom()+invoiceRow {
+			iw.newPage()
+			iw.writeItemsHeader()
+		}
+
+		iw.writeItemRow(false, item.Description, formatQuantity(item.Quantity), formatMoney(item.UnitPrice),
+			formatMoney(item.Amount))
+
+		iw.doc.SetStrokeColor(0.85, 0.85, 0.85)
+		iw.doc.Line(invoiceMargin, iw.y+invoiceRow-5, iw.doc.Width()-invoiceMargin, iw.y+invoiceRow-5, 0.5)
+	}
+
+	iw.y -= 10
+}
+
+func (iw *invoiceWriter) writeItemsHeader() {
+	iw.doc.SetColor(iw.accent[0], iw.accent[1], iw.accent[2])
+	iw.doc.Rect(invoiceMargin, iw.y-6, iw.doc.Width()-2*invoiceMargin, invoiceRow)
+	iw.doc.SetColor(1, 1, 1)
+	iw.writeItemRow(true, "Description", "Quantity", "Unit price", fmt.Sprintf("Amount (%s)", iw.invoice.Currency))
+	iw.doc.SetColor(0, 0, 0)
+}
+
+// writeItemRow writes the description left aligned and the numbers right aligned in their columns
+func (iw *invoiceWriter) writeItemRow(bold bool, description, quantity, unitPrice, amount string) {
+	right := iw.doc.Width() - invoiceMargin - 6
+	iw.doc.Text(invoiceMargin+6, iw.y, invoiceFontSize, bold, pdf.Truncate(description, right-230-invoiceMargin,
+		invoiceFontSize, bold))
+	iw.doc.TextRight(right-180, iw.y, invoiceFontSize, bold, quantity)
+	iw.doc.TextRight(right-90, iw.y, invoiceFontSize, bold, unitPrice)
+	iw.doc.TextRight(right, iw.y, invoiceFontSize, bold, amount)
+	iw.y -= invoiceRow
+}
+
+// writeTotals writes the subtotal, discount, tax and total below the amounts
+func (iw *invoiceWriter) writeTotals() {
+	invoice := iw.invoice
+	rows := [][2]string{{"Subtotal", formatMoney(invoice.Subtotal)}}
+	if invoice.DiscountPercentage > 0 {
+		rows = append(rows, [2]string{fmt.Sprintf("Discount (%s%%)", formatQuantity(invoice.DiscountPercentage)),
+			"-" + formatMoney(invoice.DiscountAmount)})
+	}
+	if invoice.TaxPercentage > 0 {
+		rows = append(rows, [2]string{fmt.Sprintf("Tax (%s%%)", formatQuantity(invoice.TaxPercentage)),
+			formatMoney(invoice.TaxAmount)})
+	}
+
+	if iw.y < iw.bottom()+float64(len(rows)+1)*invoiceLine+10 {
+		iw.newPage()
+	}
+
+	right := iw.doc.Width() - invoiceMargin - 6
+	for _, r := range rows {
+		iw.doc.TextRight(right-110, iw.y, invoiceFontSize, false, r[0])
+		iw.doc.TextRight(right, iw.y, invoiceFontSize, false, r[1])
+		iw.y -= invoiceLine
+	}
+
+	iw.doc.SetStrokeColor(0.6, 0.6, 0.6)
+	iw.doc.Line(right-200, iw.y+invoiceLine-3, right+6, iw.y+invoiceLine-3, 0.5)
+	iw.y -= 4
+
+	iw.doc.SetColor(iw.accent[0], iw.accent[1], iw.accent[2])
+	iw.doc.TextRight(right-110, iw.y, 11, true, "Total")
+	iw.doc.TextRight(right, iw.y, 11, true, fmt.Sprintf("%s %s", invoice.Currency, formatMoney(invoice.Total)))
+	iw.doc.SetColor(0, 0, 0)
+	iw.y -= 30
+}
+
+// writeNote writes the note of the invoice below the totals
+func (iw *invoiceWriter) writeNote() {
+	lines := splitLines(iw.invoice.Note)
+	if len(lines) == 0 {
+		return
+	}
+
+	if iw.y < iw.bottom()+2*invoiceLine {
+		iw.newPage()
+	}
+
+	width := iw.doc.Width() - 2*invoiceMargin
+	iw.doc.Text(invoiceMargin, iw.y, invoiceFontSize, true, "Note")
+	for _, line := range lines {
+		iw.y -= invoiceLine
+		if iw.y < iw.bottom() {
+			iw.newPage()
+		}
+		iw.doc.Text(invoiceMargin, iw.y, invoiceFontSize, false, pdf.Truncate(line, width, invoiceFontSize, false))
+	}
+}
+
+// writeFooter writes the footer at the bottom of the current page
+func (iw *invoiceWriter) writeFooter() {
+	width := iw.doc.Width() - 2*invoiceMargin
+	y := invoiceMargin + float64(len(iw.texts.footer)-1)*invoiceLine
+
+	iw.doc.SetColor(0.4, 0.4, 0.4)
+	for _, line := range iw.texts.footer {
+		iw.doc.Text(invoiceMargin, y, 8, false, pdf.Truncate(line, width, 8, false))
+		y -= invoiceLine
+	}
+	iw.doc.SetColor(0, 0, 0)
+}
+
+// newPage finishes the current page with the footer and continues at the top of a new one
+func (iw *invoiceWriter) newPage() {
+	iw.writeFooter()
+	iw.doc.AddPage()
+	iw.y = iw.doc.Height() - invoiceMargin - invoiceFontSize
+}
+
+// bottom returns the lowest line above the footer
+func (iw *invoiceWriter) bottom() float64 {
+	return invoiceMargin + float64(len(iw.texts.footer))*invoiceLine + 10
+}
+
+// formatMoney writes amount with two decimals and thousands separated by commas
+func formatMoney(amount float64) string {
+	s := strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+
+	if amount < 0 {
+		return "-" + whole + cents
+	}
+
+	return whole + cents
+}
+
+// formatQuantity writes q with at most two decimals
+func formatQuantity(q float64) string {
+	return strconv.FormatFloat(math.Round(q*100)/100, 'f', -1, 64)
+}
+
+// splitLines returns the lines of text without trailing empty ones
+func splitLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n ")
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
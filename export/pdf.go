@@ -0,0 +1,143 @@
+package export
+
+import (
+	"io"
+
+	"github.com/qasim-sajid/clockify-api/pdf"
+)
+
+const (
+	pdfMargin   = 30.0
+	pdfFontSize = 7.0
+	pdfRow      = 12.0
+	pdfPadding  = 6.0
+)
+
+// pdfWriter lays rows out as a table on landscape A4 pages, every page starts with the title and
+// the header. Rows are kept until Close so the columns can be sized to their content.
+type pdfWriter struct {
+	w      io.Writer
+	doc    *pdf.Document
+	title  string
+	header []string
+	rows   [][]interface{}
+	widths []float64
+	y      float64
+}
+
+func newPDFWriter(w io.Writer, title string, header []string) *pdfWriter {
+	return &pdfWriter{w: w, doc: pdf.New(pdf.A4_HEIGHT, pdf.A4_WIDTH), title: title, header: header}
+}
+
+func (pw *pdfWriter) WriteRow(cells ...interface{}) error {
+	pw.rows = append(pw.rows, cells)
+	return nil
+}
+
+func (pw *pdfWriter) Close() error {
+	pw.setWidths()
+
+	pw.newPage()
+	for _, cells := range pw.rows {
+		if pw.y < pdfMargin {
+			pw.newPage()
+		}
+
+		pw.writeCells(cells, false)
+	}
+
+	_, err := pw.doc.WriteTo(pw.w)
+	return err
+}
+
+// setWidths gives every column the width of its widest cell. When they do not fit the page the
+// narrow columns keep their width and the wide ones share the rest equally.
+func (pw *pdfWriter) setWidths() {
+	pw.widths = make([]float64, len(pw.header))
+	for i, h := range pw.header {
+		pw.widths[i] = pdf.TextWidth(h, pdfFontSize, true) + pdfPadding
+	}
+
+	for _, cells := range pw.rows {
+		for i, c := range cells {
+			if i < len(pw.widths) {
+				if w := pdf.TextWidth(formatCell(c), pdfFontSize, false) + pdfPadding; w > pw.widths[i] {
+					pw.widths[i] = w
+				}
+			}
+		}
+	}
+
+	available := pw.doc.Width() - 2*pdfMargin
+	fixed := make([]bool, len(pw.widths))
+	for {
+		remaining, flexible := available, 0
+		for i, w := range pw.widths {
+			if fixed[i] {
+				remaining -= w
+			} else {
+				flexible++
+			}
+		}
+
+		if flexible == 0 {
+			return
+		}
+
+		share := remaining / float64(flexible)
+		changed := false
+		for i, w := range pw.widths {
+			if !fixed[i] && w <= share {
+				fixed[i] = true
+				changed = true
+			}
+		}
+
+		if !changed {
+			for i := range pw.widths {
+				if !fixed[i] {
+					pw.widths[i] = share
+				}
+			}
+			return
+		}
+	}
+}
+
+func (pw *pdfWriter) newPage() {
+	pw.doc.AddPage()
+	pw.y = pw.doc.Height() - pdfMargin - 12
+
+	pw.doc.Text(pdfMargin, pw.y, 12, true, pw.title)
+	pw.y -= 2 * pdfRow
+
+	cells := make([]interface{}, 0, len(pw.header))
+	for _, h := range pw.header {
+		cells = append(cells, h)
+	}
+	pw.writeCells(cells, true)
+
+	pw.doc.SetStrokeColor(0.6, 0.6, 0.6)
+	pw.doc.Line(pdfMargin, pw.y+pdfRow-3, pw.doc.Width()-pdfMargin, pw.y+pdfRow-3, 0.5)
+}
+
+func (pw *pdfWriter) writeCells(cells []interface{}, bold bool) {
+	x := pdfMargin
+	for i, c := range cells {
+		if i >= len(pw.widths) {
+			break
+		}
+
+		text := pdf.Truncate(formatCell(c), pw.widths[i]-pdfPadding, pdfFontSize, bold)
+		switch c.(type) {
+		case float64, int:
+			pw.doc.TextRight(x+pw.widths[i]-pdfPadding, pw.y, pdfFontSize, bold, text)
+		default:
+			pw.doc.Text(x, pw.y, pdfFontSize, bold, text)
+		}
+
+		x += pw.widths[i]
+	}
+
+	pw.y -= pdfRow
+}
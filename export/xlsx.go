@@ -0,0 +1,119 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The parts of a workbook with one sheet, the sheet itself is streamed row by row
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Report" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	rows  int
+	err   error
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	xw := &xlsxWriter{zw: zip.NewWriter(w)}
+
+	for _, p := range xlsxParts {
+		f, err := xw.zw.Create(p.name)
+		if err != nil {
+			xw.err = err
+			return xw
+		}
+
+		_, err = io.WriteString(f, p.content)
+		if err != nil {
+			xw.err = err
+			return xw
+		}
+	}
+
+	xw.sheet, xw.err = xw.zw.Create("xl/worksheets/sheet1.xml")
+	if xw.err == nil {
+		_, xw.err = io.WriteString(xw.sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	}
+
+	return xw
+}
+
+func (xw *xlsxWriter) WriteRow(cells ...interface{}) error {
+	if xw.err != nil {
+		return xw.err
+	}
+	xw.rows++
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<row r="%d">`, xw.rows)
+	for i, c := range cells {
+		ref := columnName(i) + strconv.Itoa(xw.rows)
+		switch v := c.(type) {
+		case float64:
+			fmt.Fprintf(&sb, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		case int:
+			fmt.Fprintf(&sb, `<c r="%s"><v>%d</v></c>`, ref, v)
+		default:
+			fmt.Fprintf(&sb, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(&sb, []byte(formatText(c)))
+			sb.WriteString(`</t></is></c>`)
+		}
+	}
+	sb.WriteString(`</row>`)
+
+	_, xw.err = io.WriteString(xw.sheet, sb.String())
+	return xw.err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if xw.err != nil {
+		return xw.err
+	}
+
+	_, err := io.WriteString(xw.sheet, `</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+
+	return xw.zw.Close()
+}
+
+// columnName returns the letters of the column at index i, A for 0 and AA for 26
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
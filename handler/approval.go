@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+// approvalList defines how approvals can be sorted and filtered
+var approvalList = &listSpec{
+	sorts:       []string{"period_start", "submitted_at", "status"},
+	defaultSort: "-period_start",
+	filters: filterFields{
+		"status":       filterID,
+		"user_id":      filterID,
+		"period_start": filterTime,
+		"submitted_at": filterTime,
+		"reviewed_at":  filterTime,
+	},
+}
+
+var (
+	// errTaskLocked refuses changes to a time entry of an approved timesheet
+	errTaskLocked = errors.New("task is locked by an approved timesheet")
+	// errPeriodApproved refuses new time entries in the period of an approved timesheet
+	errPeriodApproved = errors.New("the timesheet of this week is approved")
+	// errOwnApproval refuses approving or rejecting the caller's own timesheet
+	errOwnApproval = errors.New("timesheets can not be reviewed by their own user")
+)
+
+// SubmitApproval submits the caller's timesheet of a week given as {"week": "2026-W42"} for
+// approval, the current week when none is given. A rejected or unlocked week can be submitted
+// again.
+func SubmitApproval(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.SubmitApprovalRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	loc, err := getUserLocation(c, h.DB, origin.ID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	start, err := parseWeek(req.Week, loc)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var approval *models.Approval
+	status := http.StatusInternalServerError
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		searchParams := workspaceFilter(c, "")
+		searchParams["user_id"] = origin.ID
+		searchParams["period_start"] = start
+
+		approvals, err := tx.GetApprovalsWithFilters(c.Request.Context(), searchParams)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		if len(approvals) == 0 {
+			approval = &models.Approval{
+				Status:      models.APPROVAL_PENDING,
+				PeriodStart: start,
+				PeriodEnd:   start.AddDate(0, 0, TIMESHEET_DAYS),
+				SubmittedAt: now,
+				User:        origin.ID,
+				Workspace:   c.Param("workspace_id"),
+			}
+
+			approval, _, err = tx.AddApproval(c.Request.Context(), approval)
+			return err
+		}
+
+		switch approvals[0].Status {
+		case models.APPROVAL_PENDING, models.APPROVAL_APPROVED:
+			status = http.StatusConflict
+			return fmt.Errorf("the timesheet of this week is %s already", approvals[0].Status)
+		}
+
+		updates := make(map[string]interface{})
+		updates["status"] = models.APPROVAL_PENDING
+		updates["submitted_at"] = now
+		updates["reviewed_at"] = nil
+		updates["reviewer_id"] = nil
+
+		approval, err = tx.UpdateApproval(c.Request.Context(), approvals[0].ID, updates)
+		return err
+	})
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	localizeApprovals(origin, approval)
+	setETag(c, approval.Version)
+	c.JSON(http.StatusOK, approval)
+}
+
+// GetAllApprovals returns the caller's approvals of the workspace, latest period first. With
+// report:view_all those of every team member are returned, or those of the user given by
+// user_id. They can be filtered, e.g. status=pending for the weeks waiting for review.
+func GetAllApprovals(c *gin.Context, h *Handler, origin *models.User) {
+	searchParams := workspaceFilter(c, "")
+
+	userID, err := getVisibleUser(c, origin)
+	if err != nil {
+		RespondError(c, http.StatusForbidden, err)
+		return
+	}
+
+	if userID != "" {
+		searchParams["user_id"] = userID
+	}
+
+	options, page, status, err := getListPage(c, h, origin, models.Approval{}, searchParams, approvalList)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	approvals, err := h.DB.GetApprovalsWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeApprovals(origin, approvals...)
+	respondList(c, options, page, approvals)
+}
+
+func GetApproval(c *gin.Context, h *Handler, origin *models.User) {
+	approval, status, err := getWorkspaceApproval(c, h, c.Param("approval_id"))
+	if err == nil && approval.User != origin.ID && !rbac.HasPermission(workspaceMember(c).TeamRole, rbac.REPORT_VIEW_ALL) {
+		status, err = http.StatusForbidden, fmt.Errorf("Missing permission %s", rbac.REPORT_VIEW_ALL)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	localizeApprovals(origin, approval)
+	setETag(c, approval.Version)
+	c.JSON(http.StatusOK, approval)
+}
+
+// ApproveApproval approves a pending timesheet and locks its time entries
+func ApproveApproval(c *gin.Context, h *Handler, origin *models.User) {
+	reviewApproval(c, h, origin, models.APPROVAL_PENDING, models.APPROVAL_APPROVED, "")
+}
+
+// RejectApproval sends a pending timesheet back to its user, a comment saying why is required
+func RejectApproval(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.ReviewApprovalRequest{}
+	err := bindRequest(c, req)
+	if err == nil && req.Comment == "" {
+		err = newFieldError("comment", "is required")
+	}
+
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	reviewApproval(c, h, origin, models.APPROVAL_PENDING, models.APPROVAL_REJECTED, req.Comment)
+}
+
+// UnlockApproval opens an approved timesheet for changes again and unlocks its time entries, the
+// user has to submit it once more
+func UnlockApproval(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.ReviewApprovalRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	reviewApproval(c, h, origin, models.APPROVAL_APPROVED, models.APPROVAL_UNLOCKED, req.Comment)
+}
+
+// reviewApproval moves the approval of the route from status from to status to, locking its time
+// entries when it is approved and unlocking them when it leaves approved
+func reviewApproval(c *gin.Context, h *Handler, origin *models.User, from, to, comment string) {
+	approvalID := c.Param("approval_id")
+
+	var approval *models.Approval
+	status := http.StatusInternalServerError
+	err := h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		approvals, err := tx.GetApprovalsForUpdate(c.Request.Context(), workspaceFilter(c, approvalID))
+		if err != nil {
+			return err
+		}
+
+		if len(approvals) == 0 {
+			status = http.StatusNotFound
+			return errors.New("approval with given id not found")
+		}
+
+		if approvals[0].User == origin.ID && from == models.APPROVAL_PENDING {
+			status = http.StatusForbidden
+			return errOwnApproval
+		}
+
+		if approvals[0].Status != from {
+			status = http.StatusConflict
+			return fmt.Errorf("only %s timesheets can be %s, this one is %s", from, to, approvals[0].Status)
+		}
+
+		updates := make(map[string]interface{})
+		updates["status"] = to
+		updates["comment"] = comment
+		updates["reviewed_at"] = time.Now().UTC().Truncate(time.Second)
+		updates["reviewer_id"] = origin.ID
+
+		approval, err = tx.UpdateApproval(c.Request.Context(), approvalID, updates)
+		if err != nil {
+			return err
+		}
+
+		return lockApprovalTasks(c, tx, approval, to == models.APPROVAL_APPROVED)
+	})
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	localizeApprovals(origin, approval)
+	setETag(c, approval.Version)
+	c.JSON(http.StatusOK, approval)
+}
+
+// lockApprovalTasks locks or unlocks the time entries of the user and workspace of approval that
+// start in its period
+func lockApprovalTasks(c *gin.Context, db dbhandler.DbHandler, approval *models.Approval, locked bool) error {
+	searchParams := make(map[string]interface{})
+	searchParams["workspace_id"] = approval.Workspace
+	searchParams["user_id"] = approval.User
+
+	tasks, err := db.GetTasksInRange(c.Request.Context(), searchParams, approval.PeriodStart, approval.PeriodEnd)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		if t.IsLocked == locked {
+			continue
+		}
+
+		_, err = db.UpdateTask(c.Request.Context(), t.ID, map[string]interface{}{"is_locked": locked})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPeriodOpen makes sure a time entry of userID starting at startTime does not fall into an
+// approved timesheet of workspaceID, entries without a workspace are never approved. It runs in
+// the transaction changing the entry and locks the approvals of the user, so none of them can be
+// approved until the change is committed and the approval locks it along with the others.
+func checkPeriodOpen(c *gin.Context, db dbhandler.DbHandler, workspaceID, userID string, startTime time.Time) (int, error) {
+	if workspaceID == "" {
+		return http.StatusOK, nil
+	}
+
+	searchParams := make(map[string]interface{})
+	searchParams["workspace_id"] = workspaceID
+	searchParams["user_id"] = userID
+
+	approvals, err := db.GetApprovalsForUpdate(c.Request.Context(), searchParams)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	for _, a := range approvals {
+		if a.Status != models.APPROVAL_APPROVED {
+			continue
+		}
+
+		if !startTime.Before(a.PeriodStart) && startTime.Before(a.PeriodEnd) {
+			return http.StatusConflict, errPeriodApproved
+		}
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+// DEFAULT_BUDGET_ALERTS are the thresholds, in percent of a budget, alerted for new projects
+const DEFAULT_BUDGET_ALERTS = "80,100"
+
+// parseBudgetAlerts parses comma separated alert thresholds in percent, such as 80,100
+func parseBudgetAlerts(value string) ([]int, error) {
+	thresholds := make([]int, 0)
+	if value == "" {
+		return thresholds, nil
+	}
+
+	for _, v := range strings.Split(value, ",") {
+		threshold, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || threshold <= 0 || threshold > 1000 {
+			return nil, fmt.Errorf("budget_alerts: %q is not a percentage between 1 and 1000", v)
+		}
+
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Ints(thresholds)
+
+	return thresholds, nil
+}
+
+// notifyProjectBudget stores a notification for every alert threshold of the project's budgets
+// that has been reached in the current budget period and was not alerted yet. It runs in the
+// transaction that changed the time entries so alerts are never lost, an alert stored by a
+// concurrent change in the meantime is skipped by AddNotification instead of failing the change.
+func notifyProjectBudget(c *gin.Context, db dbhandler.DbHandler, projectID string) error {
+	if projectID == "" {
+		return nil
+	}
+
+	project, err := db.GetProject(c.Request.Context(), projectID)
+	if err != nil {
+		return err
+	}
+
+	thresholds, err := parseBudgetAlerts(project.BudgetAlerts)
+	if err != nil {
+		return err
+	}
+
+	budgets := []struct {
+		kind       string
+		limit      float64
+		percentage float64
+		name       string
+	}{
+		{models.NOTIFICATION_TIME_BUDGET, project.EstimateHours, project.ProgressPercentage, "time estimate"},
+		{models.NOTIFICATION_MONEY_BUDGET, project.BudgetAmount, project.BudgetPercentage, "budget"},
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	periodStart := project.BudgetPeriodStart(now)
+
+	var recipients []*models.User
+	for _, b := range budgets {
+		if b.limit <= 0 {
+			continue
+		}
+
+		for _, threshold := range thresholds {
+			if b.percentage < float64(threshold) {
+				break
+			}
+
+			searchParams := make(map[string]interface{})
+			searchParams["project_id"] = project.ID
+			searchParams["kind"] = b.kind
+			searchParams["threshold"] = threshold
+			searchParams["period_start"] = periodStart
+
+			sent, err := db.GetNotificationsWithFilters(c.Request.Context(), searchParams)
+			if err != nil {
+				return err
+			}
+
+			if len(sent) > 0 {
+				continue
+			}
+
+			if recipients == nil {
+				recipients, err = getBudgetRecipients(c, db, project.Workspace)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, u := range recipients {
+				notification := &models.Notification{}
+				notification.Kind = b.kind
+				notification.Threshold = threshold
+				notification.PeriodStart = periodStart
+				notification.Message = fmt.Sprintf("Project %s reached %d%% of its %s", project.Name, threshold, b.name)
+				notification.CreatedAt = now
+				notification.User = u.ID
+				notification.Workspace = project.Workspace
+				notification.Project = project.ID
+
+				_, _, err = db.AddNotification(c.Request.Context(), notification)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// getBudgetRecipients returns the users of the workspace whose team role can edit projects
+func getBudgetRecipients(c *gin.Context, db dbhandler.DbHandler, workspaceID string) ([]*models.User, error) {
+	teamMembers, err := db.GetTeamMembersWithFilters(c.Request.Context(), map[string]interface{}{"workspace_id": workspaceID})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, 0)
+	for _, tm := range teamMembers {
+		if !rbac.HasPermission(tm.TeamRole, rbac.PROJECT_EDIT) {
+			continue
+		}
+
+		memberUsers, err := db.GetUsersWithFilters(c.Request.Context(), map[string]interface{}{"email": tm.User})
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, memberUsers...)
+	}
+
+	return users, nil
+}
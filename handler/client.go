@@ -3,77 +3,118 @@ package handler
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
+// clientList defines how clients can be sorted and filtered
+var clientList = &listSpec{
+	sorts:       []string{"name", "is_archived"},
+	defaultSort: "name",
+	filters: filterFields{
+		"name":        filterText,
+		"address":     filterText,
+		"note":        filterText,
+		"is_archived": filterBool,
+	},
+}
+
 func AddClient(c *gin.Context, h *Handler, origin *models.User) {
-	client := &models.Client{}
-	client.Name = c.Query("name")
-	client.Address = c.Query("address")
-	client.Note = c.Query("note")
-	isArchived, err := strconv.ParseBool(c.Query("is_archived"))
+	req := &models.AddClientRequest{}
+	err := bindRequest(c, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
 		return
 	}
-	client.IsArchived = isArchived
 
-	client, _, err = h.DB.AddClient(client)
+	client := &models.Client{}
+	client.Name = req.Name
+	client.Address = req.Address
+	client.Note = req.Note
+	client.IsArchived = req.IsArchived
+
+	client.Workspace = c.Param("workspace_id")
+
+	client, _, err = h.DB.AddClient(c.Request.Context(), client)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Client with _id = %s added!", client.ID)})
 	}
 }
 
 func GetAllClients(c *gin.Context, h *Handler, origin *models.User) {
-	clients, err := h.DB.GetAllClients()
+	options, page, status, err := getListPage(c, h, origin, models.Client{}, workspaceFilter(c, ""), clientList)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, clients)
+	clients, err := h.DB.GetClientsWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondList(c, options, page, clients)
 }
 
 func GetClient(c *gin.Context, h *Handler, origin *models.User) {
 	clientID := c.Param("client_id")
-	client, err := h.DB.GetClient(clientID)
+	client, status, err := getWorkspaceClient(c, h, clientID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
+		setETag(c, client.Version)
 		c.JSON(http.StatusOK, client)
 	}
 }
 
 func UpdateClient(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	clientID := c.Param("client_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
+	client, status, err := getWorkspaceClient(c, h, clientID)
+	if err == nil {
+		status, err = checkIfMatch(c, client.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
 	}
 
-	_, err := h.DB.UpdateClient(clientID, updates)
+	updates, err := bindPatch(c, &models.UpdateClientRequest{})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	expectVersion(updates, client.Version)
+
+	client, err = h.DB.UpdateClient(c.Request.Context(), clientID, updates)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Client with _id = %s updated!", clientID)})
+		setETag(c, client.Version)
+		c.JSON(http.StatusOK, client)
 	}
 }
 
 func DeleteClient(c *gin.Context, h *Handler, origin *models.User) {
 	clientID := c.Param("client_id")
-	err := h.DB.DeleteClient(clientID)
+	client, status, err := getWorkspaceClient(c, h, clientID)
+	if err == nil {
+		status, err = checkIfMatch(c, client.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.DeleteClient(c.Request.Context(), clientID, client.Version)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Client with _id = %s deleted!", clientID)})
 	}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+)
+
+// errorCodes are the codes of error responses by their status, clients should tell errors apart
+// by their code rather than their message
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusPreconditionFailed:    "precondition_failed",
+	http.StatusRequestEntityTooLarge: "too_large",
+	http.StatusUnprocessableEntity:   "validation_failed",
+	http.StatusPreconditionRequired:  "precondition_required",
+	http.StatusInternalServerError:   "internal",
+	http.StatusGatewayTimeout:        "timeout",
+}
+
+// kindStatuses are the statuses of the kinds of errors returned by the db handler
+var kindStatuses = map[error]int{
+	dbhandler.ErrNotFound:           http.StatusNotFound,
+	dbhandler.ErrConflict:           http.StatusConflict,
+	dbhandler.ErrValidation:         http.StatusUnprocessableEntity,
+	dbhandler.ErrForbidden:          http.StatusForbidden,
+	dbhandler.ErrPreconditionFailed: http.StatusPreconditionFailed,
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details"`
+	RequestID string      `json:"request_id"`
+}
+
+// RespondError responds with err and aborts the request. Errors of the db handler get the status
+// of their kind and a request body with invalid fields is a validation error listing them, other
+// errors get status. Server errors are logged with the request id and the client is only told
+// that something went wrong.
+func RespondError(c *gin.Context, status int, err error) {
+	resp := &ErrorResponse{Message: err.Error(), RequestID: c.GetString(REQUEST_ID_KEY)}
+
+	var reqErr *requestError
+	var dbErr *dbhandler.Error
+	switch {
+	case errors.As(err, &reqErr):
+		status, resp.Message, resp.Details = http.StatusUnprocessableEntity, "invalid request", reqErr.Fields
+	case errors.As(err, &dbErr):
+		status, resp.Message, resp.Details = kindStatuses[dbErr.Kind], dbErr.Message, dbErr.Details
+	case errors.Is(err, context.DeadlineExceeded):
+		status, resp.Message = http.StatusGatewayTimeout, "the request took too long"
+	}
+
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+
+	if status >= http.StatusInternalServerError {
+		log.Printf("request %s: %s %s: %v", resp.RequestID, c.Request.Method, c.Request.URL.Path, err)
+		if status == http.StatusInternalServerError {
+			resp.Message = "internal server error"
+		}
+	}
+
+	resp.Code = errorCodes[status]
+	if resp.Code == "" && status < http.StatusInternalServerError {
+		resp.Code = errorCodes[http.StatusBadRequest]
+	} else if resp.Code == "" {
+		resp.Code = errorCodes[http.StatusInternalServerError]
+	}
+
+	c.AbortWithStatusJSON(status, resp)
+}
+
+// RouteNotFound responds to requests no route matches
+func RouteNotFound(c *gin.Context) {
+	RespondError(c, http.StatusNotFound, fmt.Errorf("no route for %s %s", c.Request.Method, c.Request.URL.Path))
+}
+
+// MethodNotAllowed responds to requests for a route that does not take their method
+func MethodNotAllowed(c *gin.Context) {
+	RespondError(c, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed on %s", c.Request.Method,
+		c.Request.URL.Path))
+}
+
+// RecoverPanic responds to a request whose handler panicked, recovered is what it panicked with
+func RecoverPanic(c *gin.Context, recovered interface{}) {
+	RespondError(c, http.StatusInternalServerError, fmt.Errorf("panic: %v", recovered))
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+)
+
+// etag returns the entity tag of a resource at version, resources without a version yet such as
+// the default invoice template are at version 0
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// setETag sends the version of the resource the response holds as its ETag
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", etag(version))
+}
+
+// checkIfMatch makes sure a request changing or deleting a resource was made from its current
+// version: its If-Match header has to list the ETag of version, or be * for any version.
+func checkIfMatch(c *gin.Context, version int) (int, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return http.StatusPreconditionRequired, errors.New("the If-Match header with the ETag of the resource is required")
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag(version) {
+			return http.StatusOK, nil
+		}
+	}
+
+	return http.StatusPreconditionFailed, dbhandler.PreconditionFailed(
+		"the resource was changed since it was read, its ETag is now %s", etag(version))
+}
+
+// expectVersion makes updates fail with ErrPreconditionFailed when the resource moved on from
+// version by the time they are stored
+func expectVersion(updates map[string]interface{}, version int) {
+	updates[dbhandler.VERSION_COLUMN] = version
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// filterKind is the kind of values a column is filtered by
+type filterKind int
+
+const (
+	filterText filterKind = iota
+	filterID
+	filterBool
+	filterNumber
+	filterTime
+	// filterRelation is a list of ids linked to the item, such as the tags of a time entry
+	filterRelation
+)
+
+// filterOperators are the operators each kind of column can be filtered with, the first is used
+// when none is given
+var filterOperators = map[filterKind][]string{
+	filterText:     {models.FILTER_EQ, models.FILTER_NE, models.FILTER_CONTAINS, models.FILTER_IN},
+	filterID:       {models.FILTER_EQ, models.FILTER_NE, models.FILTER_IN},
+	filterBool:     {models.FILTER_EQ, models.FILTER_NE},
+	filterNumber:   {models.FILTER_EQ, models.FILTER_NE, models.FILTER_GT, models.FILTER_GTE, models.FILTER_LT, models.FILTER_LTE},
+	filterTime:     {models.FILTER_EQ, models.FILTER_NE, models.FILTER_GT, models.FILTER_GTE, models.FILTER_LT, models.FILTER_LTE},
+	filterRelation: {models.FILTER_ANY},
+}
+
+// filterFields are the columns a list can be filtered by with the kind of their values
+type filterFields map[string]filterKind
+
+// listParams are the query parameters of a list route that are not filters
+var listParams = []string{"limit", "page_size", "page", "cursor", "sort"}
+
+var filterKeyRegexp = regexp.MustCompile(`^(?:or\.([A-Za-z0-9_]+)\.)?([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+// parseFilter reads the filters of fields from the query, e.g. start_time[gte]=2022-03-01,
+// project_id[in]=a,b, description[contains]=meeting, tags[any]=a,b or billable=true. Filters are
+// joined with AND, those whose name starts with the same or.<group>. are joined with OR first:
+// or.1.billable=true&or.1.project_id=a matches the billable entries and those of project a. Times
+// are RFC 3339 timestamps or dates, the start of that day in loc. Other query parameters are left
+// to the route, unless they name an operator or a group.
+func parseFilter(c *gin.Context, fields filterFields, loc *time.Location) (*models.Filter, error) {
+	query := c.Request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if !containsString(listParams, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	root := &models.Filter{}
+	groups := make(map[string]*models.Filter)
+	groupNames := make([]string, 0)
+	for _, k := range keys {
+		match := filterKeyRegexp.FindStringSubmatch(k)
+		explicit := strings.ContainsAny(k, ".[")
+		if match == nil {
+			if explicit {
+				return nil, fmt.Errorf("%s: invalid filter", k)
+			}
+			continue
+		}
+
+		group, column, operator := match[1], match[2], match[3]
+		kind, ok := fields[column]
+		if !ok {
+			if explicit {
+				return nil, fmt.Errorf("%s: can not filter by %s", k, column)
+			}
+			continue
+		}
+
+		operators := filterOperators[kind]
+		if operator == "" {
+			operator = operators[0]
+		}
+
+		if !containsString(operators, operator) {
+			return nil, fmt.Errorf("%s: expected one of the operators %s", k, strings.Join(operators, ", "))
+		}
+
+		parent := root
+		if group != "" {
+			if _, ok := groups[group]; !ok {
+				groups[group] = &models.Filter{Or: true}
+				groupNames = append(groupNames, group)
+			}
+			parent = groups[group]
+		}
+
+		for _, v := range query[k] {
+			value, err := parseFilterValue(k, v, kind, operator, loc)
+			if err != nil {
+				return nil, err
+			}
+
+			parent.Filters = append(parent.Filters, &models.Filter{Column: column, Operator: operator, Value: value})
+		}
+	}
+
+	sort.Strings(groupNames)
+	for _, g := range groupNames {
+		root.Filters = append(root.Filters, groups[g])
+	}
+
+	if len(root.Filters) == 0 {
+		return nil, nil
+	}
+
+	return root, nil
+}
+
+// parseFilterValue parses the value of the filter name, a comma separated list for the in and any
+// operators
+func parseFilterValue(name, value string, kind filterKind, operator string, loc *time.Location) (interface{}, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%s: a value is required", name)
+	}
+
+	if operator == models.FILTER_IN || operator == models.FILTER_ANY {
+		values := make([]string, 0)
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%s: a value is required", name)
+		}
+
+		return values, nil
+	}
+
+	switch kind {
+	case filterBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected true or false", name)
+		}
+
+		return b, nil
+	case filterNumber:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected a number", name)
+		}
+
+		return f, nil
+	case filterTime:
+		if t, err := time.ParseInLocation(DATE_LAYOUT, value, loc); err == nil {
+			return t, nil
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected a date such as 2006-01-02 or an RFC 3339 timestamp", name)
+		}
+
+		return t, nil
+	}
+
+	return value, nil
+}
@@ -1,12 +1,27 @@
 package handler
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/qasim-sajid/clockify-api/conf"
 	"github.com/qasim-sajid/clockify-api/dbhandler"
 )
 
+// WORKSPACE_MEMBER_KEY is the context key of the caller's team member in the workspace of the route
+const WORKSPACE_MEMBER_KEY = "workspace_member"
+
+// REQUEST_ID_KEY is the context key of the id of the request
+const REQUEST_ID_KEY = "request_id"
+
+// REQUEST_ID_HEADER carries the id of a request, in the request when the client picks it and in
+// every response
+const REQUEST_ID_HEADER = "X-Request-ID"
+
 //Handler defines the handler struct for APIs
 type Handler struct {
 	DB dbhandler.DbHandler
@@ -14,7 +29,13 @@ type Handler struct {
 
 //NewHandler implements constructor for Handler
 func NewHandler() (*Handler, error) {
-	dbC, err := dbhandler.NewDBClient(conf.Configs.DBName)
+	var dbC dbhandler.DbHandler
+	var err error
+	if conf.Configs.DBDriver == conf.DB_DRIVER_MEMORY {
+		dbC, err = dbhandler.NewMemClient(conf.Configs.DBName)
+	} else {
+		dbC, err = dbhandler.NewDBClient(conf.Configs.DBName)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("NewHandler: %v", err)
 	}
@@ -23,3 +44,39 @@ func NewHandler() (*Handler, error) {
 		DB: dbC,
 	}, nil
 }
+
+// QueryTimeout bounds the database work of every request. The request context is also
+// cancelled when the client disconnects, so running queries are stopped in both cases.
+// A zero timeout leaves only the disconnect cancellation in place.
+func QueryTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+var requestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an id to find it by in the logs, the one the client sent in the
+// X-Request-ID header when it is valid. The id is sent back in the same header and is part of
+// every error response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(REQUEST_ID_HEADER)
+		if !requestIDRegexp.MatchString(id) {
+			id = uuid.New().String()
+		}
+
+		c.Set(REQUEST_ID_KEY, id)
+		c.Header(REQUEST_ID_HEADER, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,573 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/export"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// DEFAULT_INVOICE_CURRENCY is the currency of invoices created without one
+const DEFAULT_INVOICE_CURRENCY = "USD"
+
+// invoiceList defines how invoices can be sorted and filtered
+var invoiceList = &listSpec{
+	sorts:       []string{"number", "issue_date", "status"},
+	defaultSort: "-number",
+	filters: filterFields{
+		"number":     filterNumber,
+		"status":     filterID,
+		"client_id":  filterID,
+		"currency":   filterID,
+		"issue_date": filterTime,
+		"due_date":   filterTime,
+	},
+}
+
+var (
+	// errInvoiceNotDraft refuses changes to an invoice that was sent
+	errInvoiceNotDraft = errors.New("only draft invoices can be changed")
+	// errTaskInvoiced refuses changes to a time entry billed on an invoice
+	errTaskInvoiced = errors.New("task is billed on an invoice, remove it from the invoice first")
+)
+
+// AddInvoice creates a draft invoice for client_id billing its uninvoiced billable time entries
+// from start to end, days in the caller's time zone. The entries become one item per project and
+// rate and are marked as invoiced.
+func AddInvoice(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.AddInvoiceRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status, err := checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	loc := userLocation(origin)
+	invoice := &models.Invoice{}
+	invoice.Status = models.INVOICE_DRAFT
+	invoice.Note = req.Note
+	invoice.Client = req.Client
+	invoice.Workspace = c.Param("workspace_id")
+	invoice.TaxPercentage = req.TaxPercentage
+	invoice.DiscountPercentage = req.DiscountPercentage
+
+	invoice.PeriodStart, err = parseDate("start", req.Start, loc)
+	if err == nil {
+		invoice.PeriodEnd, err = parseDate("end", req.End, loc)
+	}
+
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	invoice.PeriodEnd = invoice.PeriodEnd.AddDate(0, 0, 1)
+	if !invoice.PeriodEnd.After(invoice.PeriodStart) {
+		RespondError(c, http.StatusBadRequest, newFieldError("end", "must not be before start"))
+		return
+	}
+
+	updates := map[string]interface{}{"currency": req.Currency, "issue_date": req.IssueDate, "due_date": req.DueDate}
+	for k, v := range updates {
+		if v == "" {
+			delete(updates, k)
+		}
+	}
+
+	err = parseInvoiceUpdates(origin, updates)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	invoice.Currency = DEFAULT_INVOICE_CURRENCY
+	if v, ok := updates["currency"]; ok {
+		invoice.Currency = v.(string)
+	}
+
+	invoice.IssueDate = startOfDay(time.Now(), loc)
+	if v, ok := updates["issue_date"]; ok {
+		invoice.IssueDate = v.(time.Time)
+	}
+
+	if v, ok := updates["due_date"].(time.Time); ok {
+		invoice.DueDate = &v
+	}
+
+	billable, invoiced := true, false
+	filter := &models.ReportFilter{}
+	filter.Workspace = invoice.Workspace
+	filter.Client = invoice.Client
+	filter.Start = invoice.PeriodStart
+	filter.End = invoice.PeriodEnd
+	filter.Billable = &billable
+	filter.Invoiced = &invoiced
+
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		entries, err := getInvoiceEntries(c, tx, filter)
+		if err != nil {
+			return err
+		}
+
+		var taskIDs [][]string
+		invoice.Items, taskIDs = getInvoiceItems(entries)
+
+		invoice, _, err = tx.AddInvoice(c.Request.Context(), invoice)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range invoice.Items {
+			err = tx.InvoiceTasks(c.Request.Context(), item.ID, taskIDs[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeInvoices(origin, invoice)
+	c.JSON(http.StatusOK, invoice)
+}
+
+// GetAllInvoices returns the invoices of the workspace, latest number first. They can be filtered,
+// e.g. by status and client_id.
+func GetAllInvoices(c *gin.Context, h *Handler, origin *models.User) {
+	options, page, status, err := getListPage(c, h, origin, models.Invoice{}, workspaceFilter(c, ""), invoiceList)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	invoices, err := h.DB.GetInvoicesWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeInvoices(origin, invoices...)
+	respondList(c, options, page, invoices)
+}
+
+func GetInvoice(c *gin.Context, h *Handler, origin *models.User) {
+	invoice, status, err := getWorkspaceInvoice(c, h, c.Param("invoice_id"))
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	localizeInvoices(origin, invoice)
+	setETag(c, invoice.Version)
+	c.JSON(http.StatusOK, invoice)
+}
+
+// GetInvoicePDF downloads the invoice as a PDF laid out with the invoice template of the workspace
+func GetInvoicePDF(c *gin.Context, h *Handler, origin *models.User) {
+	invoice, status, err := getWorkspaceInvoice(c, h, c.Param("invoice_id"))
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	client, status, err := getWorkspaceClient(c, h, invoice.Client)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	workspace, err := h.DB.GetWorkspace(c.Request.Context(), invoice.Workspace)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	template, err := getInvoiceTemplate(c, h)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The document is rendered before anything is sent so a template error can still be reported
+	var buf bytes.Buffer
+	localizeInvoices(origin, invoice)
+	err = export.WriteInvoice(&buf, invoice, client, workspace, template)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="invoice-%d.pdf"`, invoice.Number))
+	c.Data(http.StatusOK, export.ContentType(export.FORMAT_PDF), buf.Bytes())
+}
+
+// UpdateInvoice changes a draft invoice or moves an invoice on from draft to sent and from sent to
+// paid
+func UpdateInvoice(c *gin.Context, h *Handler, origin *models.User) {
+	invoiceID := c.Param("invoice_id")
+	invoice, status, err := getWorkspaceInvoice(c, h, invoiceID)
+	if err == nil {
+		status, err = checkIfMatch(c, invoice.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	updates, err := bindPatch(c, &models.UpdateInvoiceRequest{})
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	for k := range updates {
+		if k != "status" && invoice.Status != models.INVOICE_DRAFT {
+			RespondError(c, http.StatusConflict, errInvoiceNotDraft)
+			return
+		}
+	}
+
+	if v, ok := updates["status"]; ok {
+		status, err = checkInvoiceStatus(invoice.Status, fmt.Sprint(v))
+		if err != nil {
+			RespondError(c, status, err)
+			return
+		}
+	}
+
+	err = parseInvoiceUpdates(origin, updates)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	expectVersion(updates, invoice.Version)
+
+	invoice, err = h.DB.UpdateInvoice(c.Request.Context(), invoiceID, updates)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeInvoices(origin, invoice)
+	setETag(c, invoice.Version)
+	c.JSON(http.StatusOK, invoice)
+}
+
+// DeleteInvoice deletes a draft invoice, its time entries can be invoiced again
+func DeleteInvoice(c *gin.Context, h *Handler, origin *models.User) {
+	invoiceID := c.Param("invoice_id")
+	invoice, status, err := getDraftInvoice(c, h, invoiceID)
+	if err == nil {
+		status, err = checkIfMatch(c, invoice.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.DeleteInvoice(c.Request.Context(), invoiceID, invoice.Version)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+	} else {
+		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Invoice with _id = %s deleted!", invoiceID)})
+	}
+}
+
+// AddInvoiceItem adds a line with a description, quantity and unit_price to a draft invoice
+func AddInvoiceItem(c *gin.Context, h *Handler, origin *models.User) {
+	invoice, status, err := getDraftInvoice(c, h, c.Param("invoice_id"))
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	req := &models.AddInvoiceItemRequest{}
+	err = bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status, err = checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	item := &models.InvoiceItem{}
+	item.Invoice = invoice.ID
+	item.Description = req.Description
+	item.Quantity = *req.Quantity
+	item.UnitPrice = *req.UnitPrice
+	item.Project = req.Project
+
+	for _, i := range invoice.Items {
+		if i.Position >= item.Position {
+			item.Position = i.Position + 1
+		}
+	}
+
+	invoice, err = changeInvoiceLines(c, h, invoice, func(tx dbhandler.DbHandler) error {
+		_, _, err := tx.AddInvoiceItem(c.Request.Context(), item)
+		return err
+	})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeInvoices(origin, invoice)
+	setETag(c, invoice.Version)
+	c.JSON(http.StatusOK, invoice)
+}
+
+// UpdateInvoiceItem changes the description, quantity or unit_price of a line of a draft invoice
+func UpdateInvoiceItem(c *gin.Context, h *Handler, origin *models.User) {
+	invoice, item, status, err := getDraftInvoiceItem(c, h)
+	if err == nil {
+		status, err = checkIfMatch(c, invoice.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	updates, err := bindPatch(c, &models.UpdateInvoiceItemRequest{})
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	expectVersion(updates, item.Version)
+
+	invoice, err = changeInvoiceLines(c, h, invoice, func(tx dbhandler.DbHandler) error {
+		_, err := tx.UpdateInvoiceItem(c.Request.Context(), item.ID, updates)
+		return err
+	})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeInvoices(origin, invoice)
+	setETag(c, invoice.Version)
+	c.JSON(http.StatusOK, invoice)
+}
+
+// DeleteInvoiceItem removes a line of a draft invoice, the time entries billed on it can be
+// invoiced again
+func DeleteInvoiceItem(c *gin.Context, h *Handler, origin *models.User) {
+	invoice, item, status, err := getDraftInvoiceItem(c, h)
+	if err == nil {
+		status, err = checkIfMatch(c, invoice.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	invoice, err = changeInvoiceLines(c, h, invoice, func(tx dbhandler.DbHandler) error {
+		return tx.DeleteInvoiceItem(c.Request.Context(), item.ID, item.Version)
+	})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	localizeInvoices(origin, invoice)
+	setETag(c, invoice.Version)
+	c.JSON(http.StatusOK, invoice)
+}
+
+// changeInvoiceLines runs fn changing the lines of invoice and moves the invoice on to a new
+// version along with them, the lines stay unchanged when the invoice was changed since it was read
+func changeInvoiceLines(c *gin.Context, h *Handler, invoice *models.Invoice, fn func(tx dbhandler.DbHandler) error) (*models.Invoice, error) {
+	var changed *models.Invoice
+	err := h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		err := fn(tx)
+		if err != nil {
+			return err
+		}
+
+		updates := make(map[string]interface{})
+		expectVersion(updates, invoice.Version)
+		changed, err = tx.UpdateInvoice(c.Request.Context(), invoice.ID, updates)
+		return err
+	})
+
+	return changed, err
+}
+
+// getDraftInvoice returns the invoice of the workspace with invoiceID when it can still be changed
+func getDraftInvoice(c *gin.Context, h *Handler, invoiceID string) (*models.Invoice, int, error) {
+	invoice, status, err := getWorkspaceInvoice(c, h, invoiceID)
+	if err != nil {
+		return nil, status, err
+	}
+
+	if invoice.Status != models.INVOICE_DRAFT {
+		return nil, http.StatusConflict, errInvoiceNotDraft
+	}
+
+	return invoice, http.StatusOK, nil
+}
+
+// getDraftInvoiceItem returns the draft invoice of the route and the item of the route, which has
+// to be one of its lines
+func getDraftInvoiceItem(c *gin.Context, h *Handler) (*models.Invoice, *models.InvoiceItem, int, error) {
+	invoice, status, err := getDraftInvoice(c, h, c.Param("invoice_id"))
+	if err != nil {
+		return nil, nil, status, err
+	}
+
+	itemID := c.Param("item_id")
+	for _, item := range invoice.Items {
+		if item.ID == itemID {
+			return invoice, item, http.StatusOK, nil
+		}
+	}
+
+	return nil, nil, http.StatusNotFound, errors.New("invoice item with given id not found")
+}
+
+// getInvoiceEntries returns every time entry matching filter, read in a single query so that no
+// entry is skipped or billed twice when entries change in between
+func getInvoiceEntries(c *gin.Context, db dbhandler.DbHandler, filter *models.ReportFilter) ([]*models.DetailedEntry, error) {
+	entries := make([]*models.DetailedEntry, 0)
+	err := db.EachDetailedEntry(c.Request.Context(), filter, func(e *models.DetailedEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// getInvoiceItems sums entries into one item per project and rate, ordered by project name. The
+// ids of the entries of each item are returned along with it.
+func getInvoiceItems(entries []*models.DetailedEntry) ([]*models.InvoiceItem, [][]string) {
+	type itemKey struct {
+		project string
+		rate    float64
+	}
+
+	items := make([]*models.InvoiceItem, 0)
+	taskIDs := make(map[*models.InvoiceItem][]string)
+	byKey := make(map[itemKey]*models.InvoiceItem)
+	for _, e := range entries {
+		key := itemKey{e.ProjectID, e.Rate}
+		item, ok := byKey[key]
+		if !ok {
+			item = &models.InvoiceItem{Description: e.ProjectName, UnitPrice: e.Rate, Project: e.ProjectID}
+			if e.ProjectID == "" {
+				item.Description = "Time without project"
+			}
+
+			byKey[key] = item
+			items = append(items, item)
+		}
+
+		item.Quantity += e.Hours
+		taskIDs[item] = append(taskIDs[item], e.ID)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Description != items[j].Description {
+			return items[i].Description < items[j].Description
+		}
+		return items[i].UnitPrice > items[j].UnitPrice
+	})
+
+	ids := make([][]string, 0, len(items))
+	for _, item := range items {
+		item.Quantity = math.Round(item.Quantity*100) / 100
+		ids = append(ids, taskIDs[item])
+	}
+
+	return items, ids
+}
+
+// checkInvoiceStatus makes sure an invoice moves on from draft to sent and from sent to paid
+func checkInvoiceStatus(from, to string) (int, error) {
+	switch to {
+	case models.INVOICE_DRAFT, models.INVOICE_SENT, models.INVOICE_PAID:
+	default:
+		return http.StatusBadRequest, fmt.Errorf("status: expected %s, %s or %s", models.INVOICE_DRAFT,
+			models.INVOICE_SENT, models.INVOICE_PAID)
+	}
+
+	if from == to || (from == models.INVOICE_DRAFT && to == models.INVOICE_SENT) ||
+		(from == models.INVOICE_SENT && to == models.INVOICE_PAID) {
+		return http.StatusOK, nil
+	}
+
+	return http.StatusConflict, fmt.Errorf("status: a %s invoice can not become %s", from, to)
+}
+
+// parseInvoiceUpdates converts the currency and dates in updates from their request values, dates
+// are days in the time zone of user and an empty due_date clears it
+func parseInvoiceUpdates(user *models.User, updates map[string]interface{}) error {
+	loc := userLocation(user)
+	for k, v := range updates {
+		value, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch k {
+		case "currency":
+			updates[k] = strings.ToUpper(value)
+		case "issue_date":
+			updates[k], err = parseDate(k, value, loc)
+		case "due_date":
+			if value == "" {
+				updates[k] = nil
+			} else {
+				updates[k], err = parseDate(k, value, loc)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/export"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+const (
+	// MAX_LOGO_SIZE is the largest logo in bytes that can be uploaded
+	MAX_LOGO_SIZE = 1 << 20
+	// LOGO_JPEG_QUALITY is the quality uploaded logos are stored with
+	LOGO_JPEG_QUALITY = 90
+)
+
+// defaultInvoiceTemplate returns the template of a workspace that did not customize its own
+func defaultInvoiceTemplate(workspaceID string) *models.InvoiceTemplate {
+	return &models.InvoiceTemplate{
+		Title:       "Invoice {{.Number}}",
+		AccentColor: "#1f6feb",
+		Footer:      "{{if .DueDate}}Please pay {{.Currency}} {{.Total}} by {{.DueDate}}.{{end}}",
+		Workspace:   workspaceID,
+	}
+}
+
+// GetInvoiceTemplate returns the template the workspace renders its invoices with
+func GetInvoiceTemplate(c *gin.Context, h *Handler, origin *models.User) {
+	template, err := getInvoiceTemplate(c, h)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, template.Version)
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateInvoiceTemplate changes the title, accent_color, header or footer of the invoice template
+// of the workspace
+func UpdateInvoiceTemplate(c *gin.Context, h *Handler, origin *models.User) {
+	updates, err := bindPatch(c, &models.UpdateInvoiceTemplateRequest{})
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	saveInvoiceTemplate(c, h, updates)
+}
+
+// UpdateInvoiceTemplateLogo sets the logo of the invoice template of the workspace to the PNG or
+// JPEG image in the request body
+func UpdateInvoiceTemplateLogo(c *gin.Context, h *Handler, origin *models.User) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MAX_LOGO_SIZE))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, fmt.Errorf("logo: expected at most %d bytes", MAX_LOGO_SIZE))
+		return
+	}
+
+	logo, err := encodeLogo(data)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	saveInvoiceTemplate(c, h, map[string]interface{}{"logo": logo})
+}
+
+// DeleteInvoiceTemplateLogo removes the logo of the invoice template of the workspace
+func DeleteInvoiceTemplateLogo(c *gin.Context, h *Handler, origin *models.User) {
+	saveInvoiceTemplate(c, h, map[string]interface{}{"logo": ""})
+}
+
+// getInvoiceTemplate returns the invoice template of the workspace of the route, the default one
+// at version 0 when it has none
+func getInvoiceTemplate(c *gin.Context, h *Handler) (*models.InvoiceTemplate, error) {
+	workspaceID := c.Param("workspace_id")
+	templates, err := h.DB.GetInvoiceTemplatesWithFilters(c.Request.Context(),
+		map[string]interface{}{"workspace_id": workspaceID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(templates) == 0 {
+		return defaultInvoiceTemplate(workspaceID), nil
+	}
+
+	return templates[0], nil
+}
+
+// saveInvoiceTemplate applies updates to the invoice template of the workspace of the route,
+// storing the default one with them when it has none, and responds with the template
+func saveInvoiceTemplate(c *gin.Context, h *Handler, updates map[string]interface{}) {
+	template, err := getInvoiceTemplate(c, h)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	status, err := checkIfMatch(c, template.Version)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	changed := *template
+	for k, v := range updates {
+		switch k {
+		case "title":
+			changed.Title = v.(string)
+		case "accent_color":
+			changed.AccentColor = v.(string)
+		case "header":
+			changed.Header = v.(string)
+		case "footer":
+			changed.Footer = v.(string)
+		case "logo":
+			changed.Logo = v.(string)
+		}
+	}
+
+	err = export.CheckInvoiceTemplate(&changed)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if template.ID == "" {
+		template, _, err = h.DB.AddInvoiceTemplate(c.Request.Context(), &changed)
+	} else {
+		expectVersion(updates, template.Version)
+		template, err = h.DB.UpdateInvoiceTemplate(c.Request.Context(), template.ID, updates)
+	}
+
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, template.Version)
+	c.JSON(http.StatusOK, template)
+}
+
+// encodeLogo converts a PNG or JPEG image to a base64 encoded JPEG, transparent parts become white
+func encodeLogo(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("logo: expected a PNG or JPEG image: %v", err)
+	}
+
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, flat, &jpeg.Options{Quality: LOGO_JPEG_QUALITY})
+	if err != nil {
+		return "", fmt.Errorf("logo: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
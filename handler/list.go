@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+const (
+	// DEFAULT_LIST_LIMIT and MAX_LIST_LIMIT limit the items of a page of a list route
+	DEFAULT_LIST_LIMIT = 50
+	MAX_LIST_LIMIT     = 500
+)
+
+// listSpec defines how the items of a list route can be sorted and filtered, defaultSort is used
+// when no sort is given
+type listSpec struct {
+	sorts       []string
+	defaultSort string
+	filters     filterFields
+}
+
+// listCursor is what a next_cursor carries: the sort order of the list and the sort values and
+// _id of the last item of the page
+type listCursor struct {
+	Sort  string        `json:"sort"`
+	After []interface{} `json:"after"`
+}
+
+// getListPage reads the page of the rows of structType matching searchParams and the filters that
+// the query asks for, sorted and filtered as list allows. Times are read in the time zone of origin.
+func getListPage(c *gin.Context, h *Handler, origin *models.User, structType interface{},
+	searchParams map[string]interface{}, list *listSpec) (*models.ListOptions, *models.IDPage, int, error) {
+	options, err := parseListOptions(c, list, userLocation(origin))
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, err
+	}
+
+	page, err := h.DB.GetIDPage(c.Request.Context(), structType, searchParams, options)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	return options, page, http.StatusOK, nil
+}
+
+// respondList responds with items, the rows of page loaded by their ids, in the order of the page
+func respondList(c *gin.Context, options *models.ListOptions, page *models.IDPage, items interface{}) {
+	list := &models.List{Items: sortByIDs(items, page.IDs), Total: page.Total, Limit: options.Limit}
+	if options.Offset > 0 || c.Query("page") != "" {
+		list.Page = options.Offset/options.Limit + 1
+	}
+
+	if page.Next != nil {
+		cursor, err := json.Marshal(&listCursor{Sort: formatSort(options.Sort), After: page.Next})
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		list.NextCursor = base64.RawURLEncoding.EncodeToString(cursor)
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// parseListOptions reads limit (or page_size), cursor or page, sort and the filters from the
+// query. sort is a comma separated list of columns, descending with a leading -, e.g.
+// sort=-start_time,description.
+func parseListOptions(c *gin.Context, list *listSpec, loc *time.Location) (*models.ListOptions, error) {
+	var err error
+	options := &models.ListOptions{}
+	options.Filter, err = parseFilter(c, list.filters, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	limitName := "limit"
+	if c.Query("limit") == "" && c.Query("page_size") != "" {
+		limitName = "page_size"
+	}
+
+	options.Limit, err = parsePositiveInt(limitName, c.Query(limitName), DEFAULT_LIST_LIMIT)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Limit > MAX_LIST_LIMIT {
+		return nil, fmt.Errorf("%s: at most %d", limitName, MAX_LIST_LIMIT)
+	}
+
+	sortValue := c.Query("sort")
+	if c.Query("cursor") != "" {
+		if c.Query("page") != "" {
+			return nil, errors.New("cursor and page can not be combined")
+		}
+
+		cursor, err := parseCursor(c.Query("cursor"))
+		if err != nil {
+			return nil, err
+		}
+
+		if sortValue != "" && sortValue != cursor.Sort {
+			return nil, errors.New("cursor: the list was read with another sort")
+		}
+
+		sortValue = cursor.Sort
+		options.After = cursor.After
+	}
+
+	if sortValue == "" {
+		sortValue = list.defaultSort
+	}
+
+	options.Sort, err = parseSort(sortValue, list.sorts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(options.After) > 0 && len(options.After) != len(options.Sort)+1 {
+		return nil, errors.New("cursor: invalid cursor")
+	}
+
+	page, err := parsePositiveInt("page", c.Query("page"), 1)
+	if err != nil {
+		return nil, err
+	}
+	options.Offset = (page - 1) * options.Limit
+
+	return options, nil
+}
+
+// parseSort parses a sort order of the columns in sortable
+func parseSort(value string, sortable []string) ([]models.SortField, error) {
+	fields := make([]models.SortField, 0)
+	for _, s := range strings.Split(value, ",") {
+		field := models.SortField{Column: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+		if !containsString(sortable, field.Column) {
+			return nil, fmt.Errorf("sort: expected one of %s", strings.Join(sortable, ", "))
+		}
+
+		for _, f := range fields {
+			if f.Column == field.Column {
+				return nil, fmt.Errorf("sort: %s is given twice", field.Column)
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func formatSort(fields []models.SortField) string {
+	sort := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Desc {
+			sort = append(sort, "-"+f.Column)
+		} else {
+			sort = append(sort, f.Column)
+		}
+	}
+
+	return strings.Join(sort, ",")
+}
+
+// parseCursor decodes a next_cursor, numbers are kept as text so they compare exactly
+func parseCursor(value string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("cursor: invalid cursor")
+	}
+
+	cursor := &listCursor{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(cursor); err != nil || len(cursor.After) == 0 {
+		return nil, errors.New("cursor: invalid cursor")
+	}
+
+	for i, v := range cursor.After {
+		switch value := v.(type) {
+		case json.Number:
+			cursor.After[i] = value.String()
+		case string, bool:
+		default:
+			return nil, errors.New("cursor: invalid cursor")
+		}
+	}
+
+	return cursor, nil
+}
+
+// sortByIDs returns items, a slice of pointers to structs with an ID field, in the order of ids.
+// Items removed since their ids were read are left out.
+func sortByIDs(items interface{}, ids []string) interface{} {
+	v := reflect.ValueOf(items)
+	byID := make(map[string]reflect.Value, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		byID[v.Index(i).Elem().FieldByName("ID").String()] = v.Index(i)
+	}
+
+	sorted := reflect.MakeSlice(v.Type(), 0, len(ids))
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			sorted = reflect.Append(sorted, item)
+		}
+	}
+
+	return sorted.Interface()
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// notificationList defines how notifications can be sorted and filtered
+var notificationList = &listSpec{
+	sorts:       []string{"created_at", "is_read"},
+	defaultSort: "-created_at",
+	filters: filterFields{
+		"kind":       filterID,
+		"is_read":    filterBool,
+		"project_id": filterID,
+		"created_at": filterTime,
+	},
+}
+
+// GetAllNotifications returns the caller's notifications, newest first. They can be filtered,
+// e.g. is_read=false limits them to unread ones.
+func GetAllNotifications(c *gin.Context, h *Handler, origin *models.User) {
+	searchParams := make(map[string]interface{})
+	searchParams["user_id"] = origin.ID
+
+	options, page, status, err := getListPage(c, h, origin, models.Notification{}, searchParams, notificationList)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	notifications, err := h.DB.GetNotificationsWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondList(c, options, page, notifications)
+}
+
+// UpdateNotification marks one of the caller's notifications read or unread
+func UpdateNotification(c *gin.Context, h *Handler, origin *models.User) {
+	notificationID := c.Param("notification_id")
+
+	req := &models.UpdateNotificationRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	notifications, err := h.DB.GetNotificationsWithFilters(c.Request.Context(), map[string]interface{}{
+		"_id": notificationID, "user_id": origin.ID})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(notifications) == 0 {
+		RespondError(c, http.StatusNotFound, dbhandler.NotFound("notification with given id not found"))
+		return
+	}
+
+	status, err := checkIfMatch(c, notifications[0].Version)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	updates := map[string]interface{}{"is_read": *req.IsRead}
+	expectVersion(updates, notifications[0].Version)
+
+	notification, err := h.DB.UpdateNotification(c.Request.Context(), notificationID, updates)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+	} else {
+		setETag(c, notification.Version)
+		c.JSON(http.StatusOK, notification)
+	}
+}
@@ -3,100 +3,158 @@ package handler
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
-func AddProject(c *gin.Context, h *Handler, origin *models.User) {
-	project := &models.Project{}
-
-	project.Name = c.Query("name")
-	project.ColorTag = c.Query("color_tag")
-	var err error
+// projectList defines how projects can be sorted and filtered
+var projectList = &listSpec{
+	sorts:       []string{"name", "is_public", "billable_rate", "estimate_hours", "budget_amount"},
+	defaultSort: "name",
+	filters: filterFields{
+		"name":           filterText,
+		"client_id":      filterID,
+		"is_public":      filterBool,
+		"billable_rate":  filterNumber,
+		"estimate_hours": filterNumber,
+		"budget_amount":  filterNumber,
+		"budget_period":  filterID,
+	},
+}
 
-	project.IsPublic, err = strconv.ParseBool(c.Query("is_public"))
+func AddProject(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.AddProjectRequest{}
+	err := bindRequest(c, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	project.TrackedHours, err = strconv.ParseFloat(c.Query("tracked_hours"), 64)
+	status, err := checkRequestReferences(c, h, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	project.TrackedAmount, err = strconv.ParseFloat(c.Query("tracked_amount"), 64)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+	project := &models.Project{}
+	project.Name = req.Name
+	project.ColorTag = req.ColorTag
+	project.IsPublic = req.IsPublic
+	project.EstimateHours = req.EstimateHours
+	project.BudgetAmount = req.BudgetAmount
+	project.BillableRate = req.BillableRate
+
+	project.BudgetPeriod = models.BUDGET_PERIOD_TOTAL
+	if req.BudgetPeriod != "" {
+		project.BudgetPeriod = req.BudgetPeriod
 	}
 
-	project.ProgressPercentage, err = strconv.ParseFloat(c.Query("progress_percentage"), 32)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+	project.BudgetAlerts = DEFAULT_BUDGET_ALERTS
+	if req.BudgetAlerts != nil {
+		project.BudgetAlerts = *req.BudgetAlerts
 	}
 
-	project.Client = c.Query("client_id")
+	project.Client = req.Client
+	if len(req.TeamMembers) > 0 {
+		project.TeamMembers = req.TeamMembers
+	}
+	if len(req.TeamGroups) > 0 {
+		project.TeamGroups = req.TeamGroups
+	}
 
-	project.Workspace = c.Query("workspace_id")
+	project.Workspace = c.Param("workspace_id")
 
-	project, _, err = h.DB.AddProject(project)
+	project, _, err = h.DB.AddProject(c.Request.Context(), project)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Project with _id = %s added!", project.ID)})
 	}
 }
 
 func GetAllProjects(c *gin.Context, h *Handler, origin *models.User) {
-	projects, err := h.DB.GetAllProjects()
+	options, page, status, err := getListPage(c, h, origin, models.Project{}, workspaceFilter(c, ""), projectList)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, projects)
+	projects, err := h.DB.GetProjectsWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondList(c, options, page, projects)
 }
 
 func GetProject(c *gin.Context, h *Handler, origin *models.User) {
 	projectID := c.Param("project_id")
-	project, err := h.DB.GetProject(projectID)
+	project, status, err := getWorkspaceProject(c, h, projectID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
+		setETag(c, project.Version)
 		c.JSON(http.StatusOK, project)
 	}
 }
 
 func UpdateProject(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	projectID := c.Param("project_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
+	project, status, err := getWorkspaceProject(c, h, projectID)
+	if err == nil {
+		status, err = checkIfMatch(c, project.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	req := &models.UpdateProjectRequest{}
+	updates, err := bindPatch(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status, err = checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
 	}
 
-	_, err := h.DB.UpdateProject(projectID, updates)
+	applyRelationPatches(updates, map[string][]string{
+		"team_members": project.TeamMembers,
+		"team_groups":  project.TeamGroups,
+	})
+	expectVersion(updates, project.Version)
+
+	project, err = h.DB.UpdateProject(c.Request.Context(), projectID, updates)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Project with _id = %s updated!", projectID)})
+		setETag(c, project.Version)
+		c.JSON(http.StatusOK, project)
 	}
 }
 
 func DeleteProject(c *gin.Context, h *Handler, origin *models.User) {
 	projectID := c.Param("project_id")
-	err := h.DB.DeleteProject(projectID)
+	project, status, err := getWorkspaceProject(c, h, projectID)
+	if err == nil {
+		status, err = checkIfMatch(c, project.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.DeleteProject(c.Request.Context(), projectID, project.Version)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Project with _id = %s deleted!", projectID)})
 	}
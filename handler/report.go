@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/export"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+const (
+	// MAX_REPORT_GROUPS is how many levels a summary report can be grouped by
+	MAX_REPORT_GROUPS = 2
+	// DEFAULT_REPORT_PAGE_SIZE and MAX_REPORT_PAGE_SIZE limit the entries of a detailed report page
+	DEFAULT_REPORT_PAGE_SIZE = 50
+	MAX_REPORT_PAGE_SIZE     = 500
+	// EXPORT_TIME_LAYOUT is how exported reports show times, in the caller's time zone
+	EXPORT_TIME_LAYOUT = "2006-01-02 15:04:05"
+)
+
+// detailedReportHeader are the columns of an exported detailed report
+var detailedReportHeader = []string{"Description", "Project", "Client", "Tags", "User", "Start", "End", "Duration",
+	"Hours", "Billable", "Amount"}
+
+// GetSummaryReport returns the hours and amounts of the finished time entries of the workspace
+// matching the filter, grouped by up to two of project, client, user and tag
+func GetSummaryReport(c *gin.Context, h *Handler, origin *models.User) {
+	filter, status, err := parseReportFilter(c, origin)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	groupBy, err := parseReportGroups(c.Query("group_by"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.DB.GetSummaryReport(c.Request.Context(), filter, groupBy)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	loc := userLocation(origin)
+	report.Start = report.Start.In(loc)
+	report.End = report.End.In(loc)
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetDetailedReport returns a page of the finished time entries of the workspace matching the
+// filter, oldest first. With format=csv, xlsx or pdf every matching entry is exported as a file
+// instead.
+func GetDetailedReport(c *gin.Context, h *Handler, origin *models.User) {
+	filter, status, err := parseReportFilter(c, origin)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	if format := c.Query("format"); format != "" {
+		exportDetailedReport(c, h, origin, filter, format)
+		return
+	}
+
+	page, err := parsePositiveInt("page", c.Query("page"), 1)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	pageSize, err := parsePositiveInt("page_size", c.Query("page_size"), DEFAULT_REPORT_PAGE_SIZE)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if pageSize > MAX_REPORT_PAGE_SIZE {
+		RespondError(c, http.StatusBadRequest, fmt.Errorf("page_size: at most %d", MAX_REPORT_PAGE_SIZE))
+		return
+	}
+
+	report, err := h.DB.GetDetailedReport(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	loc := userLocation(origin)
+	report.Start = report.Start.In(loc)
+	report.End = report.End.In(loc)
+	for _, e := range report.Entries {
+		e.StartTime = e.StartTime.In(loc)
+		e.EndTime = e.EndTime.In(loc)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// exportDetailedReport streams every entry of the detailed report as a file of format. The
+// entries come from a single query and the total is summed from them, so the file is consistent
+// even when entries change while it is written.
+func exportDetailedReport(c *gin.Context, h *Handler, origin *models.User, filter *models.ReportFilter, format string) {
+	switch format {
+	case export.FORMAT_CSV, export.FORMAT_XLSX, export.FORMAT_PDF:
+	default:
+		RespondError(c, http.StatusBadRequest, fmt.Errorf("format: expected %s, %s or %s", export.FORMAT_CSV,
+			export.FORMAT_XLSX, export.FORMAT_PDF))
+		return
+	}
+
+	loc := userLocation(origin)
+	start := filter.Start.In(loc).Format(DATE_LAYOUT)
+	end := filter.End.In(loc).AddDate(0, 0, -1).Format(DATE_LAYOUT)
+
+	// The file is only started with the first entry, until then a failure can still be answered
+	// with an error status
+	var w export.Writer
+	started := false
+	begin := func() error {
+		started = true
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="detailed-report-%s-%s.%s"`, start, end,
+			format))
+		c.Status(http.StatusOK)
+
+		var err error
+		w, err = export.NewWriter(format, c.Writer, fmt.Sprintf("Detailed report %s - %s", start, end),
+			detailedReportHeader)
+		return err
+	}
+
+	var hours, amount float64
+	written := 0
+	err := h.DB.EachDetailedEntry(c.Request.Context(), filter, func(e *models.DetailedEntry) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+
+		billable := "No"
+		if e.Billable {
+			billable = "Yes"
+		}
+
+		err := w.WriteRow(e.Description, e.ProjectName, e.ClientName, strings.Join(e.Tags, ", "), e.UserName,
+			e.StartTime.In(loc).Format(EXPORT_TIME_LAYOUT), e.EndTime.In(loc).Format(EXPORT_TIME_LAYOUT),
+			formatDuration(e.EndTime.Sub(e.StartTime)), e.Hours, billable, e.Amount)
+		if err != nil {
+			return err
+		}
+
+		hours += e.Hours
+		amount += e.Amount
+		written++
+		if written%MAX_REPORT_PAGE_SIZE == 0 {
+			c.Writer.Flush()
+		}
+
+		return nil
+	})
+	if err == nil && !started {
+		err = begin()
+	}
+
+	if err != nil {
+		if !started {
+			RespondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		// The status is sent already, the file can only be cut short
+		_ = c.Error(err)
+		return
+	}
+
+	err = w.WriteRow("Total", "", "", "", "", "", "", "", hours, "", amount)
+	if err == nil {
+		err = w.Close()
+	}
+
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// formatDuration formats d as hours, minutes and seconds such as 1:05:00
+func formatDuration(d time.Duration) string {
+	seconds := int64(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// parsePositiveInt parses a number of at least 1, def is used when value is empty
+func parsePositiveInt(name, value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s: expected a number of at least 1", name)
+	}
+
+	return n, nil
+}
+
+// parseReportFilter reads the filter of a report from the query. start and end are days in the
+// caller's time zone and both are included.
+func parseReportFilter(c *gin.Context, origin *models.User) (*models.ReportFilter, int, error) {
+	filter := &models.ReportFilter{}
+	filter.Workspace = c.Param("workspace_id")
+
+	var err error
+	loc := userLocation(origin)
+	if c.Query("start") == "" || c.Query("end") == "" {
+		return nil, http.StatusBadRequest, errors.New("start and end are required")
+	}
+
+	filter.Start, err = parseDate("start", c.Query("start"), loc)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	end, err := parseDate("end", c.Query("end"), loc)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	filter.End = end.AddDate(0, 0, 1)
+
+	if !filter.End.After(filter.Start) {
+		return nil, http.StatusBadRequest, errors.New("end must not be before start")
+	}
+
+	// Time entries are filtered like the task list, the client and whether an entry is invoiced
+	// are not columns of it
+	filter.Filter, err = parseFilter(c, taskList.filters, loc)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	filter.Client = c.Query("client_id")
+
+	if c.Query("invoiced") != "" {
+		invoiced, err := strconv.ParseBool(c.Query("invoiced"))
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invoiced: %v", err)
+		}
+		filter.Invoiced = &invoiced
+	}
+
+	filter.User, err = getVisibleUser(c, origin)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	return filter, http.StatusOK, nil
+}
+
+// parseReportGroups parses comma separated report groupings such as client,project
+func parseReportGroups(value string) ([]string, error) {
+	groupBy := make([]string, 0)
+	if value == "" {
+		return groupBy, nil
+	}
+
+	for _, g := range strings.Split(value, ",") {
+		switch g {
+		case models.REPORT_GROUP_PROJECT, models.REPORT_GROUP_CLIENT, models.REPORT_GROUP_USER, models.REPORT_GROUP_TAG:
+		default:
+			return nil, fmt.Errorf("group_by: unknown grouping %q", g)
+		}
+
+		for _, other := range groupBy {
+			if other == g {
+				return nil, fmt.Errorf("group_by: %s is given twice", g)
+			}
+		}
+
+		groupBy = append(groupBy, g)
+	}
+
+	if len(groupBy) > MAX_REPORT_GROUPS {
+		return nil, fmt.Errorf("group_by: at most %d groupings are supported", MAX_REPORT_GROUPS)
+	}
+
+	return groupBy, nil
+}
+
+// getVisibleUser returns whose time entries of the workspace the caller asks for with the
+// user_id parameter. Everyone can see their own, with report:view_all an empty result means
+// every team member.
+func getVisibleUser(c *gin.Context, origin *models.User) (string, error) {
+	userID := c.Query("user_id")
+	if rbac.HasPermission(workspaceMember(c).TeamRole, rbac.REPORT_VIEW_ALL) {
+		return userID, nil
+	}
+
+	if userID == "" || userID == origin.ID {
+		return origin.ID, nil
+	}
+
+	return "", fmt.Errorf("Missing permission %s", rbac.REPORT_VIEW_ALL)
+}
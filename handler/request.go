@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/password"
+)
+
+// MAX_REQUEST_SIZE is the largest JSON body a write route reads
+const MAX_REQUEST_SIZE = 1 << 20
+
+// fieldError is what is wrong with one field of a request body
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// requestError is a request body with invalid fields
+type requestError struct {
+	Fields []fieldError
+}
+
+func (e *requestError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Field+" "+f.Message)
+	}
+
+	return "invalid request: " + strings.Join(messages, ", ")
+}
+
+// newFieldError returns a requestError for a single field
+func newFieldError(field, format string, a ...interface{}) *requestError {
+	return &requestError{Fields: []fieldError{{Field: field, Message: fmt.Sprintf(format, a...)}}}
+}
+
+// requestValidations are the validation tags of request bodies besides those of the validator
+// package, messages describe a value that does not pass them. Empty values pass, they are refused
+// with required or min=1 where a value is needed.
+var requestValidations = map[string]struct {
+	valid   func(value string) bool
+	message string
+}{
+	"timezone": {func(v string) bool { return validateTimezone(v) == nil },
+		"must be a time zone such as Europe/Berlin"},
+	"password": {func(v string) bool { return password.Validate(v) == nil }, ""},
+	"timestamp": {func(v string) bool { _, err := time.Parse(time.RFC3339, v); return err == nil },
+		"must be an RFC 3339 timestamp such as 2006-01-02T15:04:05+01:00"},
+	"day": {func(v string) bool { _, err := parseDate("", v, time.UTC); return err == nil },
+		"must be a date such as 2006-01-02 or an RFC 3339 timestamp"},
+	"week": {func(v string) bool { _, err := parseWeek(v, time.UTC); return err == nil },
+		"must be an ISO week such as 2026-W42"},
+	"budget_alerts": {func(v string) bool { _, err := parseBudgetAlerts(v); return err == nil },
+		"must be a comma separated list of percentages between 1 and 1000"},
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// Fields are reported by the names they have in the body
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	for tag, validation := range requestValidations {
+		valid := validation.valid
+		v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			value := fl.Field().String()
+			return value == "" || valid(value)
+		})
+	}
+}
+
+// bindRequest reads the JSON body into req, a pointer to a request struct, and validates it by
+// the binding tags of its fields. Fields req does not have are refused, an empty body is read as
+// an empty object.
+func bindRequest(c *gin.Context, req interface{}) error {
+	data, err := readRequestBody(c)
+	if err != nil {
+		return err
+	}
+
+	return decodeRequest(data, req)
+}
+
+// readRequestBody reads the body of the request up to MAX_REQUEST_SIZE bytes
+func readRequestBody(c *gin.Context) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, MAX_REQUEST_SIZE+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request format: %v", err)
+	}
+
+	if len(data) > MAX_REQUEST_SIZE {
+		return nil, fmt.Errorf("the request body can not be larger than %d bytes", MAX_REQUEST_SIZE)
+	}
+
+	return data, nil
+}
+
+// decodeRequest is bindRequest for a body that was read already
+func decodeRequest(data []byte, req interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(req)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+	case errors.As(err, &typeErr):
+		return newFieldError(typeErr.Field, "must be %s", jsonTypeName(typeErr.Type))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return newFieldError(field, "is not a field of this request")
+	default:
+		return fmt.Errorf("invalid request format: %v", err)
+	}
+
+	err = binding.Validator.ValidateStruct(req)
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		reqErr := &requestError{}
+		for _, fe := range validationErrs {
+			reqErr.Fields = append(reqErr.Fields, fieldError{Field: fieldName(fe), Message: fieldMessage(fe)})
+		}
+		return reqErr
+	}
+
+	return err
+}
+
+// bindPatch reads a JSON merge patch (RFC 7386) into req, a pointer to a request struct whose
+// fields are all pointers, and returns the changes it makes by field name. The fields of req are
+// the only ones that can be changed and fields left out stay as they are. A field set to null is
+// cleared when it is tagged patch:"nullable", to no reference for an id and to its empty value
+// otherwise. A models.RelationPatch set to null unlinks every id.
+func bindPatch(c *gin.Context, req interface{}) (map[string]interface{}, error) {
+	data, err := readRequestBody(c)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &members); err != nil {
+			return nil, errors.New("invalid request format: expected a JSON object of the fields to change")
+		}
+	}
+
+	err = decodeRequest(data, req)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := requestUpdates(req)
+	reqErr := &requestError{}
+	t := reflect.Indirect(reflect.ValueOf(req)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if raw, ok := members[name]; !ok || string(bytes.TrimSpace(raw)) != "null" {
+			continue
+		}
+
+		switch {
+		case field.Type.Elem() == reflect.TypeOf(models.RelationPatch{}):
+			updates[name] = models.RelationPatch{Set: []string{}}
+		case field.Tag.Get("patch") == "nullable" && field.Tag.Get("ref") != "":
+			updates[name] = nil
+		case field.Tag.Get("patch") == "nullable":
+			updates[name] = reflect.Zero(field.Type.Elem()).Interface()
+		default:
+			reqErr.Fields = append(reqErr.Fields, fieldError{Field: name, Message: "can not be null"})
+		}
+	}
+
+	if len(reqErr.Fields) > 0 {
+		return nil, reqErr
+	}
+
+	if len(updates) == 0 {
+		return nil, errors.New("no fields to change given")
+	}
+
+	return updates, nil
+}
+
+// requestUpdates returns the pointer fields of req that are set, by their json names
+func requestUpdates(req interface{}) map[string]interface{} {
+	updates := make(map[string]interface{})
+	v := reflect.Indirect(reflect.ValueOf(req))
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.Ptr || field.IsNil() {
+			continue
+		}
+
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		updates[name] = field.Elem().Interface()
+	}
+
+	return updates
+}
+
+// applyRelationPatches replaces the relation patches in updates by the ids they leave linked,
+// linked are the ids of each relation before the change
+func applyRelationPatches(updates map[string]interface{}, linked map[string][]string) {
+	for name, ids := range linked {
+		if patch, ok := updates[name].(models.RelationPatch); ok {
+			updates[name] = patch.Apply(ids)
+		}
+	}
+}
+
+// fieldName is the path of the field of fe in the body, e.g. rows[0].hours[2]
+func fieldName(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
+// fieldMessage describes why the field of fe is invalid
+func fieldMessage(fe validator.FieldError) string {
+	if fe.Tag() == "password" {
+		return strings.TrimPrefix(password.Validate(fmt.Sprint(fe.Value())).Error(), "password ")
+	}
+
+	if validation, ok := requestValidations[fe.Tag()]; ok {
+		return validation.message
+	}
+
+	kind := fe.Kind()
+	isText := kind == reflect.String
+	isList := kind == reflect.Slice || kind == reflect.Array
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "email":
+		return "must be an email address"
+	case "alpha":
+		return "must only contain letters"
+	case "len":
+		if isText {
+			return fmt.Sprintf("must be %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must have %s items", fe.Param())
+	case "min", "gte":
+		if isText && fe.Param() == "1" {
+			return "must not be empty"
+		} else if isText {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		} else if isList {
+			return fmt.Sprintf("must have at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if isText {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		} else if isList {
+			return fmt.Sprintf("must have at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "unique":
+		return "must not contain duplicates"
+	}
+
+	return "is invalid"
+}
+
+// jsonTypeName names the JSON type values of t are given as
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+
+	return "a number"
+}
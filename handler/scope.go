@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// Resources of another workspace are reported as not found so their existence does not leak
+
+func getWorkspaceApproval(c *gin.Context, h *Handler, approvalID string) (*models.Approval, int, error) {
+	approvals, err := h.DB.GetApprovalsWithFilters(c.Request.Context(), workspaceFilter(c, approvalID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(approvals) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("approval with given id not found")
+	}
+
+	return approvals[0], http.StatusOK, nil
+}
+
+func getWorkspaceClient(c *gin.Context, h *Handler, clientID string) (*models.Client, int, error) {
+	clients, err := h.DB.GetClientsWithFilters(c.Request.Context(), workspaceFilter(c, clientID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(clients) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("client with given id not found")
+	}
+
+	return clients[0], http.StatusOK, nil
+}
+
+func getWorkspaceInvoice(c *gin.Context, h *Handler, invoiceID string) (*models.Invoice, int, error) {
+	invoices, err := h.DB.GetInvoicesWithFilters(c.Request.Context(), workspaceFilter(c, invoiceID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(invoices) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("invoice with given id not found")
+	}
+
+	return invoices[0], http.StatusOK, nil
+}
+
+func getWorkspaceProject(c *gin.Context, h *Handler, projectID string) (*models.Project, int, error) {
+	projects, err := h.DB.GetProjectsWithFilters(c.Request.Context(), workspaceFilter(c, projectID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(projects) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("project with given id not found")
+	}
+
+	return projects[0], http.StatusOK, nil
+}
+
+func getWorkspaceTag(c *gin.Context, h *Handler, tagID string) (*models.Tag, int, error) {
+	tags, err := h.DB.GetTagsWithFilters(c.Request.Context(), workspaceFilter(c, tagID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(tags) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("tag with given id not found")
+	}
+
+	return tags[0], http.StatusOK, nil
+}
+
+func getWorkspaceTask(c *gin.Context, h *Handler, taskID string) (*models.Task, int, error) {
+	tasks, err := h.DB.GetTasksWithFilters(c.Request.Context(), workspaceFilter(c, taskID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("task with given id not found")
+	}
+
+	return tasks[0], http.StatusOK, nil
+}
+
+func getWorkspaceTeamGroup(c *gin.Context, h *Handler, teamGroupID string) (*models.TeamGroup, int, error) {
+	teamGroups, err := h.DB.GetTeamGroupsWithFilters(c.Request.Context(), workspaceFilter(c, teamGroupID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(teamGroups) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("team group with given id not found")
+	}
+
+	return teamGroups[0], http.StatusOK, nil
+}
+
+func getWorkspaceTeamMember(c *gin.Context, h *Handler, teamMemberID string) (*models.TeamMember, int, error) {
+	teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), workspaceFilter(c, teamMemberID))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(teamMembers) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("team member with given id not found")
+	}
+
+	return teamMembers[0], http.StatusOK, nil
+}
+
+// workspaceMember returns the caller's team member in the workspace of the route
+func workspaceMember(c *gin.Context) *models.TeamMember {
+	return c.MustGet(WORKSPACE_MEMBER_KEY).(*models.TeamMember)
+}
+
+func workspaceFilter(c *gin.Context, id string) map[string]interface{} {
+	searchParams := make(map[string]interface{})
+	searchParams["workspace_id"] = c.Param("workspace_id")
+	if id != "" {
+		searchParams["_id"] = id
+	}
+
+	return searchParams
+}
+
+// workspaceReferences look up the resources of the workspace of the route that the fields of a
+// request can refer to, by the name given in their ref tag
+var workspaceReferences = map[string]func(c *gin.Context, h *Handler, id string) (int, error){
+	"client": func(c *gin.Context, h *Handler, id string) (int, error) {
+		_, status, err := getWorkspaceClient(c, h, id)
+		return status, err
+	},
+	"project": func(c *gin.Context, h *Handler, id string) (int, error) {
+		_, status, err := getWorkspaceProject(c, h, id)
+		return status, err
+	},
+	"tag": func(c *gin.Context, h *Handler, id string) (int, error) {
+		_, status, err := getWorkspaceTag(c, h, id)
+		return status, err
+	},
+	"team_member": func(c *gin.Context, h *Handler, id string) (int, error) {
+		_, status, err := getWorkspaceTeamMember(c, h, id)
+		return status, err
+	},
+	"team_group": func(c *gin.Context, h *Handler, id string) (int, error) {
+		_, status, err := getWorkspaceTeamGroup(c, h, id)
+		return status, err
+	},
+}
+
+// checkRequestReferences makes sure every id in the fields of req tagged ref:"client",
+// ref:"project" and so on belongs to the workspace of the route. Fields hold an id or a list of
+// ids, or a models.RelationPatch whose linked ids are checked. Nested requests are checked as well.
+func checkRequestReferences(c *gin.Context, h *Handler, req interface{}) (int, error) {
+	reqErr := &requestError{}
+	status, err := checkReferences(c, h, reflect.ValueOf(req), "", reqErr)
+	if err != nil {
+		return status, err
+	}
+
+	if len(reqErr.Fields) > 0 {
+		return http.StatusBadRequest, reqErr
+	}
+
+	return http.StatusOK, nil
+}
+
+func checkReferences(c *gin.Context, h *Handler, v reflect.Value, path string, reqErr *requestError) (int, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return http.StatusOK, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			status, err := checkReferences(c, h, v.Index(i), fmt.Sprintf("%s[%d]", path, i), reqErr)
+			if err != nil {
+				return status, err
+			}
+		}
+		return http.StatusOK, nil
+	}
+
+	if v.Kind() != reflect.Struct {
+		return http.StatusOK, nil
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if path != "" {
+			name = path + "." + name
+		}
+
+		lookup, ok := workspaceReferences[field.Tag.Get("ref")]
+		if !ok {
+			status, err := checkReferences(c, h, v.Field(i), name, reqErr)
+			if err != nil {
+				return status, err
+			}
+			continue
+		}
+
+		ids := make([]string, 0)
+		switch value := reflect.Indirect(v.Field(i)); value.Kind() {
+		case reflect.String:
+			ids = append(ids, value.String())
+		case reflect.Slice:
+			ids = append(ids, value.Interface().([]string)...)
+		case reflect.Struct:
+			if patch, ok := value.Interface().(models.RelationPatch); ok {
+				ids = append(ids, patch.Linked()...)
+			}
+		}
+
+		for _, id := range ids {
+			if id == "" {
+				continue
+			}
+
+			status, err := lookup(c, h, id)
+			if status == http.StatusNotFound {
+				reqErr.Fields = append(reqErr.Fields, fieldError{Field: name,
+					Message: fmt.Sprintf("%s is not part of this workspace", id)})
+			} else if err != nil {
+				return status, err
+			}
+		}
+	}
+
+	return http.StatusOK, nil
+}
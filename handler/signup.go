@@ -1,9 +1,8 @@
 package handler
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/models"
@@ -11,40 +10,40 @@ import (
 
 func SignUpUser(h *Handler) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user := &models.User{}
-
-		err := c.ShouldBindJSON(user)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Errorf("invalid request format: %v", err).Error()})
-			return
+		req := &models.SignUpRequest{}
+		err := bindRequest(c, req)
+		if err == nil {
+			err = checkSignUp(req)
 		}
 
-		user, err = validateUser(user)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			RespondError(c, http.StatusBadRequest, err)
 			return
 		}
 
-		addedUser, status, err := h.DB.AddUser(user)
+		user := &models.User{}
+		user.Name = req.Name
+		user.Email = req.Email
+		user.Username = req.Username
+		user.Password = req.Password
+		user.Timezone = req.Timezone
+
+		addedUser, status, err := h.DB.AddUser(c.Request.Context(), user)
 		if err != nil {
-			c.JSON(status, gin.H{"error": err.Error()})
+			RespondError(c, status, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, addedUser)
+		c.JSON(http.StatusOK, addedUser.Public())
 	}
 }
 
-func validateUser(user *models.User) (*models.User, error) {
-	if user.Name == "" {
-		return nil, errors.New("name is missing")
-	} else if user.Email == "" {
-		return nil, errors.New("email is missing")
-	} else if user.Username == "" {
-		return nil, errors.New("username is missing")
-	} else if user.Password == "" {
-		return nil, errors.New("password is missing")
+// checkSignUp checks what the binding tags of req can not, that the password differs from the
+// username and email
+func checkSignUp(req *models.SignUpRequest) error {
+	if strings.EqualFold(req.Password, req.Username) || strings.EqualFold(req.Password, req.Email) {
+		return newFieldError("password", "must not match the username or email")
 	}
 
-	return user, nil
+	return nil
 }
@@ -8,63 +8,104 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
+// tagList defines how tags can be sorted and filtered
+var tagList = &listSpec{
+	sorts:       []string{"name"},
+	defaultSort: "name",
+	filters:     filterFields{"name": filterText},
+}
+
 func AddTag(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.AddTagRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
 	tag := &models.Tag{}
-	tag.Name = c.Query("name")
+	tag.Name = req.Name
+	tag.Workspace = c.Param("workspace_id")
 
-	tag, _, err := h.DB.AddTag(tag)
+	tag, _, err = h.DB.AddTag(c.Request.Context(), tag)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Tag with _id = %s added!", tag.ID)})
 	}
 }
 
 func GetAllTags(c *gin.Context, h *Handler, origin *models.User) {
-	tags, err := h.DB.GetAllTags()
+	options, page, status, err := getListPage(c, h, origin, models.Tag{}, workspaceFilter(c, ""), tagList)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, tags)
+	tags, err := h.DB.GetTagsWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondList(c, options, page, tags)
 }
 
 func GetTag(c *gin.Context, h *Handler, origin *models.User) {
 	tagID := c.Param("tag_id")
-	tag, err := h.DB.GetTag(tagID)
+	tag, status, err := getWorkspaceTag(c, h, tagID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
+		setETag(c, tag.Version)
 		c.JSON(http.StatusOK, tag)
 	}
 }
 
 func UpdateTag(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	tagID := c.Param("tag_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
+	tag, status, err := getWorkspaceTag(c, h, tagID)
+	if err == nil {
+		status, err = checkIfMatch(c, tag.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
 	}
 
-	_, err := h.DB.UpdateTag(tagID, updates)
+	updates, err := bindPatch(c, &models.UpdateTagRequest{})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	expectVersion(updates, tag.Version)
+
+	tag, err = h.DB.UpdateTag(c.Request.Context(), tagID, updates)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Tag with _id = %s updated!", tagID)})
+		setETag(c, tag.Version)
+		c.JSON(http.StatusOK, tag)
 	}
 }
 
 func DeleteTag(c *gin.Context, h *Handler, origin *models.User) {
 	tagID := c.Param("tag_id")
-	err := h.DB.DeleteTag(tagID)
+	tag, status, err := getWorkspaceTag(c, h, tagID)
+	if err == nil {
+		status, err = checkIfMatch(c, tag.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.DeleteTag(c.Request.Context(), tagID, tag.Version)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Tag with _id = %s deleted!", tagID)})
 	}
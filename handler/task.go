@@ -3,112 +3,380 @@ package handler
 import (
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/qasim-sajid/clockify-api/conf"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
 	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
 )
 
-func AddTask(c *gin.Context, h *Handler, origin *models.User) {
-	task := &models.Task{}
-
-	task.Description = c.Query("description")
-	var err error
+// taskList defines how time entries can be sorted and filtered
+var taskList = &listSpec{
+	sorts:       []string{"start_time", "date", "billable"},
+	defaultSort: "-start_time",
+	filters: filterFields{
+		"description":     filterText,
+		"billable":        filterBool,
+		"start_time":      filterTime,
+		"end_time":        filterTime,
+		"date":            filterTime,
+		"is_active":       filterBool,
+		"is_locked":       filterBool,
+		"project_id":      filterID,
+		"user_id":         filterID,
+		"invoice_item_id": filterID,
+		"tags":            filterRelation,
+	},
+}
 
-	task.Billable, err = strconv.ParseBool(c.Query("billable"))
+func AddTask(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.AddTaskRequest{}
+	err := bindRequest(c, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	task.StartTime, err = time.Parse(conf.TIME_LAYOUT, c.Query("start_time"))
+	task := &models.Task{}
+	task.Description = req.Description
+	task.Billable = req.Billable
+	task.IsActive = req.IsActive
+
+	task.StartTime, err = parseTimestamp("start_time", req.StartTime)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	task.EndTime, err = time.Parse(conf.TIME_LAYOUT, c.Query("end_time"))
+	if req.EndTime != "" {
+		endTime, err := parseTimestamp("end_time", req.EndTime)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+		task.EndTime = &endTime
+	}
+
+	loc, err := getUserLocation(c, h.DB, origin.ID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	task.Date, err = time.Parse(conf.TIME_LAYOUT, c.Query("date"))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	task.Date = startOfDay(task.StartTime, loc)
+	if req.Date != "" {
+		task.Date, err = parseDate("date", req.Date, loc)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if task.EndTime != nil && task.EndTime.Before(task.StartTime) {
+		RespondError(c, http.StatusBadRequest, newFieldError("end_time", "must not be before start_time"))
 		return
 	}
 
-	task.IsActive, err = strconv.ParseBool(c.Query("is_active"))
+	task.Project = req.Project
+
+	task.User = origin.ID
+	task.Workspace = c.Param("workspace_id")
+
+	if len(req.Tags) > 0 {
+		task.Tags = req.Tags
+	}
+
+	status, err := checkRequestReferences(c, h, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	task.Project = c.Query("project_id")
+	// A running entry stops the caller's running entry first, the same way starting a timer does
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		status, err = checkPeriodOpen(c, tx, task.Workspace, task.User, task.StartTime)
+		if err != nil {
+			return err
+		}
 
-	tags := strings.Split(c.Query("tags"), ",")
-	if len(tags) > 0 && tags[0] != "" {
-		task.Tags = tags
-	}
+		if task.IsActive {
+			status, err = stopRunningTasks(c, tx, origin.ID, time.Now().UTC().Truncate(time.Second))
+			if err != nil {
+				return err
+			}
+		}
 
-	task, _, err = h.DB.AddTask(task)
+		task, _, err = tx.AddTask(c.Request.Context(), task)
+		if err != nil {
+			return err
+		}
+
+		return notifyProjectBudget(c, tx, task.Project)
+	})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Task with _id = %s added!", task.ID)})
 	}
 }
 
+// GetAllTasks returns the caller's tasks in the workspace. With report:view_all the tasks of
+// every team member are returned, or those of the user given by the user_id parameter.
 func GetAllTasks(c *gin.Context, h *Handler, origin *models.User) {
-	tasks, err := h.DB.GetAllTasks()
+	searchParams := workspaceFilter(c, "")
+
+	userID, err := getVisibleUser(c, origin)
+	if err != nil {
+		RespondError(c, http.StatusForbidden, err)
+		return
+	}
+
+	if userID != "" {
+		searchParams["user_id"] = userID
+	}
+
+	options, page, status, err := getListPage(c, h, origin, models.Task{}, searchParams, taskList)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	tasks, err := h.DB.GetTasksWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	localizeTasks(origin, tasks...)
+	respondList(c, options, page, tasks)
 }
 
 func GetTask(c *gin.Context, h *Handler, origin *models.User) {
 	taskID := c.Param("task_id")
-	task, err := h.DB.GetTask(taskID)
+	task, status, err := getWorkspaceTask(c, h, taskID)
+	if err == nil {
+		status, err = checkTaskOwner(c, origin, task, rbac.REPORT_VIEW_ALL)
+	}
+
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
+		localizeTasks(origin, task)
+		setETag(c, task.Version)
 		c.JSON(http.StatusOK, task)
 	}
 }
 
 func UpdateTask(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	taskID := c.Param("task_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
+	task, status, err := getWorkspaceTask(c, h, taskID)
+	if err == nil {
+		status, err = checkTaskOwner(c, origin, task, rbac.TIME_EDIT_OTHERS)
+	}
+
+	if err == nil {
+		status, err = checkTaskChangeable(task)
+	}
+
+	if err == nil {
+		status, err = checkIfMatch(c, task.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	req := &models.UpdateTaskRequest{}
+	updates, err := bindPatch(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Restarting an entry makes it the running one of its user, the previous one is stopped
+	starting := false
+	if active, _ := updates["is_active"].(bool); active && !task.IsActive {
+		if v, ok := updates["end_time"]; ok && v != nil && fmt.Sprint(v) != "" {
+			RespondError(c, http.StatusBadRequest, newFieldError("end_time", "must be empty for a running entry"))
 			return
-		} else {
-			updates[k] = v[0]
 		}
+
+		starting = true
+		updates["end_time"] = ""
 	}
 
-	_, err := h.DB.UpdateTask(taskID, updates)
+	status, err = parseTaskTimeUpdates(c, h, task, updates)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
+		return
+	}
+
+	status, err = checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	startTime := task.StartTime
+	if v, ok := updates["start_time"].(time.Time); ok {
+		startTime = v
+	}
+
+	applyRelationPatches(updates, map[string][]string{"tags": task.Tags})
+	expectVersion(updates, task.Version)
+
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		status, err = checkTaskOpen(c, tx, task, startTime)
+		if err != nil {
+			return err
+		}
+
+		if starting {
+			status, err = stopRunningTasks(c, tx, task.User, time.Now().UTC().Truncate(time.Second))
+			if err != nil {
+				return err
+			}
+		}
+
+		task, err = tx.UpdateTask(c.Request.Context(), taskID, updates)
+		if err != nil {
+			return err
+		}
+
+		return notifyProjectBudget(c, tx, task.Project)
+	})
+	if err != nil {
+		RespondError(c, status, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Task with _id = %s updated!", taskID)})
+		localizeTasks(origin, task)
+		setETag(c, task.Version)
+		c.JSON(http.StatusOK, task)
 	}
 }
 
 func DeleteTask(c *gin.Context, h *Handler, origin *models.User) {
 	taskID := c.Param("task_id")
-	err := h.DB.DeleteTask(taskID)
+	task, status, err := getWorkspaceTask(c, h, taskID)
+	if err == nil {
+		status, err = checkTaskOwner(c, origin, task, rbac.TIME_EDIT_OTHERS)
+	}
+
+	if err == nil {
+		status, err = checkTaskChangeable(task)
+	}
+
+	if err == nil {
+		status, err = checkIfMatch(c, task.Version)
+	}
+
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		status, err = checkTaskOpen(c, tx, task, task.StartTime)
+		if err != nil {
+			return err
+		}
+
+		return tx.DeleteTask(c.Request.Context(), taskID, task.Version)
+	})
+	if err != nil {
+		RespondError(c, status, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Task with _id = %s deleted!", taskID)})
 	}
 }
+
+// checkTaskChangeable refuses changes to a time entry of an approved timesheet or billed on an
+// invoice, the approved or billed time would no longer match it
+func checkTaskChangeable(task *models.Task) (int, error) {
+	switch {
+	case task.IsLocked:
+		return http.StatusConflict, errTaskLocked
+	case task.InvoiceItem != "":
+		return http.StatusConflict, errTaskInvoiced
+	}
+
+	return http.StatusOK, nil
+}
+
+// checkTaskOpen makes sure task, starting at startTime once it is changed, can still be changed in
+// the transaction of db. The approvals of its user are locked by checkPeriodOpen and the task is
+// read again with a row lock, so it can be neither locked nor invoiced between the check and the
+// change.
+func checkTaskOpen(c *gin.Context, db dbhandler.DbHandler, task *models.Task, startTime time.Time) (int, error) {
+	status, err := checkPeriodOpen(c, db, task.Workspace, task.User, startTime)
+	if err != nil {
+		return status, err
+	}
+
+	current, err := db.GetTaskForUpdate(c.Request.Context(), task.ID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return checkTaskChangeable(current)
+}
+
+// checkTaskOwner lets the caller at their own tasks, other team members' tasks need permission
+func checkTaskOwner(c *gin.Context, origin *models.User, task *models.Task, permission rbac.Permission) (int, error) {
+	if task.User == origin.ID || rbac.HasPermission(workspaceMember(c).TeamRole, permission) {
+		return http.StatusOK, nil
+	}
+
+	return http.StatusForbidden, fmt.Errorf("Missing permission %s", permission)
+}
+
+// parseTaskTimeUpdates converts the timestamps in updates and moves the date of task along with
+// its start time. Days are computed in the time zone of the user who tracked the task, an empty
+// end_time clears it.
+func parseTaskTimeUpdates(c *gin.Context, h *Handler, task *models.Task, updates map[string]interface{}) (int, error) {
+	loc, err := getUserLocation(c, h.DB, task.User)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	startTime, endTime := task.StartTime, task.EndTime
+	if v, ok := updates["start_time"]; ok {
+		startTime, err = parseTimestamp("start_time", fmt.Sprint(v))
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		updates["start_time"] = startTime
+
+		if _, ok := updates["date"]; !ok {
+			updates["date"] = startOfDay(startTime, loc)
+		}
+	}
+
+	if v, ok := updates["end_time"]; ok {
+		if fmt.Sprint(v) == "" {
+			endTime = nil
+			updates["end_time"] = nil
+		} else {
+			t, err := parseTimestamp("end_time", fmt.Sprint(v))
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+			endTime = &t
+			updates["end_time"] = t
+		}
+	}
+
+	if v, ok := updates["date"].(string); ok {
+		updates["date"], err = parseDate("date", v, loc)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+
+	if endTime != nil && endTime.Before(startTime) {
+		return http.StatusBadRequest, newFieldError("end_time", "must not be before start_time")
+	}
+
+	return http.StatusOK, nil
+}
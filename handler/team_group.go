@@ -8,68 +8,122 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
+// teamGroupList defines how team groups can be sorted and filtered
+var teamGroupList = &listSpec{
+	sorts:       []string{"name"},
+	defaultSort: "name",
+	filters:     filterFields{"name": filterText},
+}
+
 func AddTeamGroup(c *gin.Context, h *Handler, origin *models.User) {
-	teamGroup := &models.TeamGroup{}
+	req := &models.AddTeamGroupRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
 
-	teamGroup.Name = c.Query("name")
+	status, err := checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
 
-	var err error
+	teamGroup := &models.TeamGroup{}
+	teamGroup.Name = req.Name
+	if len(req.TeamMembers) > 0 {
+		teamGroup.TeamMembers = req.TeamMembers
+	}
 
-	teamGroup.Workspace = c.Query("workspace_id")
+	teamGroup.Workspace = c.Param("workspace_id")
 
-	teamGroup, _, err = h.DB.AddTeamGroup(teamGroup)
+	teamGroup, _, err = h.DB.AddTeamGroup(c.Request.Context(), teamGroup)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamGroup with _id = %s added!", teamGroup.ID)})
 	}
 }
 
 func GetAllTeamGroups(c *gin.Context, h *Handler, origin *models.User) {
-	teamGroups, err := h.DB.GetAllTeamGroups()
+	options, page, status, err := getListPage(c, h, origin, models.TeamGroup{}, workspaceFilter(c, ""), teamGroupList)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, teamGroups)
+	teamGroups, err := h.DB.GetTeamGroupsWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondList(c, options, page, teamGroups)
 }
 
 func GetTeamGroup(c *gin.Context, h *Handler, origin *models.User) {
 	teamGroupID := c.Param("team_group_id")
-	teamGroup, err := h.DB.GetTeamGroup(teamGroupID)
+	teamGroup, status, err := getWorkspaceTeamGroup(c, h, teamGroupID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
+		setETag(c, teamGroup.Version)
 		c.JSON(http.StatusOK, teamGroup)
 	}
 }
 
 func UpdateTeamGroup(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	teamGroupID := c.Param("team_group_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
+	teamGroup, status, err := getWorkspaceTeamGroup(c, h, teamGroupID)
+	if err == nil {
+		status, err = checkIfMatch(c, teamGroup.Version)
 	}
 
-	_, err := h.DB.UpdateTeamGroup(teamGroupID, updates)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
+		return
+	}
+
+	req := &models.UpdateTeamGroupRequest{}
+	updates, err := bindPatch(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status, err = checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	applyRelationPatches(updates, map[string][]string{"team_members": teamGroup.TeamMembers})
+	expectVersion(updates, teamGroup.Version)
+
+	teamGroup, err = h.DB.UpdateTeamGroup(c.Request.Context(), teamGroupID, updates)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamGroup with _id = %s updated!", teamGroupID)})
+		setETag(c, teamGroup.Version)
+		c.JSON(http.StatusOK, teamGroup)
 	}
 }
 
 func DeleteTeamGroup(c *gin.Context, h *Handler, origin *models.User) {
 	teamGroupID := c.Param("team_group_id")
-	err := h.DB.DeleteTeamGroup(teamGroupID)
+	teamGroup, status, err := getWorkspaceTeamGroup(c, h, teamGroupID)
+	if err == nil {
+		status, err = checkIfMatch(c, teamGroup.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.DeleteTeamGroup(c.Request.Context(), teamGroupID, teamGroup.Version)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamGroup with _id = %s deleted!", teamGroupID)})
 	}
@@ -3,83 +3,187 @@ package handler
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
 )
 
-func AddTeamMember(c *gin.Context, h *Handler, origin *models.User) {
-	teamMember := &models.TeamMember{}
-
-	var err error
+// teamMemberList defines how team members can be sorted and filtered
+var teamMemberList = &listSpec{
+	sorts:       []string{"user_email", "billable_rate"},
+	defaultSort: "user_email",
+	filters: filterFields{
+		"user_email":    filterText,
+		"billable_rate": filterNumber,
+		"team_role_id":  filterID,
+	},
+}
 
-	teamMember.BillableRate, err = strconv.ParseFloat(c.Query("billable_rate"), 64)
+func AddTeamMember(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.AddTeamMemberRequest{}
+	err := bindRequest(c, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	teamMember.Workspace = c.Query("workspace_id")
+	teamMember := &models.TeamMember{}
+	teamMember.BillableRate = req.BillableRate
+
+	teamMember.Workspace = c.Param("workspace_id")
 
-	teamMember.User = c.Query("user_email")
+	teamMember.User = req.User
 
-	teamMember.TeamRole = c.Query("team_role_id")
+	teamMember.TeamRole = req.TeamRole
+	if teamMember.TeamRole == "" {
+		teamMember.TeamRole = rbac.ROLE_MEMBER
+	}
 
-	teamMember, _, err = h.DB.AddTeamMember(teamMember)
+	status, err := checkRoleChange(c, h, nil, teamMember.TeamRole)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
+		return
+	}
+
+	teamMember, _, err = h.DB.AddTeamMember(c.Request.Context(), teamMember)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamMember with _id = %s added!", teamMember.ID)})
 	}
 }
 
 func GetAllTeamMembers(c *gin.Context, h *Handler, origin *models.User) {
-	teamMembers, err := h.DB.GetAllTeamMembers()
+	options, page, status, err := getListPage(c, h, origin, models.TeamMember{}, workspaceFilter(c, ""), teamMemberList)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, teamMembers)
+	respondList(c, options, page, teamMembers)
 }
 
 func GetTeamMember(c *gin.Context, h *Handler, origin *models.User) {
 	teamMemberID := c.Param("team_member_id")
-	teamMember, err := h.DB.GetTeamMember(teamMemberID)
+	teamMember, status, err := getWorkspaceTeamMember(c, h, teamMemberID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 	} else {
+		setETag(c, teamMember.Version)
 		c.JSON(http.StatusOK, teamMember)
 	}
 }
 
 func UpdateTeamMember(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	teamMemberID := c.Param("team_member_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
+	teamMember, status, err := getWorkspaceTeamMember(c, h, teamMemberID)
+	if err == nil {
+		status, err = checkIfMatch(c, teamMember.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	req := &models.UpdateTeamMemberRequest{}
+	updates, err := bindPatch(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status, err = checkRequestReferences(c, h, req)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	if req.TeamRole != nil {
+		status, err = checkRoleChange(c, h, teamMember, *req.TeamRole)
+		if err != nil {
+			RespondError(c, status, err)
 			return
-		} else {
-			updates[k] = v[0]
 		}
 	}
 
-	_, err := h.DB.UpdateTeamMember(teamMemberID, updates)
+	applyRelationPatches(updates, map[string][]string{"team_groups": teamMember.TeamGroups})
+	expectVersion(updates, teamMember.Version)
+
+	teamMember, err = h.DB.UpdateTeamMember(c.Request.Context(), teamMemberID, updates)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamMember with _id = %s updated!", teamMemberID)})
+		setETag(c, teamMember.Version)
+		c.JSON(http.StatusOK, teamMember)
 	}
 }
 
 func DeleteTeamMember(c *gin.Context, h *Handler, origin *models.User) {
 	teamMemberID := c.Param("team_member_id")
-	err := h.DB.DeleteTeamMember(teamMemberID)
+	teamMember, status, err := getWorkspaceTeamMember(c, h, teamMemberID)
+	if err == nil {
+		status, err = checkIfMatch(c, teamMember.Version)
+	}
+
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	if teamMember.TeamRole == rbac.ROLE_OWNER {
+		status, err = checkRoleChange(c, h, teamMember, "")
+		if err != nil {
+			RespondError(c, status, err)
+			return
+		}
+	}
+
+	err = h.DB.DeleteTeamMember(c.Request.Context(), teamMemberID, teamMember.Version)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamMember with _id = %s deleted!", teamMemberID)})
 	}
 }
+
+// checkRoleChange validates giving roleID to teamMember, which is nil for a new member and an
+// empty roleID when the member is removed. Only owners can hand out or take away ownership and
+// a workspace always keeps at least one owner.
+func checkRoleChange(c *gin.Context, h *Handler, teamMember *models.TeamMember, roleID string) (int, error) {
+	if _, ok := rbac.GetRole(roleID); !ok && roleID != "" {
+		return http.StatusBadRequest, fmt.Errorf("unknown team role %s", roleID)
+	}
+
+	wasOwner := teamMember != nil && teamMember.TeamRole == rbac.ROLE_OWNER
+	if roleID != rbac.ROLE_OWNER && !wasOwner {
+		return http.StatusOK, nil
+	}
+
+	if workspaceMember(c).TeamRole != rbac.ROLE_OWNER {
+		return http.StatusForbidden, fmt.Errorf("only owners can change ownership of a workspace")
+	}
+
+	if wasOwner && roleID != rbac.ROLE_OWNER {
+		searchParams := workspaceFilter(c, "")
+		searchParams["team_role_id"] = rbac.ROLE_OWNER
+
+		owners, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), searchParams)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if len(owners) <= 1 {
+			return http.StatusBadRequest, fmt.Errorf("a workspace needs at least one owner")
+		}
+	}
+
+	return http.StatusOK, nil
+}
@@ -1,71 +1,57 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
 )
 
-func AddTeamRole(c *gin.Context, h *Handler, origin *models.User) {
-	teamRole := &models.TeamRole{}
-	teamRole.Role = c.Query("role")
+// teamRoleList defines how team roles can be sorted and filtered
+var teamRoleList = &listSpec{
+	sorts:       []string{"role"},
+	defaultSort: "role",
+	filters:     filterFields{"role": filterText},
+}
+
+// Team roles are the built-in roles of the rbac package, they are listed here but can not be changed
 
-	teamRole, _, err := h.DB.AddTeamRole(teamRole)
+func GetAllTeamRoles(c *gin.Context, h *Handler, origin *models.User) {
+	options, page, status, err := getListPage(c, h, origin, models.TeamRole{}, nil, teamRoleList)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamRole with _id = %s added!", teamRole.ID)})
+		RespondError(c, status, err)
+		return
 	}
-}
 
-func GetAllTeamRoles(c *gin.Context, h *Handler, origin *models.User) {
-	teamRoles, err := h.DB.GetAllTeamRoles()
+	teamRoles, err := h.DB.GetTeamRolesWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, teamRoles)
+	for _, tr := range teamRoles {
+		setRolePermissions(tr)
+	}
+
+	respondList(c, options, page, teamRoles)
 }
 
 func GetTeamRole(c *gin.Context, h *Handler, origin *models.User) {
 	teamRoleID := c.Param("team_role_id")
-	teamRole, err := h.DB.GetTeamRole(teamRoleID)
+	teamRole, err := h.DB.GetTeamRole(c.Request.Context(), teamRoleID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
+		setRolePermissions(teamRole)
+		setETag(c, teamRole.Version)
 		c.JSON(http.StatusOK, teamRole)
 	}
 }
 
-func UpdateTeamRole(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
-	teamRoleID := c.Param("team_role_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
-	}
-
-	_, err := h.DB.UpdateTeamRole(teamRoleID, updates)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamRole with _id = %s updated!", teamRoleID)})
-	}
-}
-
-func DeleteTeamRole(c *gin.Context, h *Handler, origin *models.User) {
-	teamRoleID := c.Param("team_role_id")
-	err := h.DB.DeleteTeamRole(teamRoleID)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("TeamRole with _id = %s deleted!", teamRoleID)})
+func setRolePermissions(teamRole *models.TeamRole) {
+	teamRole.Permissions = make([]string, 0)
+	for _, p := range rbac.Permissions(teamRole.ID) {
+		teamRole.Permissions = append(teamRole.Permissions, string(p))
 	}
 }
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// StartTimer starts a running time entry for the caller, a running entry the caller already has
+// is stopped first so there is never more than one
+func StartTimer(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.StartTimerRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	task := &models.Task{}
+	task.Description = req.Description
+	task.Billable = req.Billable
+	task.Project = req.Project
+	task.Workspace = req.Workspace
+	task.User = origin.ID
+
+	if len(req.Tags) > 0 {
+		task.Tags = req.Tags
+	}
+
+	status, err := checkTimerReferences(c, h, origin, task)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	loc, err := getUserLocation(c, h.DB, origin.ID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	task.StartTime = now
+	task.Date = startOfDay(now, loc)
+	task.IsActive = true
+
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		status, err = checkPeriodOpen(c, tx, task.Workspace, task.User, now)
+		if err != nil {
+			return err
+		}
+
+		status, err = stopRunningTasks(c, tx, origin.ID, now)
+		if err != nil {
+			return err
+		}
+
+		task, _, err = tx.AddTask(c.Request.Context(), task)
+		return err
+	})
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	localizeTasks(origin, task)
+	c.JSON(http.StatusOK, task)
+}
+
+// StopTimer stops the caller's running time entry and returns it
+func StopTimer(c *gin.Context, h *Handler, origin *models.User) {
+	var task *models.Task
+	status := http.StatusInternalServerError
+	err := h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		running, err := getRunningTask(c, tx, origin)
+		if err != nil || running == nil {
+			return err
+		}
+
+		status, err = stopRunningTasks(c, tx, origin.ID, time.Now().UTC().Truncate(time.Second))
+		if err != nil {
+			return err
+		}
+
+		task, err = tx.GetTask(c.Request.Context(), running.ID)
+		return err
+	})
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	if task == nil {
+		RespondError(c, http.StatusNotFound, errors.New("no timer is running"))
+		return
+	}
+
+	localizeTasks(origin, task)
+	c.JSON(http.StatusOK, task)
+}
+
+// GetCurrentTimer returns the caller's running time entry
+func GetCurrentTimer(c *gin.Context, h *Handler, origin *models.User) {
+	task, err := getRunningTask(c, h.DB, origin)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if task == nil {
+		RespondError(c, http.StatusNotFound, errors.New("no timer is running"))
+		return
+	}
+
+	localizeTasks(origin, task)
+	c.JSON(http.StatusOK, task)
+}
+
+func getRunningTask(c *gin.Context, db dbhandler.DbHandler, origin *models.User) (*models.Task, error) {
+	searchParams := make(map[string]interface{})
+	searchParams["user_id"] = origin.ID
+	searchParams["is_active"] = true
+
+	tasks, err := db.GetTasksWithFilters(c.Request.Context(), searchParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	return tasks[0], nil
+}
+
+// stopRunningTasks ends the running time entries of userID at now, it refuses to change an entry
+// of an approved timesheet or on an invoice
+func stopRunningTasks(c *gin.Context, db dbhandler.DbHandler, userID string, now time.Time) (int, error) {
+	searchParams := make(map[string]interface{})
+	searchParams["user_id"] = userID
+	searchParams["is_active"] = true
+
+	tasks, err := db.GetTasksWithFilters(c.Request.Context(), searchParams)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	for _, t := range tasks {
+		status, err := checkTaskChangeable(t)
+		if err != nil {
+			return status, err
+		}
+
+		updates := make(map[string]interface{})
+		updates["end_time"] = now
+		updates["is_active"] = false
+
+		_, err = db.UpdateTask(c.Request.Context(), t.ID, updates)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		err = notifyProjectBudget(c, db, t.Project)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// checkTimerReferences fills in the workspace of the entry from its project and makes sure the
+// caller is a team member of that workspace and the tags belong to it. Entries without a
+// workspace can not have tags, as tags are scoped to a workspace.
+func checkTimerReferences(c *gin.Context, h *Handler, origin *models.User, task *models.Task) (int, error) {
+	if task.Project != "" {
+		projects, err := h.DB.GetProjectsWithFilters(c.Request.Context(), map[string]interface{}{"_id": task.Project})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if len(projects) == 0 {
+			return http.StatusBadRequest, errors.New("project with given id not found")
+		}
+
+		if task.Workspace != "" && task.Workspace != projects[0].Workspace {
+			return http.StatusBadRequest, errors.New("project_id: project is not part of this workspace")
+		}
+		task.Workspace = projects[0].Workspace
+	}
+
+	if task.Workspace == "" {
+		if len(task.Tags) > 0 {
+			return http.StatusBadRequest, errors.New("tags need a project_id or workspace_id")
+		}
+
+		return http.StatusOK, nil
+	}
+
+	searchParams := make(map[string]interface{})
+	searchParams["workspace_id"] = task.Workspace
+	searchParams["user_email"] = origin.Email
+
+	teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), searchParams)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if len(teamMembers) == 0 {
+		return http.StatusForbidden, errors.New("Not a member of this workspace")
+	}
+
+	for _, tagID := range task.Tags {
+		tags, err := h.DB.GetTagsWithFilters(c.Request.Context(), map[string]interface{}{"_id": tagID,
+			"workspace_id": task.Workspace})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if len(tags) == 0 {
+			return http.StatusBadRequest, fmt.Errorf("tags: %s is not part of this workspace", tagID)
+		}
+	}
+
+	return http.StatusOK, nil
+}
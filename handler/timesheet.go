@@ -0,0 +1,425 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+const (
+	// TIMESHEET_DAYS is the number of days of a timesheet, weeks start on Monday
+	TIMESHEET_DAYS = 7
+	// TIMESHEET_START_HOUR is the hour of the day, in the user's time zone, time entries created
+	// from a timesheet start at
+	TIMESHEET_START_HOUR = 9
+)
+
+// GetTimesheet returns the caller's time entries of a week given as week=2026-W42 as a grid of
+// projects and days, the current week when none is given. With report:view_all the timesheet of
+// the user given by user_id is returned.
+func GetTimesheet(c *gin.Context, h *Handler, origin *models.User) {
+	userID, err := getVisibleUser(c, origin)
+	if err != nil {
+		RespondError(c, http.StatusForbidden, err)
+		return
+	}
+
+	owner, status, err := getTimesheetOwner(c, h, origin, userID)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	start, err := parseWeek(c.Query("week"), userLocation(owner))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	timesheet, err := getTimesheet(c, h.DB, owner, start)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, timesheet)
+}
+
+// UpdateTimesheet sets the hours of the rows given in the body for every day of the week. A cell
+// that changes is made to add up to its new hours by stretching or shortening its latest time
+// entries, deleting entries that no longer fit, or creating an entry when the cell was empty.
+// Cells with a running timer or locked entries can not be changed, nor can an approved week.
+// Editing another user's timesheet, given by
+// user_id, needs time:edit_others.
+func UpdateTimesheet(c *gin.Context, h *Handler, origin *models.User) {
+	userID := c.Query("user_id")
+	if userID != "" && userID != origin.ID && !rbac.HasPermission(workspaceMember(c).TeamRole, rbac.TIME_EDIT_OTHERS) {
+		RespondError(c, http.StatusForbidden, fmt.Errorf("Missing permission %s", rbac.TIME_EDIT_OTHERS))
+		return
+	}
+
+	owner, status, err := getTimesheetOwner(c, h, origin, userID)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	loc := userLocation(owner)
+	start, err := parseWeek(c.Query("week"), loc)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	edit := &models.TimesheetEdit{}
+	if err := bindRequest(c, edit); err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status, err = checkTimesheetEdit(c, h, edit)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		status, err = checkPeriodOpen(c, tx, c.Param("workspace_id"), owner.ID, start)
+		if err != nil {
+			return err
+		}
+
+		status = http.StatusInternalServerError
+		searchParams := workspaceFilter(c, "")
+		searchParams["user_id"] = owner.ID
+
+		tasks, err := tx.GetTasksInRange(c.Request.Context(), searchParams, start, start.AddDate(0, 0, TIMESHEET_DAYS))
+		if err != nil {
+			return err
+		}
+
+		cells := make(map[string][][]*models.Task)
+		for _, t := range tasks {
+			if cells[t.Project] == nil {
+				cells[t.Project] = make([][]*models.Task, TIMESHEET_DAYS)
+			}
+
+			day := timesheetDay(start, t.StartTime, loc)
+			cells[t.Project][day] = append(cells[t.Project][day], t)
+		}
+
+		for _, row := range edit.Rows {
+			for day, hours := range row.Hours {
+				var cell []*models.Task
+				if cells[row.Project] != nil {
+					cell = cells[row.Project][day]
+				}
+
+				err = setTimesheetCell(c, tx, owner, row, start.AddDate(0, 0, day), cell, hours)
+				if err != nil {
+					if errors.Is(err, errTimerRunning) {
+						status = http.StatusBadRequest
+					} else if errors.Is(err, errTaskLocked) || errors.Is(err, errTaskInvoiced) {
+						status = http.StatusConflict
+					}
+					return err
+				}
+			}
+
+			err = notifyProjectBudget(c, tx, row.Project)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	timesheet, err := getTimesheet(c, h.DB, owner, start)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, timesheet)
+}
+
+// errTimerRunning rejects changing a timesheet cell that holds a running time entry
+var errTimerRunning = errors.New("stop the running timer before changing its timesheet cell")
+
+// setTimesheetCell makes the finished time entries of cell, the entries of row's project on the
+// day starting at dayStart, add up to hours
+func setTimesheetCell(c *gin.Context, db dbhandler.DbHandler, owner *models.User, row *models.TimesheetEditRow,
+	dayStart time.Time, cell []*models.Task, hours float64) error {
+	target := time.Duration(math.Round(hours*3600)) * time.Second
+
+	current := timesheetCellDuration(cell)
+	if target == current {
+		return nil
+	}
+
+	for _, t := range cell {
+		if t.EndTime == nil {
+			return fmt.Errorf("%s on %s: %w", timesheetRowName(row.Project), dayStart.Format(DATE_LAYOUT), errTimerRunning)
+		}
+
+		// The entry is locked first, so it can not be invoiced before it is changed
+		current, err := db.GetTaskForUpdate(c.Request.Context(), t.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := checkTaskChangeable(current); err != nil {
+			return fmt.Errorf("%s on %s: %w", timesheetRowName(row.Project), dayStart.Format(DATE_LAYOUT), err)
+		}
+	}
+
+	if len(cell) == 0 {
+		startTime := time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), TIMESHEET_START_HOUR, 0, 0, 0,
+			dayStart.Location()).UTC()
+		endTime := startTime.Add(target)
+
+		task := &models.Task{
+			Billable:  row.Billable,
+			StartTime: startTime,
+			EndTime:   &endTime,
+			Date:      dayStart,
+			Project:   row.Project,
+			User:      owner.ID,
+			Workspace: c.Param("workspace_id"),
+		}
+
+		_, _, err := db.AddTask(c.Request.Context(), task)
+		return err
+	}
+
+	// Time is added to or taken from the latest entries so earlier ones keep their times. Entries
+	// are only changed at the version they were read at, a concurrent edit fails the request.
+	if target > current {
+		last := cell[len(cell)-1]
+		updates := map[string]interface{}{"end_time": last.EndTime.Add(target - current)}
+		expectVersion(updates, last.Version)
+
+		_, err := db.UpdateTask(c.Request.Context(), last.ID, updates)
+		return err
+	}
+
+	remove := current - target
+	for i := len(cell) - 1; i >= 0 && remove > 0; i-- {
+		t := cell[i]
+		duration := t.EndTime.Sub(t.StartTime)
+		if duration <= remove {
+			err := db.DeleteTask(c.Request.Context(), t.ID, t.Version)
+			if err != nil {
+				return err
+			}
+			remove -= duration
+			continue
+		}
+
+		updates := map[string]interface{}{"end_time": t.EndTime.Add(-remove)}
+		expectVersion(updates, t.Version)
+
+		_, err := db.UpdateTask(c.Request.Context(), t.ID, updates)
+		return err
+	}
+
+	return nil
+}
+
+// timesheetCellDuration is the time of the finished entries of cell
+func timesheetCellDuration(cell []*models.Task) time.Duration {
+	var d time.Duration
+	for _, t := range cell {
+		if t.EndTime != nil {
+			d += t.EndTime.Sub(t.StartTime)
+		}
+	}
+
+	return d
+}
+
+// getTimesheet builds the timesheet of owner for the week starting at start from the time
+// entries of the workspace, running entries are not counted
+func getTimesheet(c *gin.Context, db dbhandler.DbHandler, owner *models.User, start time.Time) (*models.Timesheet, error) {
+	searchParams := workspaceFilter(c, "")
+	searchParams["user_id"] = owner.ID
+
+	tasks, err := db.GetTasksInRange(c.Request.Context(), searchParams, start, start.AddDate(0, 0, TIMESHEET_DAYS))
+	if err != nil {
+		return nil, err
+	}
+
+	year, week := start.ISOWeek()
+	timesheet := &models.Timesheet{
+		Week:   fmt.Sprintf("%04d-W%02d", year, week),
+		User:   owner.ID,
+		Start:  start,
+		Days:   make([]string, TIMESHEET_DAYS),
+		Rows:   make([]*models.TimesheetRow, 0),
+		Totals: make([]float64, TIMESHEET_DAYS),
+	}
+
+	for i := range timesheet.Days {
+		timesheet.Days[i] = start.AddDate(0, 0, i).Format(DATE_LAYOUT)
+	}
+
+	rows := make(map[string]*models.TimesheetRow)
+	loc := userLocation(owner)
+	for _, t := range tasks {
+		if t.EndTime == nil {
+			continue
+		}
+
+		row, ok := rows[t.Project]
+		if !ok {
+			row = &models.TimesheetRow{Project: t.Project, Hours: make([]float64, TIMESHEET_DAYS)}
+			if t.Project != "" {
+				project, err := db.GetProject(c.Request.Context(), t.Project)
+				if err != nil {
+					return nil, err
+				}
+				row.ProjectName = project.Name
+			}
+
+			rows[t.Project] = row
+			timesheet.Rows = append(timesheet.Rows, row)
+		}
+
+		hours := t.EndTime.Sub(t.StartTime).Hours()
+		day := timesheetDay(start, t.StartTime, loc)
+		row.Hours[day] += hours
+		row.Total += hours
+		timesheet.Totals[day] += hours
+		timesheet.Hours += hours
+	}
+
+	// Projects are listed by name, time tracked without a project comes last
+	sort.Slice(timesheet.Rows, func(i, j int) bool {
+		a, b := timesheet.Rows[i], timesheet.Rows[j]
+		if (a.Project == "") != (b.Project == "") {
+			return b.Project == ""
+		}
+		if a.ProjectName != b.ProjectName {
+			return a.ProjectName < b.ProjectName
+		}
+		return a.Project < b.Project
+	})
+
+	return timesheet, nil
+}
+
+// getTimesheetOwner returns the user whose timesheet is asked for, the caller when userID is
+// empty. The user has to be a team member of the workspace.
+func getTimesheetOwner(c *gin.Context, h *Handler, origin *models.User, userID string) (*models.User, int, error) {
+	// The caller is read as well, their time zone in the auth token may be out of date
+	if userID == "" || userID == origin.ID {
+		owner, err := h.DB.GetUser(c.Request.Context(), origin.ID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+
+		return owner, http.StatusOK, nil
+	}
+
+	owner, err := h.DB.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	searchParams := workspaceFilter(c, "")
+	searchParams["user_email"] = owner.Email
+
+	teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), searchParams)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(teamMembers) == 0 {
+		return nil, http.StatusNotFound, errors.New("user is not a member of this workspace")
+	}
+
+	return owner, http.StatusOK, nil
+}
+
+// checkTimesheetEdit checks what the binding tags of edit can not: that no project has two rows
+// and that the projects belong to the workspace
+func checkTimesheetEdit(c *gin.Context, h *Handler, edit *models.TimesheetEdit) (int, error) {
+	seen := make(map[string]bool)
+	for i, row := range edit.Rows {
+		if seen[row.Project] {
+			return http.StatusBadRequest, newFieldError(fmt.Sprintf("rows[%d].project_id", i), "%s is given twice",
+				timesheetRowName(row.Project))
+		}
+		seen[row.Project] = true
+	}
+
+	return checkRequestReferences(c, h, edit)
+}
+
+// timesheetRowName names the row of projectID in errors
+func timesheetRowName(projectID string) string {
+	if projectID == "" {
+		return "the row without project"
+	}
+
+	return "project " + projectID
+}
+
+// timesheetDay returns the column of a timesheet starting at start that t falls on in loc
+func timesheetDay(start, t time.Time, loc *time.Location) int {
+	day := startOfDay(t, loc)
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// parseWeek parses an ISO week such as 2026-W42 and returns the start of its Monday in loc, the
+// current week when value is empty
+func parseWeek(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		today := startOfDay(time.Now(), loc)
+		return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7)), nil
+	}
+
+	invalid := fmt.Errorf("week: expected an ISO week such as 2026-W42")
+	parts := strings.Split(value, "-W")
+	if len(parts) != 2 || len(parts[0]) != 4 || len(parts[1]) != 2 {
+		return time.Time{}, invalid
+	}
+
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, invalid
+	}
+
+	week, err := strconv.Atoi(parts[1])
+	if err != nil || week < 1 {
+		return time.Time{}, invalid
+	}
+
+	// January 4th is always in the first week of its year
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	start := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*TIMESHEET_DAYS)
+
+	if y, w := start.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("week: %d has no week %d", year, week)
+	}
+
+	return start, nil
+}
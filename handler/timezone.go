@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// DATE_LAYOUT is the layout of a calendar day given without a time
+const DATE_LAYOUT = "2006-01-02"
+
+// validateTimezone checks that timezone is an IANA zone name such as Europe/Berlin
+func validateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+
+	_, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("timezone: unknown time zone %s", timezone)
+	}
+
+	return nil
+}
+
+// userLocation returns the time zone preferred by user, UTC when none is set
+func userLocation(user *models.User) *time.Location {
+	if user == nil || user.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// getUserLocation returns the time zone userID has set now. The days of time entries are computed
+// in it rather than in the time zone of the auth token, which is the one the user had at login.
+func getUserLocation(c *gin.Context, db dbhandler.DbHandler, userID string) (*time.Location, error) {
+	user, err := db.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return userLocation(user), nil
+}
+
+// parseTimestamp parses an RFC 3339 timestamp, the offset is required so the instant is never
+// ambiguous
+func parseTimestamp(name, value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: expected an RFC 3339 timestamp such as 2006-01-02T15:04:05+01:00", name)
+	}
+
+	return t.Truncate(time.Second), nil
+}
+
+// parseDate parses a day given as 2006-01-02 in loc or as an RFC 3339 timestamp, and returns the
+// start of that day in loc
+func parseDate(name, value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(DATE_LAYOUT, value, loc); err == nil {
+		return t, nil
+	}
+
+	t, err := parseTimestamp(name, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: expected a date such as 2006-01-02 or an RFC 3339 timestamp", name)
+	}
+
+	return startOfDay(t, loc), nil
+}
+
+// startOfDay returns midnight of the day t falls on in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// localizeTasks converts the timestamps of tasks into the time zone of user for the response
+func localizeTasks(user *models.User, tasks ...*models.Task) {
+	loc := userLocation(user)
+	for _, t := range tasks {
+		if t == nil {
+			continue
+		}
+
+		t.StartTime = t.StartTime.In(loc)
+		t.Date = t.Date.In(loc)
+		if t.EndTime != nil {
+			endTime := t.EndTime.In(loc)
+			t.EndTime = &endTime
+		}
+	}
+}
+
+// localizeApprovals converts the timestamps of approvals into the time zone of user for the
+// response
+func localizeApprovals(user *models.User, approvals ...*models.Approval) {
+	loc := userLocation(user)
+	for _, a := range approvals {
+		a.PeriodStart = a.PeriodStart.In(loc)
+		a.PeriodEnd = a.PeriodEnd.In(loc)
+		a.SubmittedAt = a.SubmittedAt.In(loc)
+		if a.ReviewedAt != nil {
+			reviewedAt := a.ReviewedAt.In(loc)
+			a.ReviewedAt = &reviewedAt
+		}
+	}
+}
+
+func localizeInvoices(user *models.User, invoices ...*models.Invoice) {
+	loc := userLocation(user)
+	for _, i := range invoices {
+		i.IssueDate = i.IssueDate.In(loc)
+		i.PeriodStart = i.PeriodStart.In(loc)
+		i.PeriodEnd = i.PeriodEnd.In(loc)
+		if i.DueDate != nil {
+			dueDate := i.DueDate.In(loc)
+			i.DueDate = &dueDate
+		}
+	}
+}
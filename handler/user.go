@@ -8,67 +8,144 @@ import (
 	"github.com/qasim-sajid/clockify-api/models"
 )
 
+// userList defines how users can be sorted and filtered
+var userList = &listSpec{
+	sorts:       []string{"name", "username", "email"},
+	defaultSort: "name",
+	filters:     filterFields{"name": filterText, "username": filterText, "email": filterText},
+}
+
 func AddUser(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.SignUpRequest{}
+	err := bindRequest(c, req)
+	if err == nil {
+		err = checkSignUp(req)
+	}
+
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
 	user := &models.User{}
-	user.Name = c.Query("name")
-	user.Email = c.Query("email")
-	user.Username = c.Query("username")
-	user.Password = c.Query("password")
+	user.Name = req.Name
+	user.Email = req.Email
+	user.Username = req.Username
+	user.Password = req.Password
+	user.Timezone = req.Timezone
 
-	user, _, err := h.DB.AddUser(user)
+	user, _, err = h.DB.AddUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("User with _id = %s added!", user.ID)})
 	}
 }
 
+// GetAllUsers returns the caller and everyone sharing a workspace with them
 func GetAllUsers(c *gin.Context, h *Handler, origin *models.User) {
-	users, err := h.DB.GetAllUsers()
+	workspaces, err := getUserWorkspaces(c, h, origin)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	emails := []string{origin.Email}
+	seen := map[string]bool{origin.Email: true}
+	for _, w := range workspaces {
+		teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), map[string]interface{}{"workspace_id": w.ID})
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, tm := range teamMembers {
+			if !seen[tm.User] {
+				seen[tm.User] = true
+				emails = append(emails, tm.User)
+			}
+		}
+	}
+
+	options, page, status, err := getListPage(c, h, origin, models.User{}, map[string]interface{}{"email": emails}, userList)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	users, err := h.DB.GetUsersWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	respondList(c, options, page, models.PublicUsers(users))
 }
 
 func GetUser(c *gin.Context, h *Handler, origin *models.User) {
 	userID := c.Param("user_id")
-	user, err := h.DB.GetUser(userID)
+	user, err := h.DB.GetUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, user)
+		setETag(c, user.Version)
+		c.JSON(http.StatusOK, user.Public())
 	}
 }
 
 func UpdateUser(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	userID := c.Param("user_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
+	user, status, err := getMatchingUser(c, h, userID)
+	if err != nil {
+		RespondError(c, status, err)
+		return
 	}
 
-	_, err := h.DB.UpdateUser(userID, updates)
+	updates, err := bindPatch(c, &models.UpdateUserRequest{})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	expectVersion(updates, user.Version)
+
+	user, err = h.DB.UpdateUser(c.Request.Context(), userID, updates)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("User with _id = %s updated!", userID)})
+		setETag(c, user.Version)
+		c.JSON(http.StatusOK, user.Public())
 	}
 }
 
 func DeleteUser(c *gin.Context, h *Handler, origin *models.User) {
 	userID := c.Param("user_id")
-	err := h.DB.DeleteUser(userID)
+	user, status, err := getMatchingUser(c, h, userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.DeleteUser(c.Request.Context(), userID, user.Version)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("User with _id = %s deleted!", userID)})
 	}
 }
+
+// getMatchingUser returns the user with userID when the If-Match header of the request holds
+// its current ETag
+func getMatchingUser(c *gin.Context, h *Handler, userID string) (*models.User, int, error) {
+	user, err := h.DB.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	status, err := checkIfMatch(c, user.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	return user, http.StatusOK, nil
+}
@@ -5,68 +5,179 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/dbhandler"
 	"github.com/qasim-sajid/clockify-api/models"
+	"github.com/qasim-sajid/clockify-api/rbac"
 )
 
+// workspaceList defines how workspaces can be sorted and filtered
+var workspaceList = &listSpec{
+	sorts:       []string{"name"},
+	defaultSort: "name",
+	filters:     filterFields{"name": filterText},
+}
+
+// AddWorkspace creates the workspace and makes its creator the owner
 func AddWorkspace(c *gin.Context, h *Handler, origin *models.User) {
+	req := &models.AddWorkspaceRequest{}
+	err := bindRequest(c, req)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
 	workspace := &models.Workspace{}
+	workspace.Name = req.Name
 
-	workspace.Name = c.Query("name")
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		var err error
+		workspace, _, err = tx.AddWorkspace(c.Request.Context(), workspace)
+		if err != nil {
+			return err
+		}
 
-	workspace, _, err := h.DB.AddWorkspace(workspace)
+		teamMember := &models.TeamMember{}
+		teamMember.Workspace = workspace.ID
+		teamMember.User = origin.Email
+		teamMember.TeamRole = rbac.ROLE_OWNER
+
+		_, _, err = tx.AddTeamMember(c.Request.Context(), teamMember)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Workspace with _id = %s added!", workspace.ID)})
 	}
 }
 
+// GetAllWorkspaces returns the workspaces the caller is a team member of
 func GetAllWorkspaces(c *gin.Context, h *Handler, origin *models.User) {
-	workspaces, err := h.DB.GetAllWorkspaces()
+	teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), map[string]interface{}{"user_email": origin.Email})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	workspaceIDs := make([]string, 0, len(teamMembers))
+	for _, tm := range teamMembers {
+		workspaceIDs = append(workspaceIDs, tm.Workspace)
+	}
+
+	options, page, status, err := getListPage(c, h, origin, models.Workspace{}, map[string]interface{}{"_id": workspaceIDs}, workspaceList)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, workspaces)
+	workspaces, err := h.DB.GetWorkspacesWithFilters(c.Request.Context(), map[string]interface{}{"_id": page.IDs})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondList(c, options, page, workspaces)
 }
 
 func GetWorkspace(c *gin.Context, h *Handler, origin *models.User) {
 	workspaceID := c.Param("workspace_id")
-	workspace, err := h.DB.GetWorkspace(workspaceID)
+	workspace, err := h.DB.GetWorkspace(c.Request.Context(), workspaceID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
+		setETag(c, workspace.Version)
 		c.JSON(http.StatusOK, workspace)
 	}
 }
 
 func UpdateWorkspace(c *gin.Context, h *Handler, origin *models.User) {
-	updates := make(map[string]interface{})
 	workspaceID := c.Param("workspace_id")
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Duplicate parameter found!"})
-			return
-		} else {
-			updates[k] = v[0]
-		}
+	workspace, status, err := getMatchingWorkspace(c, h, workspaceID)
+	if err != nil {
+		RespondError(c, status, err)
+		return
+	}
+
+	updates, err := bindPatch(c, &models.UpdateWorkspaceRequest{})
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err)
+		return
 	}
 
-	_, err := h.DB.UpdateWorkspace(workspaceID, updates)
+	expectVersion(updates, workspace.Version)
+
+	workspace, err = h.DB.UpdateWorkspace(c.Request.Context(), workspaceID, updates)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Workspace with _id = %s updated!", workspaceID)})
+		setETag(c, workspace.Version)
+		c.JSON(http.StatusOK, workspace)
 	}
 }
 
+// DeleteWorkspace removes the workspace together with its team members, it fails while the
+// workspace still has other resources
 func DeleteWorkspace(c *gin.Context, h *Handler, origin *models.User) {
 	workspaceID := c.Param("workspace_id")
-	err := h.DB.DeleteWorkspace(workspaceID)
+	workspace, status, err := getMatchingWorkspace(c, h, workspaceID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		RespondError(c, status, err)
+		return
+	}
+
+	err = h.DB.WithTx(c.Request.Context(), func(tx dbhandler.DbHandler) error {
+		teamMembers, err := tx.GetTeamMembersWithFilters(c.Request.Context(), workspaceFilter(c, ""))
+		if err != nil {
+			return err
+		}
+
+		for _, tm := range teamMembers {
+			err = tx.DeleteTeamMember(c.Request.Context(), tm.ID, tm.Version)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.DeleteWorkspace(c.Request.Context(), workspaceID, workspace.Version)
+	})
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err)
 	} else {
 		c.JSON(http.StatusOK, gin.H{"success": fmt.Sprintf("Workspace with _id = %s deleted!", workspaceID)})
 	}
 }
+
+// getMatchingWorkspace returns the workspace with workspaceID when the If-Match header of the
+// request holds its current ETag
+func getMatchingWorkspace(c *gin.Context, h *Handler, workspaceID string) (*models.Workspace, int, error) {
+	workspace, err := h.DB.GetWorkspace(c.Request.Context(), workspaceID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	status, err := checkIfMatch(c, workspace.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	return workspace, http.StatusOK, nil
+}
+
+func getUserWorkspaces(c *gin.Context, h *Handler, user *models.User) ([]*models.Workspace, error) {
+	teamMembers, err := h.DB.GetTeamMembersWithFilters(c.Request.Context(), map[string]interface{}{"user_email": user.Email})
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]*models.Workspace, 0, len(teamMembers))
+	for _, tm := range teamMembers {
+		workspace, err := h.DB.GetWorkspace(c.Request.Context(), tm.Workspace)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func TestInvoiceBillsEntriesOnce(t *testing.T) {
+	s := newTestServer(t)
+
+	owner := s.signUp("owner")
+	workspace := s.addWorkspace(owner)
+
+	clientID := s.do(owner, http.MethodPost, workspace+"/client", gin.H{"name": "Acme"}, nil).
+		expect(http.StatusOK).addedID()
+	projectID := s.do(owner, http.MethodPost, workspace+"/project", gin.H{"name": "Alpha", "client_id": clientID,
+		"billable_rate": 50}, nil).expect(http.StatusOK).addedID()
+
+	addTask := func(billable bool, start, end string) string {
+		t.Helper()
+
+		return s.do(owner, http.MethodPost, workspace+"/task", gin.H{"project_id": projectID, "billable": billable,
+			"start_time": start, "end_time": end}, nil).expect(http.StatusOK).addedID()
+	}
+	addInvoice := func() *models.Invoice {
+		t.Helper()
+
+		invoice := &models.Invoice{}
+		s.do(owner, http.MethodPost, workspace+"/invoice", gin.H{"client_id": clientID, "start": "2022-03-01",
+			"end": "2022-03-31"}, nil).expect(http.StatusOK).decode(invoice)
+
+		return invoice
+	}
+
+	billed := addTask(true, "2022-03-01T09:00:00Z", "2022-03-01T11:00:00Z")
+	unbillable := addTask(false, "2022-03-02T09:00:00Z", "2022-03-02T10:00:00Z")
+	addTask(true, "2022-03-03T09:00:00Z", "2022-03-03T10:00:00Z")
+
+	invoice := addInvoice()
+	if len(invoice.Items) != 1 || invoice.Items[0].Quantity != 3 || invoice.Total != 150 {
+		t.Fatalf("got %d items and a total of %v, want 3 hours at 50", len(invoice.Items), invoice.Total)
+	}
+
+	task := &models.Task{}
+	s.do(owner, http.MethodGet, workspace+"/tasks/"+unbillable, nil, nil).expect(http.StatusOK).decode(task)
+	if task.InvoiceItem != "" {
+		t.Fatalf("got an invoiced entry that is not billable")
+	}
+
+	s.do(owner, http.MethodGet, workspace+"/tasks/"+billed, nil, nil).expect(http.StatusOK).decode(task)
+	if task.InvoiceItem != invoice.Items[0].ID {
+		t.Fatalf("got the entry on invoice item %q, want %q", task.InvoiceItem, invoice.Items[0].ID)
+	}
+
+	// Invoiced entries can not be changed, their time would no longer match the invoice
+	s.do(owner, http.MethodPatch, workspace+"/tasks/"+billed, gin.H{"end_time": "2022-03-01T12:00:00Z"},
+		ifMatch(task.Version)).expect(http.StatusConflict)
+	s.do(owner, http.MethodDelete, workspace+"/tasks/"+billed, nil, ifMatch(task.Version)).
+		expect(http.StatusConflict)
+	s.do(owner, http.MethodPut, workspace+"/timesheet?week=2022-W09", gin.H{"rows": []gin.H{
+		{"project_id": projectID, "billable": true, "hours": []float64{0, 3, 0, 1, 0, 0, 0}}}}, nil).
+		expect(http.StatusConflict)
+
+	// A second invoice of the same period only bills what the first did not
+	if again := addInvoice(); len(again.Items) != 0 || again.Total != 0 {
+		t.Fatalf("got %d items and a total of %v on the second invoice, want none", len(again.Items), again.Total)
+	}
+
+	addTask(true, "2022-03-04T09:00:00Z", "2022-03-04T10:00:00Z")
+	if later := addInvoice(); len(later.Items) != 1 || later.Items[0].Quantity != 1 || later.Total != 50 {
+		t.Fatalf("got %d items and a total of %v, want the new hour at 50", len(later.Items), later.Total)
+	}
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
+	_ "time/tzdata" // user time zones have to load on hosts without a zoneinfo database
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/qasim-sajid/clockify-api/auth"
 	"github.com/qasim-sajid/clockify-api/conf"
 	"github.com/qasim-sajid/clockify-api/handler"
+	"github.com/qasim-sajid/clockify-api/rbac"
 )
 
 // Main function
@@ -24,6 +31,23 @@ func main() {
 	apiHandler.DB.SetupDB()
 	defer apiHandler.DB.CloseDB()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrateCommand(apiHandler, os.Args[2:])
+		if err != nil {
+			// log.Fatal exits without running the deferred CloseDB
+			apiHandler.DB.CloseDB()
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if conf.Configs.AutoMigrate {
+		_, err = apiHandler.DB.MigrateUp(context.Background())
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	router := setupRouter(apiHandler)
 
 	err = router.Run(conf.GetServerAddress())
@@ -33,7 +57,13 @@ func main() {
 }
 
 func setupRouter(h *handler.Handler) *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
+	router.Use(handler.RequestID(), gin.Logger(), gin.CustomRecovery(handler.RecoverPanic))
+
+	// Unknown routes and methods get the same error responses as the routes themselves
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(handler.RouteNotFound)
+	router.NoMethod(handler.MethodNotAllowed)
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -43,6 +73,8 @@ func setupRouter(h *handler.Handler) *gin.Engine {
 		AllowCredentials: true,
 	}))
 
+	router.Use(handler.QueryTimeout(conf.Configs.QueryTimeout))
+
 	// healthz
 	router.GET("/healthz", healthGET())
 
@@ -50,59 +82,106 @@ func setupRouter(h *handler.Handler) *gin.Engine {
 	router.POST("/login", auth.LoginUser(h))
 	router.POST("/refresh_user", auth.RefreshUserTokenPOST(h))
 
-	router.POST("/client", auth.IsUserAuthorized(handler.AddClient, h))
-	router.GET("/clients", auth.IsUserAuthorized(handler.GetAllClients, h))
-	router.GET("/clients/:client_id", auth.IsUserAuthorized(handler.GetClient, h))
-	router.PUT("/clients/:client_id", auth.IsUserAuthorized(handler.UpdateClient, h))
-	router.DELETE("/clients/:client_id", auth.IsUserAuthorized(handler.DeleteClient, h))
-
-	router.POST("/project", auth.IsUserAuthorized(handler.AddProject, h))
-	router.GET("/projects", auth.IsUserAuthorized(handler.GetAllProjects, h))
-	router.GET("/projects/:project_id", auth.IsUserAuthorized(handler.GetProject, h))
-	router.PUT("/projects/:project_id", auth.IsUserAuthorized(handler.UpdateProject, h))
-	router.DELETE("/projects/:project_id", auth.IsUserAuthorized(handler.DeleteProject, h))
-
-	router.POST("/tag", auth.IsUserAuthorized(handler.AddTag, h))
-	router.GET("/tags", auth.IsUserAuthorized(handler.GetAllTags, h))
-	router.GET("/tags/:tag_id", auth.IsUserAuthorized(handler.GetTag, h))
-	router.PUT("/tags/:tag_id", auth.IsUserAuthorized(handler.UpdateTag, h))
-	router.DELETE("/tags/:tag_id", auth.IsUserAuthorized(handler.DeleteTag, h))
-
-	router.POST("/task", auth.IsUserAuthorized(handler.AddTask, h))
-	router.GET("/tasks", auth.IsUserAuthorized(handler.GetAllTasks, h))
-	router.GET("/tasks/:task_id", auth.IsUserAuthorized(handler.GetTask, h))
-	router.PUT("/tasks/:task_id", auth.IsUserAuthorized(handler.UpdateTask, h))
-	router.DELETE("/tasks/:task_id", auth.IsUserAuthorized(handler.DeleteTask, h))
-
-	router.POST("/team_group", auth.IsUserAuthorized(handler.AddTeamGroup, h))
-	router.GET("/team_groups", auth.IsUserAuthorized(handler.GetAllTeamGroups, h))
-	router.GET("/team_groups/:team_group_id", auth.IsUserAuthorized(handler.GetTeamGroup, h))
-	router.PUT("/team_groups/:team_group_id", auth.IsUserAuthorized(handler.UpdateTeamGroup, h))
-	router.DELETE("/team_groups/:team_group_id", auth.IsUserAuthorized(handler.DeleteTeamGroup, h))
-
-	router.POST("/team_member", auth.IsUserAuthorized(handler.AddTeamMember, h))
-	router.GET("/team_members", auth.IsUserAuthorized(handler.GetAllTeamMembers, h))
-	router.GET("/team_members/:team_member_id", auth.IsUserAuthorized(handler.GetTeamMember, h))
-	router.PUT("/team_members/:team_member_id", auth.IsUserAuthorized(handler.UpdateTeamMember, h))
-	router.DELETE("/team_members/:team_member_id", auth.IsUserAuthorized(handler.DeleteTeamMember, h))
-
-	router.POST("/team_role", auth.IsUserAuthorized(handler.AddTeamRole, h))
+	router.POST("/timer/start", auth.IsUserAuthorized(handler.StartTimer, h))
+	router.POST("/timer/stop", auth.IsUserAuthorized(handler.StopTimer, h))
+	router.GET("/timer/current", auth.IsUserAuthorized(handler.GetCurrentTimer, h))
+
+	router.GET("/notifications", auth.IsUserAuthorized(handler.GetAllNotifications, h))
+	router.PUT("/notifications/:notification_id", auth.IsUserAuthorized(handler.UpdateNotification, h))
+	router.PATCH("/notifications/:notification_id", auth.IsUserAuthorized(handler.UpdateNotification, h))
+
+	router.POST("/workspace", auth.IsUserAuthorized(handler.AddWorkspace, h))
+	router.GET("/workspaces", auth.IsUserAuthorized(handler.GetAllWorkspaces, h))
+	router.GET("/workspaces/:workspace_id", auth.IsWorkspaceMember(handler.GetWorkspace, h))
+	router.PUT("/workspaces/:workspace_id", auth.HasPermission(rbac.WORKSPACE_EDIT, handler.UpdateWorkspace, h))
+	router.PATCH("/workspaces/:workspace_id", auth.HasPermission(rbac.WORKSPACE_EDIT, handler.UpdateWorkspace, h))
+	router.DELETE("/workspaces/:workspace_id", auth.HasPermission(rbac.WORKSPACE_DELETE, handler.DeleteWorkspace, h))
+
+	// Everything below belongs to a workspace and is only visible to its team members, changes
+	// other than time entries need a permission of the member's team role
+	workspace := router.Group("/workspaces/:workspace_id")
+
+	workspace.POST("/client", auth.HasPermission(rbac.CLIENT_EDIT, handler.AddClient, h))
+	workspace.GET("/clients", auth.IsWorkspaceMember(handler.GetAllClients, h))
+	workspace.GET("/clients/:client_id", auth.IsWorkspaceMember(handler.GetClient, h))
+	workspace.PUT("/clients/:client_id", auth.HasPermission(rbac.CLIENT_EDIT, handler.UpdateClient, h))
+	workspace.PATCH("/clients/:client_id", auth.HasPermission(rbac.CLIENT_EDIT, handler.UpdateClient, h))
+	workspace.DELETE("/clients/:client_id", auth.HasPermission(rbac.CLIENT_EDIT, handler.DeleteClient, h))
+
+	workspace.POST("/project", auth.HasPermission(rbac.PROJECT_EDIT, handler.AddProject, h))
+	workspace.GET("/projects", auth.IsWorkspaceMember(handler.GetAllProjects, h))
+	workspace.GET("/projects/:project_id", auth.IsWorkspaceMember(handler.GetProject, h))
+	workspace.PUT("/projects/:project_id", auth.HasPermission(rbac.PROJECT_EDIT, handler.UpdateProject, h))
+	workspace.PATCH("/projects/:project_id", auth.HasPermission(rbac.PROJECT_EDIT, handler.UpdateProject, h))
+	workspace.DELETE("/projects/:project_id", auth.HasPermission(rbac.PROJECT_EDIT, handler.DeleteProject, h))
+
+	workspace.POST("/tag", auth.HasPermission(rbac.TAG_EDIT, handler.AddTag, h))
+	workspace.GET("/tags", auth.IsWorkspaceMember(handler.GetAllTags, h))
+	workspace.GET("/tags/:tag_id", auth.IsWorkspaceMember(handler.GetTag, h))
+	workspace.PUT("/tags/:tag_id", auth.HasPermission(rbac.TAG_EDIT, handler.UpdateTag, h))
+	workspace.PATCH("/tags/:tag_id", auth.HasPermission(rbac.TAG_EDIT, handler.UpdateTag, h))
+	workspace.DELETE("/tags/:tag_id", auth.HasPermission(rbac.TAG_EDIT, handler.DeleteTag, h))
+
+	workspace.GET("/reports/summary", auth.IsWorkspaceMember(handler.GetSummaryReport, h))
+	workspace.GET("/reports/detailed", auth.IsWorkspaceMember(handler.GetDetailedReport, h))
+
+	workspace.POST("/task", auth.IsWorkspaceMember(handler.AddTask, h))
+	workspace.GET("/tasks", auth.IsWorkspaceMember(handler.GetAllTasks, h))
+	workspace.GET("/tasks/:task_id", auth.IsWorkspaceMember(handler.GetTask, h))
+	workspace.PUT("/tasks/:task_id", auth.IsWorkspaceMember(handler.UpdateTask, h))
+	workspace.PATCH("/tasks/:task_id", auth.IsWorkspaceMember(handler.UpdateTask, h))
+	workspace.DELETE("/tasks/:task_id", auth.IsWorkspaceMember(handler.DeleteTask, h))
+
+	workspace.POST("/approval", auth.IsWorkspaceMember(handler.SubmitApproval, h))
+	workspace.GET("/approvals", auth.IsWorkspaceMember(handler.GetAllApprovals, h))
+	workspace.GET("/approvals/:approval_id", auth.IsWorkspaceMember(handler.GetApproval, h))
+	workspace.POST("/approvals/:approval_id/approve", auth.HasPermission(rbac.TIME_APPROVE, handler.ApproveApproval, h))
+	workspace.POST("/approvals/:approval_id/reject", auth.HasPermission(rbac.TIME_APPROVE, handler.RejectApproval, h))
+	workspace.POST("/approvals/:approval_id/unlock", auth.HasPermission(rbac.TIME_UNLOCK, handler.UnlockApproval, h))
+
+	workspace.POST("/invoice", auth.HasPermission(rbac.INVOICE_MANAGE, handler.AddInvoice, h))
+	workspace.GET("/invoices", auth.HasPermission(rbac.INVOICE_MANAGE, handler.GetAllInvoices, h))
+	workspace.GET("/invoices/:invoice_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.GetInvoice, h))
+	workspace.PUT("/invoices/:invoice_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoice, h))
+	workspace.PATCH("/invoices/:invoice_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoice, h))
+	workspace.DELETE("/invoices/:invoice_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.DeleteInvoice, h))
+	workspace.GET("/invoices/:invoice_id/pdf", auth.HasPermission(rbac.INVOICE_MANAGE, handler.GetInvoicePDF, h))
+	workspace.POST("/invoices/:invoice_id/item", auth.HasPermission(rbac.INVOICE_MANAGE, handler.AddInvoiceItem, h))
+	workspace.PUT("/invoices/:invoice_id/items/:item_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoiceItem, h))
+	workspace.PATCH("/invoices/:invoice_id/items/:item_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoiceItem, h))
+	workspace.DELETE("/invoices/:invoice_id/items/:item_id", auth.HasPermission(rbac.INVOICE_MANAGE, handler.DeleteInvoiceItem, h))
+	workspace.GET("/invoice_template", auth.HasPermission(rbac.INVOICE_MANAGE, handler.GetInvoiceTemplate, h))
+	workspace.PUT("/invoice_template", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoiceTemplate, h))
+	workspace.PATCH("/invoice_template", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoiceTemplate, h))
+	workspace.PUT("/invoice_template/logo", auth.HasPermission(rbac.INVOICE_MANAGE, handler.UpdateInvoiceTemplateLogo, h))
+	workspace.DELETE("/invoice_template/logo", auth.HasPermission(rbac.INVOICE_MANAGE, handler.DeleteInvoiceTemplateLogo, h))
+
+	workspace.GET("/timesheet", auth.IsWorkspaceMember(handler.GetTimesheet, h))
+	workspace.PUT("/timesheet", auth.IsWorkspaceMember(handler.UpdateTimesheet, h))
+
+	workspace.POST("/team_group", auth.HasPermission(rbac.MEMBER_MANAGE, handler.AddTeamGroup, h))
+	workspace.GET("/team_groups", auth.IsWorkspaceMember(handler.GetAllTeamGroups, h))
+	workspace.GET("/team_groups/:team_group_id", auth.IsWorkspaceMember(handler.GetTeamGroup, h))
+	workspace.PUT("/team_groups/:team_group_id", auth.HasPermission(rbac.MEMBER_MANAGE, handler.UpdateTeamGroup, h))
+	workspace.PATCH("/team_groups/:team_group_id", auth.HasPermission(rbac.MEMBER_MANAGE, handler.UpdateTeamGroup, h))
+	workspace.DELETE("/team_groups/:team_group_id", auth.HasPermission(rbac.MEMBER_MANAGE, handler.DeleteTeamGroup, h))
+
+	workspace.POST("/team_member", auth.HasPermission(rbac.MEMBER_MANAGE, handler.AddTeamMember, h))
+	workspace.GET("/team_members", auth.IsWorkspaceMember(handler.GetAllTeamMembers, h))
+	workspace.GET("/team_members/:team_member_id", auth.IsWorkspaceMember(handler.GetTeamMember, h))
+	workspace.PUT("/team_members/:team_member_id", auth.HasPermission(rbac.MEMBER_MANAGE, handler.UpdateTeamMember, h))
+	workspace.PATCH("/team_members/:team_member_id", auth.HasPermission(rbac.MEMBER_MANAGE, handler.UpdateTeamMember, h))
+	workspace.DELETE("/team_members/:team_member_id", auth.HasPermission(rbac.MEMBER_MANAGE, handler.DeleteTeamMember, h))
+
 	router.GET("/team_roles", auth.IsUserAuthorized(handler.GetAllTeamRoles, h))
 	router.GET("/team_roles/:team_role_id", auth.IsUserAuthorized(handler.GetTeamRole, h))
-	router.PUT("/team_roles/:team_role_id", auth.IsUserAuthorized(handler.UpdateTeamRole, h))
-	router.DELETE("/team_roles/:team_role_id", auth.IsUserAuthorized(handler.DeleteTeamRole, h))
 
 	router.GET("/users", auth.IsUserAuthorized(handler.GetAllUsers, h))
 	router.GET("/users/:user_id", auth.IsUserAuthorized(handler.GetUser, h))
 	router.PUT("/users/:user_id", auth.IsUserAuthorized(handler.UpdateUser, h))
+	router.PATCH("/users/:user_id", auth.IsUserAuthorized(handler.UpdateUser, h))
 	router.DELETE("/users/:user_id", auth.IsUserAuthorized(handler.DeleteUser, h))
 
-	router.POST("/workspace", auth.IsUserAuthorized(handler.AddWorkspace, h))
-	router.GET("/workspaces", auth.IsUserAuthorized(handler.GetAllWorkspaces, h))
-	router.GET("/workspaces/:workspace_id", auth.IsUserAuthorized(handler.GetWorkspace, h))
-	router.PUT("/workspaces/:workspace_id", auth.IsUserAuthorized(handler.UpdateWorkspace, h))
-	router.DELETE("/workspaces/:workspace_id", auth.IsUserAuthorized(handler.DeleteWorkspace, h))
-
 	return router
 }
 
@@ -111,3 +190,56 @@ func healthGET() gin.HandlerFunc {
 		c.JSON(http.StatusOK, "clockify-api")
 	}
 }
+
+// runMigrateCommand handles "migrate up", "migrate down [steps]" and "migrate status"
+func runMigrateCommand(h *handler.Handler, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [steps]|status")
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		count, err := h.DB.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("applied %d migration(s)\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid steps %q: %v", args[1], err)
+			}
+			steps = n
+		}
+
+		count, err := h.DB.MigrateDown(ctx, steps)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("reverted %d migration(s)\n", count)
+	case "status":
+		statuses, err := h.DB.GetMigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = fmt.Sprintf("applied at %s", s.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/conf"
+	"github.com/qasim-sajid/clockify-api/handler"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+// testPassword is the password of every user the tests sign up
+const testPassword = "Passw0rd!123"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	conf.Configs = &conf.Configuration{
+		SigningKey:            "signing-key",
+		RefreshSigningKey:     "refresh-signing-key",
+		DBDriver:              conf.DB_DRIVER_MEMORY,
+		QueryTimeout:          5 * time.Second,
+		PasswordHashAlgorithm: conf.PASSWORD_HASH_BCRYPT,
+		PasswordHashCost:      4,
+		PasswordMinLength:     8,
+	}
+
+	os.Exit(m.Run())
+}
+
+// testServer sends requests to the routes of setupRouter, every server has a memory database of
+// its own
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+// testUser is a signed up user and the token it is logged in with
+type testUser struct {
+	ID    string
+	Email string
+	token string
+}
+
+// testResponse is the response to a request of a test
+type testResponse struct {
+	t *testing.T
+	*httptest.ResponseRecorder
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	h, err := handler.NewHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testServer{t: t, router: setupRouter(h)}
+}
+
+// in returns the server for the requests of the subtest t
+func (s *testServer) in(t *testing.T) *testServer {
+	return &testServer{t: t, router: s.router}
+}
+
+// do sends a request as user, or without a token when it is nil. body is sent as JSON unless it
+// is nil.
+func (s *testServer) do(user *testUser, method, path string, body interface{}, header http.Header) *testResponse {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if user != nil {
+		req.Header.Set("Authorization", "Bearer "+user.token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	return &testResponse{t: s.t, ResponseRecorder: w}
+}
+
+// signUp signs up a user called name and logs it in
+func (s *testServer) signUp(name string) *testUser {
+	s.t.Helper()
+
+	user := &models.PublicUser{}
+	s.do(nil, http.MethodPost, "/signup", gin.H{"name": name, "email": name + "@example.com", "username": name,
+		"password": testPassword}, nil).expect(http.StatusOK).decode(user)
+
+	login := &struct {
+		AuthToken string `json:"auth_token"`
+	}{}
+	s.do(nil, http.MethodPost, "/login", gin.H{"identity": name, "password": testPassword}, nil).
+		expect(http.StatusOK).decode(login)
+
+	return &testUser{ID: user.ID, Email: user.Email, token: login.AuthToken}
+}
+
+// addWorkspace adds a workspace owned by owner and returns the path of its routes
+func (s *testServer) addWorkspace(owner *testUser) string {
+	s.t.Helper()
+
+	id := s.do(owner, http.MethodPost, "/workspace", gin.H{"name": "Workspace"}, nil).expect(http.StatusOK).addedID()
+	return "/workspaces/" + id
+}
+
+// addMember makes user a team member of the workspace at path with role
+func (s *testServer) addMember(owner *testUser, path string, user *testUser, role string) {
+	s.t.Helper()
+
+	s.do(owner, http.MethodPost, path+"/team_member", gin.H{"user_email": user.Email, "team_role_id": role}, nil).
+		expect(http.StatusOK)
+}
+
+// ifMatch returns the If-Match header of a change made from version
+func ifMatch(version int) http.Header {
+	return http.Header{"If-Match": {fmt.Sprintf(`"%d"`, version)}}
+}
+
+// expect fails the test unless the response has status
+func (r *testResponse) expect(status int) *testResponse {
+	r.t.Helper()
+
+	if r.Code != status {
+		r.t.Fatalf("got status %d, want %d: %s", r.Code, status, r.Body.String())
+	}
+
+	return r
+}
+
+// decode reads the JSON body of the response into out
+func (r *testResponse) decode(out interface{}) {
+	r.t.Helper()
+
+	err := json.Unmarshal(r.Body.Bytes(), out)
+	if err != nil {
+		r.t.Fatalf("decoding %s: %v", r.Body.String(), err)
+	}
+}
+
+// addedID returns the _id of the resource an add route reports as added
+func (r *testResponse) addedID() string {
+	r.t.Helper()
+
+	res := &struct {
+		Success string `json:"success"`
+	}{}
+	r.decode(res)
+
+	// "<Resource> with _id = <id> added!"
+	fields := strings.Fields(res.Success)
+	if len(fields) != 6 {
+		r.t.Fatalf("unexpected response %s", r.Body.String())
+	}
+
+	return fields[4]
+}
@@ -0,0 +1,45 @@
+package migrations
+
+// workspaceScoping ties clients and tags to a workspace. Existing rows take the workspace of
+// a project using them, rows that cannot be matched stay NULL and are not visible in any workspace.
+var workspaceScoping = Migration{
+	Version: 2,
+	Name:    "workspace_scoping",
+	Up: `ALTER TABLE public.client
+		ADD COLUMN IF NOT EXISTS workspace_id character varying COLLATE pg_catalog."default",
+		ADD CONSTRAINT client_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE NO ACTION;
+
+	ALTER TABLE public.tag
+		ADD COLUMN IF NOT EXISTS workspace_id character varying COLLATE pg_catalog."default",
+		ADD CONSTRAINT tag_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE NO ACTION;
+
+	UPDATE public.client c
+		SET workspace_id = (SELECT p.workspace_id FROM public.project p WHERE p.client_id = c._id LIMIT 1)
+		WHERE c.workspace_id IS NULL;
+
+	UPDATE public.tag t
+		SET workspace_id = (SELECT p.workspace_id FROM public.task_tag tt
+			JOIN public.task k ON k._id = tt.task_id
+			JOIN public.project p ON p._id = k.project_id
+			WHERE tt.tag_id = t._id LIMIT 1)
+		WHERE t.workspace_id IS NULL;
+
+	CREATE INDEX IF NOT EXISTS client_workspace_id_idx ON public.client (workspace_id);
+	CREATE INDEX IF NOT EXISTS tag_workspace_id_idx ON public.tag (workspace_id);
+
+	ALTER TABLE public.team_member
+		ADD CONSTRAINT team_member_workspace_user_unique UNIQUE (workspace_id, user_email);`,
+	Down: `ALTER TABLE public.team_member DROP CONSTRAINT IF EXISTS team_member_workspace_user_unique;
+
+	DROP INDEX IF EXISTS public.tag_workspace_id_idx;
+	DROP INDEX IF EXISTS public.client_workspace_id_idx;
+
+	ALTER TABLE public.tag DROP COLUMN IF EXISTS workspace_id;
+	ALTER TABLE public.client DROP COLUMN IF EXISTS workspace_id;`,
+}
@@ -0,0 +1,31 @@
+package migrations
+
+// builtinRoles adds the built-in team roles of the rbac package. Before roles were enforced every
+// team member could do everything, so existing members without a built-in role become owners
+// to keep that access. Owners can narrow it down afterwards.
+var builtinRoles = Migration{
+	Version: 3,
+	Name:    "builtin_roles",
+	Up: `INSERT INTO public.team_role (_id, role) VALUES
+		('tr_owner', 'owner'),
+		('tr_admin', 'admin'),
+		('tr_project_manager', 'project manager'),
+		('tr_member', 'member')
+	ON CONFLICT (_id) DO UPDATE SET role = EXCLUDED.role;
+
+	UPDATE public.team_member SET team_role_id = 'tr_owner'
+		WHERE team_role_id IS NULL
+		OR team_role_id NOT IN ('tr_owner', 'tr_admin', 'tr_project_manager', 'tr_member');
+
+	ALTER TABLE public.team_member
+		ALTER COLUMN team_role_id SET DEFAULT 'tr_member',
+		ALTER COLUMN team_role_id SET NOT NULL;`,
+	Down: `ALTER TABLE public.team_member
+		ALTER COLUMN team_role_id DROP NOT NULL,
+		ALTER COLUMN team_role_id DROP DEFAULT;
+
+	UPDATE public.team_member SET team_role_id = NULL
+		WHERE team_role_id IN ('tr_owner', 'tr_admin', 'tr_project_manager', 'tr_member');
+
+	DELETE FROM public.team_role WHERE _id IN ('tr_owner', 'tr_admin', 'tr_project_manager', 'tr_member');`,
+}
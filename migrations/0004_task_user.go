@@ -0,0 +1,22 @@
+package migrations
+
+// taskUser records who a time entry belongs to. The partial unique index keeps at most one
+// running entry per user.
+var taskUser = Migration{
+	Version: 4,
+	Name:    "task_user",
+	Up: `ALTER TABLE public.task
+		ADD COLUMN IF NOT EXISTS user_id character varying COLLATE pg_catalog."default",
+		ADD CONSTRAINT task_user_id_fkey FOREIGN KEY (user_id)
+			REFERENCES public."user" (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE NO ACTION;
+
+	CREATE INDEX IF NOT EXISTS task_user_id_idx ON public.task (user_id);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS task_running_user_unique ON public.task (user_id) WHERE is_active;`,
+	Down: `DROP INDEX IF EXISTS public.task_running_user_unique;
+	DROP INDEX IF EXISTS public.task_user_id_idx;
+
+	ALTER TABLE public.task DROP COLUMN IF EXISTS user_id;`,
+}
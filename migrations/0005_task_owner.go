@@ -0,0 +1,56 @@
+package migrations
+
+// taskOwner adds the workspace of a time entry and backfills older entries. Their workspace is
+// taken from the project, and entries without a user are given to an owner of that workspace
+// since the tracking user was never recorded. Entries without a project keep a NULL workspace.
+// An owner can keep only one running entry, so every other entry that would become theirs is
+// stopped first, the entries that already had a user and the latest ones are kept running.
+var taskOwner = Migration{
+	Version: 5,
+	Name:    "task_owner",
+	Up: `ALTER TABLE public.task
+		ADD COLUMN IF NOT EXISTS workspace_id character varying COLLATE pg_catalog."default",
+		ADD CONSTRAINT task_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE NO ACTION;
+
+	UPDATE public.task t
+		SET workspace_id = p.workspace_id
+		FROM public.project p
+		WHERE p._id = t.project_id AND t.workspace_id IS NULL;
+
+	WITH running AS (
+		SELECT t._id, t.user_id IS NOT NULL AS tracked, t.start_time,
+			COALESCE(t.user_id, (SELECT u._id FROM public.team_member tm
+				JOIN public."user" u ON u.email = tm.user_email
+				WHERE tm.workspace_id = t.workspace_id
+				ORDER BY tm.team_role_id = 'tr_owner' DESC, tm._id
+				LIMIT 1)) AS owner
+		FROM public.task t
+		WHERE t.is_active
+	), extra AS (
+		SELECT _id FROM (SELECT _id, row_number() OVER (PARTITION BY owner
+			ORDER BY tracked DESC, start_time DESC, _id) AS n
+			FROM running WHERE owner IS NOT NULL) r
+		WHERE r.n > 1
+	)
+	UPDATE public.task t
+		SET is_active = false, end_time = COALESCE(NULLIF(NULLIF(t.end_time, ''), '0001-01-01T00:00:00'),
+			to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS'))
+		FROM extra e
+		WHERE e._id = t._id;
+
+	UPDATE public.task t
+		SET user_id = (SELECT u._id FROM public.team_member tm
+			JOIN public."user" u ON u.email = tm.user_email
+			WHERE tm.workspace_id = t.workspace_id
+			ORDER BY tm.team_role_id = 'tr_owner' DESC, tm._id
+			LIMIT 1)
+		WHERE t.user_id IS NULL AND t.workspace_id IS NOT NULL;
+
+	CREATE INDEX IF NOT EXISTS task_workspace_id_idx ON public.task (workspace_id);`,
+	Down: `DROP INDEX IF EXISTS public.task_workspace_id_idx;
+
+	ALTER TABLE public.task DROP COLUMN IF EXISTS workspace_id;`,
+}
@@ -0,0 +1,34 @@
+package migrations
+
+// timestamptz converts the text timestamps of time entries, which were written as UTC without an
+// offset, into timestamptz. Running entries stored the zero time as end time, it becomes NULL.
+// The old layout wrote the minutes where the seconds go, so the seconds of those timestamps are a
+// copy of the minutes and are dropped. Users get a timezone that day boundaries of their entries
+// are computed in.
+var timestamptz = Migration{
+	Version: 6,
+	Name:    "timestamptz",
+	Up: `ALTER TABLE public.task
+		ALTER COLUMN start_time TYPE timestamp with time zone
+			USING (date_trunc('minute', start_time::timestamp) AT TIME ZONE 'UTC'),
+		ALTER COLUMN end_time TYPE timestamp with time zone
+			USING (date_trunc('minute', NULLIF(NULLIF(end_time, ''), '0001-01-01T00:00:00')::timestamp) AT TIME ZONE 'UTC'),
+		ALTER COLUMN date TYPE timestamp with time zone
+			USING (date::timestamp AT TIME ZONE 'UTC');
+
+	CREATE INDEX IF NOT EXISTS task_start_time_idx ON public.task (start_time);
+
+	ALTER TABLE public."user"
+		ADD COLUMN IF NOT EXISTS timezone character varying COLLATE pg_catalog."default" NOT NULL DEFAULT 'UTC';`,
+	Down: `ALTER TABLE public."user" DROP COLUMN IF EXISTS timezone;
+
+	DROP INDEX IF EXISTS public.task_start_time_idx;
+
+	ALTER TABLE public.task
+		ALTER COLUMN start_time TYPE character varying
+			USING to_char(start_time AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS'),
+		ALTER COLUMN end_time TYPE character varying
+			USING COALESCE(to_char(end_time AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS'), '0001-01-01T00:00:00'),
+		ALTER COLUMN date TYPE character varying
+			USING to_char(date AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS');`,
+}
@@ -0,0 +1,18 @@
+package migrations
+
+// projectEstimate drops the tracked values of projects, which were given by clients and never
+// updated. They are computed from the time entries on read and measured against the estimate.
+var projectEstimate = Migration{
+	Version: 7,
+	Name:    "project_estimate",
+	Up: `ALTER TABLE public.project
+		ADD COLUMN IF NOT EXISTS estimate_hours numeric NOT NULL DEFAULT 0,
+		DROP COLUMN IF EXISTS tracked_hours,
+		DROP COLUMN IF EXISTS tracked_amount,
+		DROP COLUMN IF EXISTS progress_percentage;`,
+	Down: `ALTER TABLE public.project
+		ADD COLUMN IF NOT EXISTS tracked_hours numeric NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS tracked_amount numeric NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS progress_percentage numeric NOT NULL DEFAULT 0,
+		DROP COLUMN IF EXISTS estimate_hours;`,
+}
@@ -0,0 +1,49 @@
+package migrations
+
+// projectBudgets adds a money budget and alert thresholds to projects, the estimate is their time
+// budget. Alerts are stored per recipient, the unique constraint keeps a threshold from alerting
+// twice in the same budget period.
+var projectBudgets = Migration{
+	Version: 8,
+	Name:    "project_budgets",
+	Up: `ALTER TABLE public.project
+		ADD COLUMN IF NOT EXISTS budget_amount numeric NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS budget_period character varying COLLATE pg_catalog."default" NOT NULL DEFAULT 'total',
+		ADD COLUMN IF NOT EXISTS budget_alerts character varying COLLATE pg_catalog."default" NOT NULL DEFAULT '80,100';
+
+	CREATE TABLE IF NOT EXISTS public.notification
+	(
+		_id character varying COLLATE pg_catalog."default" NOT NULL,
+		kind character varying COLLATE pg_catalog."default" NOT NULL,
+		threshold integer NOT NULL,
+		period_start timestamp with time zone NOT NULL,
+		message character varying COLLATE pg_catalog."default" NOT NULL,
+		created_at timestamp with time zone NOT NULL,
+		is_read boolean NOT NULL DEFAULT false,
+		user_id character varying COLLATE pg_catalog."default" NOT NULL,
+		workspace_id character varying COLLATE pg_catalog."default" NOT NULL,
+		project_id character varying COLLATE pg_catalog."default" NOT NULL,
+		CONSTRAINT notification_pkey PRIMARY KEY (_id),
+		CONSTRAINT notification_alert_unique UNIQUE (user_id, project_id, kind, threshold, period_start),
+		CONSTRAINT notification_user_id_fkey FOREIGN KEY (user_id)
+			REFERENCES public."user" (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE,
+		CONSTRAINT notification_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE,
+		CONSTRAINT notification_project_id_fkey FOREIGN KEY (project_id)
+			REFERENCES public.project (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS notification_user_id_idx ON public.notification (user_id, created_at);`,
+	Down: `DROP TABLE IF EXISTS public.notification;
+
+	ALTER TABLE public.project
+		DROP COLUMN IF EXISTS budget_alerts,
+		DROP COLUMN IF EXISTS budget_period,
+		DROP COLUMN IF EXISTS budget_amount;`,
+}
@@ -0,0 +1,44 @@
+package migrations
+
+// timesheetApprovals adds the approval of a user's timesheet per workspace and week, and the
+// lock approving puts on the time entries of that week
+var timesheetApprovals = Migration{
+	Version: 9,
+	Name:    "timesheet_approvals",
+	Up: `ALTER TABLE public.task
+		ADD COLUMN IF NOT EXISTS is_locked boolean NOT NULL DEFAULT false;
+
+	CREATE TABLE IF NOT EXISTS public.approval
+	(
+		_id character varying COLLATE pg_catalog."default" NOT NULL,
+		status character varying COLLATE pg_catalog."default" NOT NULL,
+		period_start timestamp with time zone NOT NULL,
+		period_end timestamp with time zone NOT NULL,
+		comment character varying COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+		submitted_at timestamp with time zone NOT NULL,
+		reviewed_at timestamp with time zone,
+		user_id character varying COLLATE pg_catalog."default" NOT NULL,
+		reviewer_id character varying COLLATE pg_catalog."default",
+		workspace_id character varying COLLATE pg_catalog."default" NOT NULL,
+		CONSTRAINT approval_pkey PRIMARY KEY (_id),
+		CONSTRAINT approval_period_unique UNIQUE (workspace_id, user_id, period_start),
+		CONSTRAINT approval_user_id_fkey FOREIGN KEY (user_id)
+			REFERENCES public."user" (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE,
+		CONSTRAINT approval_reviewer_id_fkey FOREIGN KEY (reviewer_id)
+			REFERENCES public."user" (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE NO ACTION,
+		CONSTRAINT approval_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS approval_status_idx ON public.approval (workspace_id, status);`,
+	Down: `DROP TABLE IF EXISTS public.approval;
+
+	ALTER TABLE public.task
+		DROP COLUMN IF EXISTS is_locked;`,
+}
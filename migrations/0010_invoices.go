@@ -0,0 +1,75 @@
+package migrations
+
+// invoices adds invoices with their items and a billable rate for projects, which takes precedence
+// over the rates of the team members. Time entries refer to the invoice item they were billed on,
+// removing the item makes them billable again.
+var invoices = Migration{
+	Version: 10,
+	Name:    "invoices",
+	Up: `ALTER TABLE public.project
+		ADD COLUMN IF NOT EXISTS billable_rate numeric NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS public.invoice
+	(
+		_id character varying COLLATE pg_catalog."default" NOT NULL,
+		number integer NOT NULL,
+		status character varying COLLATE pg_catalog."default" NOT NULL,
+		currency character varying COLLATE pg_catalog."default" NOT NULL,
+		issue_date timestamp with time zone NOT NULL,
+		due_date timestamp with time zone,
+		period_start timestamp with time zone NOT NULL,
+		period_end timestamp with time zone NOT NULL,
+		tax_percentage numeric NOT NULL DEFAULT 0,
+		discount_percentage numeric NOT NULL DEFAULT 0,
+		note character varying COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+		client_id character varying COLLATE pg_catalog."default" NOT NULL,
+		workspace_id character varying COLLATE pg_catalog."default" NOT NULL,
+		CONSTRAINT invoice_pkey PRIMARY KEY (_id),
+		CONSTRAINT invoice_number_unique UNIQUE (workspace_id, number),
+		CONSTRAINT invoice_client_id_fkey FOREIGN KEY (client_id)
+			REFERENCES public.client (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE NO ACTION,
+		CONSTRAINT invoice_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS public.invoice_item
+	(
+		_id character varying COLLATE pg_catalog."default" NOT NULL,
+		position integer NOT NULL,
+		description character varying COLLATE pg_catalog."default" NOT NULL,
+		quantity numeric NOT NULL,
+		unit_price numeric NOT NULL,
+		invoice_id character varying COLLATE pg_catalog."default" NOT NULL,
+		project_id character varying COLLATE pg_catalog."default",
+		CONSTRAINT invoice_item_pkey PRIMARY KEY (_id),
+		CONSTRAINT invoice_item_invoice_id_fkey FOREIGN KEY (invoice_id)
+			REFERENCES public.invoice (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE,
+		CONSTRAINT invoice_item_project_id_fkey FOREIGN KEY (project_id)
+			REFERENCES public.project (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE SET NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS invoice_item_invoice_id_idx ON public.invoice_item (invoice_id);
+
+	ALTER TABLE public.task
+		ADD COLUMN IF NOT EXISTS invoice_item_id character varying COLLATE pg_catalog."default"
+			REFERENCES public.invoice_item (_id) ON DELETE SET NULL;
+
+	CREATE INDEX IF NOT EXISTS task_invoice_item_id_idx ON public.task (invoice_item_id);`,
+	Down: `ALTER TABLE public.task
+		DROP COLUMN IF EXISTS invoice_item_id;
+
+	DROP TABLE IF EXISTS public.invoice_item;
+
+	DROP TABLE IF EXISTS public.invoice;
+
+	ALTER TABLE public.project
+		DROP COLUMN IF EXISTS billable_rate;`,
+}
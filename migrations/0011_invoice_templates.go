@@ -0,0 +1,25 @@
+package migrations
+
+// invoiceTemplates adds the template a workspace renders its invoices with, a workspace has at
+// most one
+var invoiceTemplates = Migration{
+	Version: 11,
+	Name:    "invoice_templates",
+	Up: `CREATE TABLE IF NOT EXISTS public.invoice_template
+	(
+		_id character varying COLLATE pg_catalog."default" NOT NULL,
+		title character varying COLLATE pg_catalog."default" NOT NULL,
+		accent_color character varying COLLATE pg_catalog."default" NOT NULL,
+		header character varying COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+		footer character varying COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+		logo text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+		workspace_id character varying COLLATE pg_catalog."default" NOT NULL,
+		CONSTRAINT invoice_template_pkey PRIMARY KEY (_id),
+		CONSTRAINT invoice_template_workspace_id_unique UNIQUE (workspace_id),
+		CONSTRAINT invoice_template_workspace_id_fkey FOREIGN KEY (workspace_id)
+			REFERENCES public.workspace (_id) MATCH SIMPLE
+			ON UPDATE NO ACTION
+			ON DELETE CASCADE
+	);`,
+	Down: `DROP TABLE IF EXISTS public.invoice_template;`,
+}
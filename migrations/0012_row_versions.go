@@ -0,0 +1,90 @@
+package migrations
+
+// rowVersions adds the version every row is at, updates move it on so that a change made from a
+// stale read can be refused
+var rowVersions = Migration{
+	Version: 12,
+	Name:    "row_versions",
+	Up: `ALTER TABLE public.approval
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.client
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.invoice
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.invoice_item
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.invoice_template
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.notification
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.project
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.tag
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.task
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.team_group
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.team_member
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.team_role
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public."user"
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+	ALTER TABLE public.workspace
+		ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;`,
+	Down: `ALTER TABLE public.approval
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.client
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.invoice
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.invoice_item
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.invoice_template
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.notification
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.project
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.tag
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.task
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.team_group
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.team_member
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.team_role
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public."user"
+		DROP COLUMN IF EXISTS version;
+
+	ALTER TABLE public.workspace
+		DROP COLUMN IF EXISTS version;`,
+}
@@ -0,0 +1,235 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration defines one numbered schema change and how to revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status defines the state of a migration in the database
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// all holds every migration of the schema, new migrations are appended at the end
+var all = []Migration{
+	initialSchema,
+	workspaceScoping,
+	builtinRoles,
+	taskUser,
+	taskOwner,
+	timestamptz,
+	projectEstimate,
+	projectBudgets,
+	timesheetApprovals,
+	invoices,
+	invoiceTemplates,
+	rowVersions,
+}
+
+// lockID is the advisory lock key used so only one instance migrates at a time
+const lockID = 7216549
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS public.schema_migrations
+	(
+		version integer NOT NULL,
+		name character varying COLLATE pg_catalog."default" NOT NULL,
+		applied_at timestamp with time zone NOT NULL DEFAULT now(),
+		CONSTRAINT schema_migrations_pkey PRIMARY KEY (version)
+	);`
+
+// All returns the known migrations ordered by version
+func All() []Migration {
+	migrations := make([]Migration, len(all))
+	copy(migrations, all)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+}
+
+// Up applies every pending migration in order and returns how many were applied
+func Up(ctx context.Context, db *sql.DB) (int, error) {
+	count := 0
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range All() {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			err = run(ctx, conn, m.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %v", m.Version, m.Name, err)
+			}
+
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("Up: %v", err)
+	}
+
+	return count, nil
+}
+
+// Down reverts the last steps applied migrations, newest first
+func Down(ctx context.Context, db *sql.DB, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("Down: %v", errors.New("steps must be greater than zero"))
+	}
+
+	count := 0
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		migrations := All()
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			err = run(ctx, conn, m.Down, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %v", m.Version, m.Name, err)
+			}
+
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("Down: %v", err)
+	}
+
+	return count, nil
+}
+
+// GetStatus lists every known migration and whether it has been applied
+func GetStatus(ctx context.Context, db *sql.DB) ([]*Status, error) {
+	statuses := make([]*Status, 0)
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range All() {
+			s := &Status{
+				Version: m.Version,
+				Name:    m.Name,
+			}
+
+			if appliedAt, ok := applied[m.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, s)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetStatus: %v", err)
+	}
+
+	return statuses, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	if db == nil {
+		return errors.New("database is not set up")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID)
+	if err != nil {
+		return err
+	}
+	// Unlock even when ctx is done, otherwise the lock stays with the pooled connection
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, createMigrationsTable)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		version := 0
+		appliedAt := time.Time{}
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// run executes a migration script and its bookkeeping statement in one transaction
+func run(ctx context.Context, conn *sql.Conn, script string, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, bookkeeping, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// APPROVAL_PENDING is a submitted timesheet waiting for review
+	APPROVAL_PENDING = "pending"
+	// APPROVAL_APPROVED is an approved timesheet, its time entries are locked
+	APPROVAL_APPROVED = "approved"
+	// APPROVAL_REJECTED is a timesheet sent back to its user with a comment
+	APPROVAL_REJECTED = "rejected"
+	// APPROVAL_UNLOCKED is an approved timesheet that was opened for changes again
+	APPROVAL_UNLOCKED = "unlocked"
+)
+
+// Approval defines the approval of a user's timesheet for a period, a week starting on Monday in
+// the user's time zone. There is one per user, workspace and period, resubmitting reuses it.
+type Approval struct {
+	ID          string     `json:"_id"`
+	Status      string     `json:"status"`
+	PeriodStart time.Time  `json:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end"`
+	Comment     string     `json:"comment"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+
+	User      string `json:"user_id"`
+	Reviewer  string `json:"reviewer_id"`
+	Workspace string `json:"workspace_id"`
+
+	Version int `json:"version"`
+}
+
+// SubmitApprovalRequest defines the body of a request submitting a week, the current week when
+// none is given
+type SubmitApprovalRequest struct {
+	Week string `json:"week" binding:"omitempty,week"`
+}
+
+// ReviewApprovalRequest defines the body of a request approving, rejecting or unlocking a week
+type ReviewApprovalRequest struct {
+	Comment string `json:"comment"`
+}
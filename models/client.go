@@ -7,4 +7,25 @@ type Client struct {
 	Address    string `json:"address"`
 	Note       string `json:"note"`
 	IsArchived bool   `json:"is_archived"`
+
+	Workspace string `json:"workspace_id"`
+
+	Version int `json:"version"`
+}
+
+// AddClientRequest defines the body of a request adding a client
+type AddClientRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Address    string `json:"address"`
+	Note       string `json:"note"`
+	IsArchived bool   `json:"is_archived"`
+}
+
+// UpdateClientRequest defines the body of a request changing a client, fields left out stay
+// unchanged
+type UpdateClientRequest struct {
+	Name       *string `json:"name" binding:"omitempty,min=1"`
+	Address    *string `json:"address" patch:"nullable"`
+	Note       *string `json:"note" patch:"nullable"`
+	IsArchived *bool   `json:"is_archived"`
 }
@@ -0,0 +1,36 @@
+package models
+
+const (
+	// FILTER_EQ matches rows whose column equals the value
+	FILTER_EQ = "eq"
+	// FILTER_NE matches rows whose column differs from the value or is not set
+	FILTER_NE = "ne"
+	// FILTER_GT, FILTER_GTE, FILTER_LT and FILTER_LTE compare the column with the value
+	FILTER_GT  = "gt"
+	FILTER_GTE = "gte"
+	FILTER_LT  = "lt"
+	FILTER_LTE = "lte"
+	// FILTER_IN matches rows whose column equals one of the values
+	FILTER_IN = "in"
+	// FILTER_CONTAINS matches rows whose column contains the value, ignoring case
+	FILTER_CONTAINS = "contains"
+	// FILTER_ANY matches rows linked to one of the values, e.g. time entries with one of the tags
+	FILTER_ANY = "any"
+)
+
+// Filter defines a condition on the rows of a list. A filter with Filters is a group that joins
+// them with AND, or with OR when Or is set. Any other filter compares Column with Value using
+// Operator, Value is a []string for FILTER_IN and FILTER_ANY.
+type Filter struct {
+	Column   string
+	Operator string
+	Value    interface{}
+
+	Or      bool
+	Filters []*Filter
+}
+
+// IsGroup tells whether f joins other filters instead of comparing a column
+func (f *Filter) IsGroup() bool {
+	return f.Column == ""
+}
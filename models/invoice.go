@@ -0,0 +1,141 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// INVOICE_DRAFT is an invoice that can still be changed
+	INVOICE_DRAFT = "draft"
+	// INVOICE_SENT is an invoice sent to its client
+	INVOICE_SENT = "sent"
+	// INVOICE_PAID is an invoice its client paid
+	INVOICE_PAID = "paid"
+)
+
+// Invoice defines invoice object, numbers count up per workspace. The amounts are computed from
+// the items, the discount is taken off the subtotal before tax.
+type Invoice struct {
+	ID                 string     `json:"_id"`
+	Number             int        `json:"number"`
+	Status             string     `json:"status"`
+	Currency           string     `json:"currency"`
+	IssueDate          time.Time  `json:"issue_date"`
+	DueDate            *time.Time `json:"due_date"`
+	PeriodStart        time.Time  `json:"period_start"`
+	PeriodEnd          time.Time  `json:"period_end"`
+	TaxPercentage      float64    `json:"tax_percentage"`
+	DiscountPercentage float64    `json:"discount_percentage"`
+	Note               string     `json:"note"`
+	Subtotal           float64    `json:"subtotal" db:"-"`
+	DiscountAmount     float64    `json:"discount_amount" db:"-"`
+	TaxAmount          float64    `json:"tax_amount" db:"-"`
+	Total              float64    `json:"total" db:"-"`
+
+	Client    string         `json:"client_id"`
+	Workspace string         `json:"workspace_id"`
+	Items     []*InvoiceItem `json:"items"`
+
+	Version int `json:"version"`
+}
+
+// InvoiceItem defines one line of an invoice, lines are listed by position. Items created from
+// time entries have the project the entries were tracked on and the entries refer to them.
+type InvoiceItem struct {
+	ID          string  `json:"_id"`
+	Position    int     `json:"position"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount" db:"-"`
+
+	Invoice string `json:"invoice_id"`
+	Project string `json:"project_id"`
+
+	Version int `json:"version"`
+}
+
+// SetTotals computes the amounts of i and its items, rounded to cents
+func (i *Invoice) SetTotals() {
+	i.Subtotal = 0
+	for _, item := range i.Items {
+		item.Amount = roundMoney(item.Quantity * item.UnitPrice)
+		i.Subtotal += item.Amount
+	}
+
+	i.Subtotal = roundMoney(i.Subtotal)
+	i.DiscountAmount = roundMoney(i.Subtotal * i.DiscountPercentage / 100)
+	i.TaxAmount = roundMoney((i.Subtotal - i.DiscountAmount) * i.TaxPercentage / 100)
+	i.Total = roundMoney(i.Subtotal - i.DiscountAmount + i.TaxAmount)
+}
+
+// AddInvoiceRequest defines the body of a request creating an invoice for a client from its time
+// entries of start to end. Dates are days in the caller's time zone, currency defaults to EUR.
+type AddInvoiceRequest struct {
+	Client             string  `json:"client_id" binding:"required" ref:"client"`
+	Start              string  `json:"start" binding:"required,day"`
+	End                string  `json:"end" binding:"required,day"`
+	Currency           string  `json:"currency" binding:"omitempty,len=3,alpha"`
+	IssueDate          string  `json:"issue_date" binding:"omitempty,day"`
+	DueDate            string  `json:"due_date" binding:"omitempty,day"`
+	TaxPercentage      float64 `json:"tax_percentage" binding:"gte=0,lte=100"`
+	DiscountPercentage float64 `json:"discount_percentage" binding:"gte=0,lte=100"`
+	Note               string  `json:"note"`
+}
+
+// UpdateInvoiceRequest defines the body of a request changing an invoice, fields left out stay
+// unchanged and a null or empty due_date clears it
+type UpdateInvoiceRequest struct {
+	Status             *string  `json:"status" binding:"omitempty,oneof=draft sent paid"`
+	Currency           *string  `json:"currency" binding:"omitempty,len=3,alpha"`
+	IssueDate          *string  `json:"issue_date" binding:"omitempty,min=1,day"`
+	DueDate            *string  `json:"due_date" binding:"omitempty,day" patch:"nullable"`
+	TaxPercentage      *float64 `json:"tax_percentage" binding:"omitempty,gte=0,lte=100"`
+	DiscountPercentage *float64 `json:"discount_percentage" binding:"omitempty,gte=0,lte=100"`
+	Note               *string  `json:"note" patch:"nullable"`
+}
+
+// AddInvoiceItemRequest defines the body of a request adding a line to an invoice
+type AddInvoiceItemRequest struct {
+	Description string   `json:"description" binding:"required"`
+	Quantity    *float64 `json:"quantity" binding:"required,gte=0"`
+	UnitPrice   *float64 `json:"unit_price" binding:"required,gte=0"`
+	Project     string   `json:"project_id" ref:"project"`
+}
+
+// UpdateInvoiceItemRequest defines the body of a request changing a line of an invoice, fields
+// left out stay unchanged
+type UpdateInvoiceItemRequest struct {
+	Description *string  `json:"description" binding:"omitempty,min=1"`
+	Quantity    *float64 `json:"quantity" binding:"omitempty,gte=0"`
+	UnitPrice   *float64 `json:"unit_price" binding:"omitempty,gte=0"`
+}
+
+// UpdateInvoiceTemplateRequest defines the body of a request changing the invoice template of a
+// workspace, fields left out stay unchanged
+type UpdateInvoiceTemplateRequest struct {
+	Title       *string `json:"title"`
+	AccentColor *string `json:"accent_color"`
+	Header      *string `json:"header"`
+	Footer      *string `json:"footer"`
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// InvoiceTemplate defines how the invoices of a workspace are rendered as PDF. Title, header and
+// footer are Go text/template texts filled in with the invoice, logo is a base64 encoded JPEG.
+type InvoiceTemplate struct {
+	ID          string `json:"_id"`
+	Title       string `json:"title"`
+	AccentColor string `json:"accent_color"`
+	Header      string `json:"header"`
+	Footer      string `json:"footer"`
+	Logo        string `json:"logo"`
+
+	Workspace string `json:"workspace_id"`
+
+	Version int `json:"version"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// SortField defines a column a list is ordered by
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ListOptions defines which page of a list is read. Only rows matching Filter are listed, ordered by
+// Sort and then _id. After holds the sort values and _id of the row the page starts behind, Offset
+// skips rows after that.
+type ListOptions struct {
+	Filter *Filter
+	Sort   []SortField
+	Limit  int
+	Offset int
+	After  []interface{}
+}
+
+// IDPage defines the ids of one page of a list with the number of rows of the whole list. Next
+// holds the sort values and _id of the last row of the page when more rows follow.
+type IDPage struct {
+	IDs   []string
+	Total int
+	Next  []interface{}
+}
+
+// List defines one page of a list route, next_cursor reads the page that follows and is empty on
+// the last one
+type List struct {
+	Items      interface{} `json:"items"`
+	Total      int         `json:"total"`
+	Limit      int         `json:"limit"`
+	Page       int         `json:"page,omitempty"`
+	NextCursor string      `json:"next_cursor"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// NOTIFICATION_TIME_BUDGET is sent when a project reaches a share of its estimated hours
+	NOTIFICATION_TIME_BUDGET = "time_budget"
+	// NOTIFICATION_MONEY_BUDGET is sent when a project reaches a share of its budget amount
+	NOTIFICATION_MONEY_BUDGET = "money_budget"
+)
+
+// Notification defines notification object, one is stored per recipient
+type Notification struct {
+	ID          string    `json:"_id"`
+	Kind        string    `json:"kind"`
+	Threshold   int       `json:"threshold"`
+	PeriodStart time.Time `json:"period_start"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+	IsRead      bool      `json:"is_read"`
+
+	User      string `json:"user_id"`
+	Workspace string `json:"workspace_id"`
+	Project   string `json:"project_id"`
+
+	Version int `json:"version"`
+}
+
+// UpdateNotificationRequest defines the body of a request marking a notification read or unread
+type UpdateNotificationRequest struct {
+	IsRead *bool `json:"is_read" binding:"required"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// RelationPatch changes the ids linked to a resource, such as the tags of a time entry. It is
+// given as a list of ids replacing the linked ones, or as {"add": [...], "remove": [...]} to link
+// and unlink single ids. A JSON null unlinks every id.
+type RelationPatch struct {
+	Set    []string `json:"set" binding:"omitempty,unique"`
+	Add    []string `json:"add" binding:"omitempty,unique"`
+	Remove []string `json:"remove" binding:"omitempty,unique"`
+}
+
+// errRelationPatch describes the values a RelationPatch can be read from
+var errRelationPatch = errors.New(`relations are changed with a list of ids or {"add": [...], "remove": [...]}`)
+
+func (p *RelationPatch) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		p.Set = make([]string, 0)
+		if err := json.Unmarshal(data, &p.Set); err != nil {
+			return errRelationPatch
+		}
+		return nil
+	}
+
+	// ops has the fields of RelationPatch without its UnmarshalJSON
+	type ops RelationPatch
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode((*ops)(p)); err != nil {
+		return errRelationPatch
+	}
+
+	return nil
+}
+
+// Linked returns the ids the patch links, those that have to exist
+func (p RelationPatch) Linked() []string {
+	return append(append([]string{}, p.Set...), p.Add...)
+}
+
+// Apply returns ids changed by the patch: replaced when the patch sets them, then with the added
+// ids appended and the removed ones left out
+func (p RelationPatch) Apply(ids []string) []string {
+	if p.Set != nil {
+		ids = p.Set
+	}
+
+	result := make([]string, 0, len(ids)+len(p.Add))
+	for _, id := range append(append([]string{}, ids...), p.Add...) {
+		if !containsID(result, id) && !containsID(p.Remove, id) {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
+
+func containsID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
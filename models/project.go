@@ -1,17 +1,98 @@
 package models
 
-// Project defines project object
+import (
+	"time"
+)
+
+const (
+	// BUDGET_PERIOD_TOTAL measures a budget against all time entries of the project
+	BUDGET_PERIOD_TOTAL = "total"
+	// BUDGET_PERIOD_MONTHLY measures a budget against the time entries of the current UTC month
+	BUDGET_PERIOD_MONTHLY = "monthly"
+)
+
+// Project defines project object, the tracked values and percentages are computed from its time
+// entries. The percentages measure the budget period against the estimate and the budget amount.
 type Project struct {
 	ID                 string  `json:"_id"`
 	Name               string  `json:"name"`
 	ColorTag           string  `json:"color_tag"`
 	IsPublic           bool    `json:"is_public"`
-	TrackedHours       float64 `json:"tracked_hours"`
-	TrackedAmount      float64 `json:"tracked_amount"`
-	ProgressPercentage float64 `json:"progress_percentage"`
+	BillableRate       float64 `json:"billable_rate"`
+	EstimateHours      float64 `json:"estimate_hours"`
+	BudgetAmount       float64 `json:"budget_amount"`
+	BudgetPeriod       string  `json:"budget_period"`
+	BudgetAlerts       string  `json:"budget_alerts"`
+	TrackedHours       float64 `json:"tracked_hours" db:"-"`
+	TrackedAmount      float64 `json:"tracked_amount" db:"-"`
+	ProgressPercentage float64 `json:"progress_percentage" db:"-"`
+	BudgetPercentage   float64 `json:"budget_percentage" db:"-"`
 
 	Client      string   `json:"client_id"`
 	Workspace   string   `json:"workspace_id"`
 	TeamMembers []string `json:"team_members"`
 	TeamGroups  []string `json:"team_groups"`
+
+	Version int `json:"version"`
+}
+
+// BudgetPeriodStart returns when the budget period of p that now falls in began, the zero time
+// for total budgets
+func (p *Project) BudgetPeriodStart(now time.Time) time.Time {
+	if p.BudgetPeriod != BUDGET_PERIOD_MONTHLY {
+		return time.Time{}
+	}
+
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SetTracked sets the tracked hours and amount of p and its progress against the estimate and
+// budget from what was tracked in the budget period. Percentages stay 0 without an estimate or
+// budget.
+func (p *Project) SetTracked(hours, amount, periodHours, periodAmount float64) {
+	p.TrackedHours = hours
+	p.TrackedAmount = amount
+
+	p.ProgressPercentage = 0
+	if p.EstimateHours > 0 {
+		p.ProgressPercentage = periodHours / p.EstimateHours * 100
+	}
+
+	p.BudgetPercentage = 0
+	if p.BudgetAmount > 0 {
+		p.BudgetPercentage = periodAmount / p.BudgetAmount * 100
+	}
+}
+
+// AddProjectRequest defines the body of a request adding a project. budget_period defaults to
+// total and budget_alerts to 80,100, an empty budget_alerts turns the alerts off.
+type AddProjectRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	ColorTag      string   `json:"color_tag"`
+	IsPublic      bool     `json:"is_public"`
+	BillableRate  float64  `json:"billable_rate" binding:"gte=0"`
+	EstimateHours float64  `json:"estimate_hours" binding:"gte=0"`
+	BudgetAmount  float64  `json:"budget_amount" binding:"gte=0"`
+	BudgetPeriod  string   `json:"budget_period" binding:"omitempty,oneof=total monthly"`
+	BudgetAlerts  *string  `json:"budget_alerts" binding:"omitempty,budget_alerts"`
+	Client        string   `json:"client_id" ref:"client"`
+	TeamMembers   []string `json:"team_members" binding:"omitempty,unique" ref:"team_member"`
+	TeamGroups    []string `json:"team_groups" binding:"omitempty,unique" ref:"team_group"`
+}
+
+// UpdateProjectRequest defines the body of a request changing a project, fields left out stay
+// unchanged
+type UpdateProjectRequest struct {
+	Name          *string        `json:"name" binding:"omitempty,min=1"`
+	ColorTag      *string        `json:"color_tag" patch:"nullable"`
+	IsPublic      *bool          `json:"is_public"`
+	BillableRate  *float64       `json:"billable_rate" binding:"omitempty,gte=0" patch:"nullable"`
+	EstimateHours *float64       `json:"estimate_hours" binding:"omitempty,gte=0" patch:"nullable"`
+	BudgetAmount  *float64       `json:"budget_amount" binding:"omitempty,gte=0" patch:"nullable"`
+	BudgetPeriod  *string        `json:"budget_period" binding:"omitempty,oneof=total monthly"`
+	BudgetAlerts  *string        `json:"budget_alerts" binding:"omitempty,budget_alerts" patch:"nullable"`
+	Client        *string        `json:"client_id" ref:"client" patch:"nullable"`
+	TeamMembers   *RelationPatch `json:"team_members" ref:"team_member"`
+	TeamGroups    *RelationPatch `json:"team_groups" ref:"team_group"`
 }
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// REPORT_GROUP_PROJECT groups a report by the project of the time entries
+	REPORT_GROUP_PROJECT = "project"
+	// REPORT_GROUP_CLIENT groups a report by the client of the projects
+	REPORT_GROUP_CLIENT = "client"
+	// REPORT_GROUP_USER groups a report by the user who tracked the time entries
+	REPORT_GROUP_USER = "user"
+	// REPORT_GROUP_TAG groups a report by tag, an entry with several tags counts in each of them
+	REPORT_GROUP_TAG = "tag"
+)
+
+// ReportFilter defines the finished time entries of a workspace a report covers, entries starting
+// from Start up to but not including End. Filter holds conditions on the time entries like those
+// of the task list. Empty fields do not filter.
+type ReportFilter struct {
+	Workspace string
+	Start     time.Time
+	End       time.Time
+	Client    string
+	User      string
+	Billable  *bool
+	Invoiced  *bool
+	Filter    *Filter
+}
+
+// SummaryReport defines the totals of the time entries matching a filter and their groups
+type SummaryReport struct {
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	GroupBy []string       `json:"group_by"`
+	Hours   float64        `json:"hours"`
+	Amount  float64        `json:"amount"`
+	Groups  []*ReportGroup `json:"groups"`
+}
+
+// ReportGroup defines the totals of one group of a summary report, an empty ID groups the entries
+// without a project, client or tag
+type ReportGroup struct {
+	ID     string         `json:"_id"`
+	Name   string         `json:"name"`
+	Hours  float64        `json:"hours"`
+	Amount float64        `json:"amount"`
+	Groups []*ReportGroup `json:"groups,omitempty"`
+}
+
+// DetailedReport defines one page of the time entries matching a filter, Total counts all of them
+// and Hours and Amount sum all of them
+type DetailedReport struct {
+	Start    time.Time        `json:"start"`
+	End      time.Time        `json:"end"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Total    int              `json:"total"`
+	Hours    float64          `json:"hours"`
+	Amount   float64          `json:"amount"`
+	Entries  []*DetailedEntry `json:"entries"`
+}
+
+// DetailedEntry defines a time entry of a detailed report with the names of what it belongs to,
+// billable entries are charged at the billable rate of their project or, when the project has
+// none, of the team member who tracked them
+type DetailedEntry struct {
+	ID          string    `json:"_id"`
+	Description string    `json:"description"`
+	ProjectID   string    `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	ClientID    string    `json:"client_id"`
+	ClientName  string    `json:"client_name"`
+	UserID      string    `json:"user_id"`
+	UserName    string    `json:"user_name"`
+	Tags        []string  `json:"tags"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Hours       float64   `json:"hours"`
+	Billable    bool      `json:"billable"`
+	Rate        float64   `json:"billable_rate"`
+	Amount      float64   `json:"amount"`
+}
+
+// SetAmount sets the hours of e from its start and end and what they are charged
+func (e *DetailedEntry) SetAmount() {
+	e.Hours = e.EndTime.Sub(e.StartTime).Hours()
+	e.Amount = 0
+	if e.Billable {
+		e.Amount = e.Hours * e.Rate
+	}
+}
@@ -4,4 +4,18 @@ package models
 type Tag struct {
 	ID   string `json:"_id"`
 	Name string `json:"name"`
+
+	Workspace string `json:"workspace_id"`
+
+	Version int `json:"version"`
+}
+
+// AddTagRequest defines the body of a request adding a tag
+type AddTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateTagRequest defines the body of a request changing a tag, fields left out stay unchanged
+type UpdateTagRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1"`
 }
@@ -6,14 +6,56 @@ import (
 
 // Task defines task object
 type Task struct {
-	ID          string    `json:"_id"`
-	Description string    `json:"description"`
-	Billable    bool      `json:"billable"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	Date        time.Time `json:"date"`
-	IsActive    bool      `json:"is_active"`
+	ID          string     `json:"_id"`
+	Description string     `json:"description"`
+	Billable    bool       `json:"billable"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	Date        time.Time  `json:"date"`
+	IsActive    bool       `json:"is_active"`
+	IsLocked    bool       `json:"is_locked"`
 
-	Project string   `json:"project_id"`
-	Tags    []string `json:"tags"`
+	Project     string   `json:"project_id"`
+	User        string   `json:"user_id"`
+	Workspace   string   `json:"workspace_id"`
+	InvoiceItem string   `json:"invoice_item_id"`
+	Tags        []string `json:"tags"`
+
+	Version int `json:"version"`
+}
+
+// AddTaskRequest defines the body of a request adding a time entry. Times are RFC 3339
+// timestamps, date defaults to the day start_time falls on in the caller's time zone.
+type AddTaskRequest struct {
+	Description string   `json:"description"`
+	Billable    bool     `json:"billable"`
+	StartTime   string   `json:"start_time" binding:"required,timestamp"`
+	EndTime     string   `json:"end_time" binding:"omitempty,timestamp"`
+	Date        string   `json:"date" binding:"omitempty,day"`
+	IsActive    bool     `json:"is_active"`
+	Project     string   `json:"project_id" ref:"project"`
+	Tags        []string `json:"tags" binding:"omitempty,unique" ref:"tag"`
+}
+
+// UpdateTaskRequest defines the body of a request changing a time entry, fields left out stay
+// unchanged and a null or empty end_time clears it
+type UpdateTaskRequest struct {
+	Description *string        `json:"description" patch:"nullable"`
+	Billable    *bool          `json:"billable"`
+	StartTime   *string        `json:"start_time" binding:"omitempty,min=1,timestamp"`
+	EndTime     *string        `json:"end_time" binding:"omitempty,timestamp" patch:"nullable"`
+	Date        *string        `json:"date" binding:"omitempty,min=1,day"`
+	IsActive    *bool          `json:"is_active"`
+	Project     *string        `json:"project_id" ref:"project" patch:"nullable"`
+	Tags        *RelationPatch `json:"tags" ref:"tag"`
+}
+
+// StartTimerRequest defines the body of a request starting the timer, the workspace is taken from
+// the project when only project_id is given
+type StartTimerRequest struct {
+	Description string   `json:"description"`
+	Billable    bool     `json:"billable"`
+	Project     string   `json:"project_id"`
+	Workspace   string   `json:"workspace_id"`
+	Tags        []string `json:"tags" binding:"omitempty,unique"`
 }
@@ -7,4 +7,19 @@ type TeamGroup struct {
 
 	Workspace   string   `json:"workspace_id"`
 	TeamMembers []string `json:"team_members"`
+
+	Version int `json:"version"`
+}
+
+// AddTeamGroupRequest defines the body of a request adding a team group
+type AddTeamGroupRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	TeamMembers []string `json:"team_members" binding:"omitempty,unique" ref:"team_member"`
+}
+
+// UpdateTeamGroupRequest defines the body of a request changing a team group, fields left out
+// stay unchanged
+type UpdateTeamGroupRequest struct {
+	Name        *string        `json:"name" binding:"omitempty,min=1"`
+	TeamMembers *RelationPatch `json:"team_members" ref:"team_member"`
 }
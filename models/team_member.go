@@ -9,4 +9,22 @@ type TeamMember struct {
 	User       string   `json:"user_email"`
 	TeamRole   string   `json:"team_role_id"`
 	TeamGroups []string `json:"team_groups"`
+
+	Version int `json:"version"`
+}
+
+// AddTeamMemberRequest defines the body of a request adding a team member, the role defaults to
+// tr_member
+type AddTeamMemberRequest struct {
+	BillableRate float64 `json:"billable_rate" binding:"gte=0"`
+	User         string  `json:"user_email" binding:"required,email"`
+	TeamRole     string  `json:"team_role_id"`
+}
+
+// UpdateTeamMemberRequest defines the body of a request changing a team member, fields left out
+// stay unchanged
+type UpdateTeamMemberRequest struct {
+	BillableRate *float64       `json:"billable_rate" binding:"omitempty,gte=0" patch:"nullable"`
+	TeamRole     *string        `json:"team_role_id" binding:"omitempty,min=1"`
+	TeamGroups   *RelationPatch `json:"team_groups" ref:"team_group"`
 }
@@ -4,4 +4,8 @@ package models
 type TeamRole struct {
 	ID   string `json:"_id"`
 	Role string `json:"role"`
+
+	Permissions []string `json:"permissions"`
+
+	Version int `json:"version"`
 }
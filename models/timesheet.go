@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Timesheet is the hours a user tracked in a week of the workspace as a grid, one row per
+// project and one column per day starting on Monday. Days are in the user's time zone.
+type Timesheet struct {
+	Week   string          `json:"week"`
+	User   string          `json:"user_id"`
+	Start  time.Time       `json:"start"`
+	Days   []string        `json:"days"`
+	Rows   []*TimesheetRow `json:"rows"`
+	Totals []float64       `json:"totals"`
+	Hours  float64         `json:"hours"`
+}
+
+// TimesheetRow is the hours tracked on a project for each day of a timesheet, entries without a
+// project have an empty project_id
+type TimesheetRow struct {
+	Project     string    `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Hours       []float64 `json:"hours"`
+	Total       float64   `json:"total"`
+}
+
+// TimesheetEdit sets cells of a timesheet, rows that are not given stay unchanged
+type TimesheetEdit struct {
+	Rows []*TimesheetEditRow `json:"rows" binding:"required,dive,required"`
+}
+
+// TimesheetEditRow sets the hours of a project for each day of the week, at most 24 a day.
+// Billable applies to the time entries created for it.
+type TimesheetEditRow struct {
+	Project  string    `json:"project_id" ref:"project"`
+	Hours    []float64 `json:"hours" binding:"len=7,dive,gte=0,lte=24"`
+	Billable bool      `json:"billable"`
+}
@@ -7,4 +7,58 @@ type User struct {
 	Email    string `json:"email"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Timezone string `json:"timezone"`
+
+	Version int `json:"version"`
+}
+
+// PublicUser defines the user object returned by the API, it never carries the password
+type PublicUser struct {
+	ID       string `json:"_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Timezone string `json:"timezone"`
+
+	Version int `json:"version"`
+}
+
+// Public returns the API representation of u
+func (u *User) Public() *PublicUser {
+	return &PublicUser{
+		ID:       u.ID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Username: u.Username,
+		Timezone: u.Timezone,
+		Version:  u.Version,
+	}
+}
+
+// PublicUsers returns the API representation of users
+func PublicUsers(users []*User) []*PublicUser {
+	publicUsers := make([]*PublicUser, 0, len(users))
+	for _, u := range users {
+		publicUsers = append(publicUsers, u.Public())
+	}
+
+	return publicUsers
+}
+
+// SignUpRequest defines the body of a request signing up a user
+type SignUpRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,password"`
+	Timezone string `json:"timezone" binding:"omitempty,timezone"`
+}
+
+// UpdateUserRequest defines the body of a request changing a user, fields left out stay unchanged.
+// The email can not be changed, team members and the auth token refer to the user by it.
+type UpdateUserRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=1"`
+	Username *string `json:"username" binding:"omitempty,min=1"`
+	Password *string `json:"password" binding:"omitempty,min=1,password"`
+	Timezone *string `json:"timezone" binding:"omitempty,min=1,timezone"`
 }
@@ -4,4 +4,17 @@ package models
 type Workspace struct {
 	ID   string `json:"_id"`
 	Name string `json:"name"`
+
+	Version int `json:"version"`
+}
+
+// AddWorkspaceRequest defines the body of a request adding a workspace
+type AddWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateWorkspaceRequest defines the body of a request changing a workspace, fields left out stay
+// unchanged
+type UpdateWorkspaceRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1"`
 }
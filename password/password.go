@@ -0,0 +1,188 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/qasim-sajid/clockify-api/conf"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// bcrypt ignores everything after 72 bytes, longer passwords are refused instead
+	maxLength = 72
+
+	argon2Memory  = 64 * 1024
+	argon2Threads = 2
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+
+	defaultArgon2Time = 3
+)
+
+// Hash hashes plain with the configured algorithm and cost
+func Hash(plain string) (string, error) {
+	switch algorithm() {
+	case conf.PASSWORD_HASH_ARGON2ID:
+		return hashArgon2id(plain, argon2Time())
+	default:
+		hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcryptCost())
+		if err != nil {
+			return "", fmt.Errorf("Hash: %v", err)
+		}
+
+		return string(hash), nil
+	}
+}
+
+// Verify reports whether plain matches stored, and whether stored should be replaced by a
+// fresh Hash of plain because it is plaintext or uses another algorithm or cost than configured
+func Verify(stored, plain string) (match bool, needsRehash bool, err error) {
+	switch {
+	case isBcrypt(stored):
+		err = bcrypt.CompareHashAndPassword([]byte(stored), []byte(plain))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		} else if err != nil {
+			return false, false, fmt.Errorf("Verify: %v", err)
+		}
+
+		cost, err := bcrypt.Cost([]byte(stored))
+		if err != nil {
+			return false, false, fmt.Errorf("Verify: %v", err)
+		}
+
+		return true, algorithm() != conf.PASSWORD_HASH_BCRYPT || cost != bcryptCost(), nil
+	case strings.HasPrefix(stored, "$argon2id$"):
+		match, time, err := verifyArgon2id(stored, plain)
+		if err != nil || !match {
+			return false, false, err
+		}
+
+		return true, algorithm() != conf.PASSWORD_HASH_ARGON2ID || time != argon2Time(), nil
+	default:
+		// Rows written before passwords were hashed hold the plaintext
+		match = stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(plain)) == 1
+
+		return match, match, nil
+	}
+}
+
+// IsHashed reports whether s is the output of Hash rather than a plaintext password
+func IsHashed(s string) bool {
+	return isBcrypt(s) || strings.HasPrefix(s, "$argon2id$")
+}
+
+// Validate checks plain against the password policy
+func Validate(plain string) error {
+	minLength := 8
+	if conf.Configs != nil && conf.Configs.PasswordMinLength > 0 {
+		minLength = conf.Configs.PasswordMinLength
+	}
+
+	if len([]rune(plain)) < minLength {
+		return fmt.Errorf("password must be at least %d characters long", minLength)
+	}
+
+	if len(plain) > maxLength {
+		return fmt.Errorf("password must be at most %d bytes long", maxLength)
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, r := range plain {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		} else if unicode.IsDigit(r) {
+			hasDigit = true
+		}
+	}
+
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain at least one letter and one digit")
+	}
+
+	return nil
+}
+
+func isBcrypt(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+func algorithm() string {
+	if conf.Configs == nil || conf.Configs.PasswordHashAlgorithm == "" {
+		return conf.PASSWORD_HASH_BCRYPT
+	}
+
+	return conf.Configs.PasswordHashAlgorithm
+}
+
+func bcryptCost() int {
+	if conf.Configs == nil || conf.Configs.PasswordHashCost <= 0 {
+		return bcrypt.DefaultCost
+	}
+
+	return conf.Configs.PasswordHashCost
+}
+
+func argon2Time() uint32 {
+	if conf.Configs == nil || conf.Configs.PasswordHashCost <= 0 {
+		return defaultArgon2Time
+	}
+
+	return uint32(conf.Configs.PasswordHashCost)
+}
+
+// hashArgon2id encodes the hash in the usual PHC format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func hashArgon2id(plain string, time uint32) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("Hash: %v", err)
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func verifyArgon2id(stored, plain string) (bool, uint32, error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, 0, errors.New("Verify: malformed argon2id hash")
+	}
+
+	version := 0
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return false, 0, errors.New("Verify: unsupported argon2id version")
+	}
+
+	var memory, time uint32
+	var threads uint8
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads)
+	if err != nil {
+		return false, 0, fmt.Errorf("Verify: %v", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, 0, fmt.Errorf("Verify: %v", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, 0, fmt.Errorf("Verify: %v", err)
+	}
+
+	other := argon2.IDKey([]byte(plain), salt, time, memory, threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, time, nil
+}
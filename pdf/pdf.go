@@ -0,0 +1,317 @@
+// Package pdf writes simple PDF documents of text, lines, rectangles and JPEG images. It only uses
+// the standard Helvetica fonts every PDF reader has, so no font files are embedded.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// A4_WIDTH and A4_HEIGHT are the size of an A4 page in points
+	A4_WIDTH  = 595.28
+	A4_HEIGHT = 841.89
+)
+
+// Document defines a PDF being built page by page, coordinates are in points from the bottom
+// left corner of the page
+type Document struct {
+	width  float64
+	height float64
+	pages  []*bytes.Buffer
+	images []*Image
+}
+
+// Image defines a JPEG image that can be drawn on the pages of a document, its data is embedded
+// as it is
+type Image struct {
+	width      int
+	height     int
+	colorSpace string
+	data       []byte
+}
+
+// NewJPEG returns the JPEG image in data
+func NewJPEG(data []byte) (*Image, error) {
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("NewJPEG: %v", err)
+	}
+
+	img := &Image{width: config.Width, height: config.Height, data: data}
+	switch config.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		img.colorSpace = "/DeviceCMYK"
+	default:
+		img.colorSpace = "/DeviceRGB"
+	}
+
+	return img, nil
+}
+
+// Size returns the width and height of img in pixels
+func (img *Image) Size() (int, int) {
+	return img.width, img.height
+}
+
+// New returns an empty document with pages of the given size
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// Width returns the width of the pages of d
+func (d *Document) Width() float64 {
+	return d.width
+}
+
+// Height returns the height of the pages of d
+func (d *Document) Height() float64 {
+	return d.height
+}
+
+// AddPage starts a new page, everything drawn afterwards goes on it
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws text with its baseline starting at x, y
+func (d *Document) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	fmt.Fprintf(d.page(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, number(size), number(x), number(y),
+		escape(encode(text)))
+}
+
+// TextRight draws text so it ends at x
+func (d *Document) TextRight(x, y, size float64, bold bool, text string) {
+	d.Text(x-TextWidth(text, size, bold), y, size, bold, text)
+}
+
+// SetColor sets the color of text and fills, r, g and b are between 0 and 1
+func (d *Document) SetColor(r, g, b float64) {
+	fmt.Fprintf(d.page(), "%s %s %s rg\n", number(r), number(g), number(b))
+}
+
+// SetStrokeColor sets the color of lines, r, g and b are between 0 and 1
+func (d *Document) SetStrokeColor(r, g, b float64) {
+	fmt.Fprintf(d.page(), "%s %s %s RG\n", number(r), number(g), number(b))
+}
+
+// Image draws img scaled to width and height with its bottom left corner at x, y
+func (d *Document) Image(img *Image, x, y, width, height float64) {
+	index := -1
+	for i, other := range d.images {
+		if other == img {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		d.images = append(d.images, img)
+		index = len(d.images) - 1
+	}
+
+	fmt.Fprintf(d.page(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n", number(width), number(height), number(x), number(y),
+		index+1)
+}
+
+// Line draws a line of the given width from x1, y1 to x2, y2
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s m %s %s l S\n", number(width), number(x1), number(y1), number(x2), number(y2))
+}
+
+// Rect fills a rectangle with its bottom left corner at x, y
+func (d *Document) Rect(x, y, width, height float64) {
+	fmt.Fprintf(d.page(), "%s %s %s %s re f\n", number(x), number(y), number(width), number(height))
+}
+
+// WriteTo writes the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	buf := &bytes.Buffer{}
+	offsets := make([]int, 0)
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are the catalog, the page tree and the fonts, each page is followed by its
+	// content stream and the images come last
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+
+	xObjects := ""
+	if len(d.images) > 0 {
+		names := make([]string, 0, len(d.images))
+		for i := range d.images {
+			names = append(names, fmt.Sprintf("/Im%d %d 0 R", i+1, 5+2*len(d.pages)+i))
+		}
+		xObjects = fmt.Sprintf(" /XObject << %s >>", strings.Join(names, " "))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >>%s >> /Contents %d 0 R >>",
+			number(d.width), number(d.height), xObjects, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.Len(), p.String()))
+	}
+
+	for _, img := range d.images {
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s "+
+			"/BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream", img.width, img.height,
+			img.colorSpace, len(img.data), img.data))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// ParseColor parses a color written as #rrggbb into its red, green and blue between 0 and 1
+func ParseColor(hex string) (float64, float64, float64, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return 0, 0, 0, errors.New("expected a color such as #1f6feb")
+	}
+
+	rgb := make([]float64, 3)
+	for i := range rgb {
+		v, err := strconv.ParseUint(hex[1+2*i:3+2*i], 16, 8)
+		if err != nil {
+			return 0, 0, 0, errors.New("expected a color such as #1f6feb")
+		}
+		rgb[i] = float64(v) / 255
+	}
+
+	return rgb[0], rgb[1], rgb[2], nil
+}
+
+// Truncate shortens text with an ellipsis so it fits in width
+func Truncate(text string, width, size float64, bold bool) string {
+	if TextWidth(text, size, bold) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if t := string(runes) + "..."; TextWidth(t, size, bold) <= width {
+			return t
+		}
+	}
+
+	return ""
+}
+
+// TextWidth returns the width of text in points
+func TextWidth(text string, size float64, bold bool) float64 {
+	widths := helveticaWidths
+	if bold {
+		widths = helveticaBoldWidths
+	}
+
+	total := 0
+	for _, c := range encode(text) {
+		if c >= 32 && int(c-32) < len(widths) {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+
+	return float64(total) * size / 1000
+}
+
+// encode converts text to WinAnsiEncoding, characters it does not have become ?
+func encode(text string) []byte {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '€':
+			b = append(b, 0x80)
+		case r >= 32 && r < 127, r >= 160 && r <= 255:
+			b = append(b, byte(r))
+		default:
+			b = append(b, '?')
+		}
+	}
+
+	return b
+}
+
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+func number(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+
+	return s
+}
+
+// Widths of the characters from space to ~ in thousandths of the font size
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = []int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,96 @@
+package rbac
+
+// Permission names an action a team member may take in a workspace
+type Permission string
+
+const (
+	WORKSPACE_EDIT   Permission = "workspace:edit"
+	WORKSPACE_DELETE Permission = "workspace:delete"
+	MEMBER_MANAGE    Permission = "member:manage"
+	CLIENT_EDIT      Permission = "client:edit"
+	PROJECT_EDIT     Permission = "project:edit"
+	TAG_EDIT         Permission = "tag:edit"
+	TIME_EDIT_OTHERS Permission = "time:edit_others"
+	REPORT_VIEW_ALL  Permission = "report:view_all"
+	TIME_APPROVE     Permission = "time:approve"
+	TIME_UNLOCK      Permission = "time:unlock"
+	INVOICE_MANAGE   Permission = "invoice:manage"
+)
+
+// IDs of the built-in team roles, the rows are created by migration
+const (
+	ROLE_OWNER           = "tr_owner"
+	ROLE_ADMIN           = "tr_admin"
+	ROLE_PROJECT_MANAGER = "tr_project_manager"
+	ROLE_MEMBER          = "tr_member"
+)
+
+// Role defines a built-in team role and what it grants
+type Role struct {
+	ID          string
+	Name        string
+	Permissions []Permission
+}
+
+var roles = []Role{
+	{
+		ID:   ROLE_OWNER,
+		Name: "owner",
+		Permissions: []Permission{WORKSPACE_EDIT, WORKSPACE_DELETE, MEMBER_MANAGE, CLIENT_EDIT, PROJECT_EDIT, TAG_EDIT,
+			TIME_EDIT_OTHERS, REPORT_VIEW_ALL, TIME_APPROVE, TIME_UNLOCK, INVOICE_MANAGE},
+	},
+	{
+		ID:   ROLE_ADMIN,
+		Name: "admin",
+		Permissions: []Permission{WORKSPACE_EDIT, MEMBER_MANAGE, CLIENT_EDIT, PROJECT_EDIT, TAG_EDIT, TIME_EDIT_OTHERS,
+			REPORT_VIEW_ALL, TIME_APPROVE, TIME_UNLOCK, INVOICE_MANAGE},
+	},
+	{
+		ID:          ROLE_PROJECT_MANAGER,
+		Name:        "project manager",
+		Permissions: []Permission{CLIENT_EDIT, PROJECT_EDIT, TAG_EDIT, TIME_EDIT_OTHERS, REPORT_VIEW_ALL, TIME_APPROVE},
+	},
+	{
+		ID:          ROLE_MEMBER,
+		Name:        "member",
+		Permissions: []Permission{},
+	},
+}
+
+// Roles returns the built-in roles, from most to least privileged
+func Roles() []Role {
+	return roles
+}
+
+// GetRole returns the built-in role with the given id
+func GetRole(roleID string) (Role, bool) {
+	for _, r := range roles {
+		if r.ID == roleID {
+			return r, true
+		}
+	}
+
+	return Role{}, false
+}
+
+// Permissions returns what a team member with roleID may do. Members without a known role
+// get the permissions of ROLE_MEMBER.
+func Permissions(roleID string) []Permission {
+	r, ok := GetRole(roleID)
+	if !ok {
+		r, _ = GetRole(ROLE_MEMBER)
+	}
+
+	return r.Permissions
+}
+
+// HasPermission reports whether roleID grants p
+func HasPermission(roleID string, p Permission) bool {
+	for _, permission := range Permissions(roleID) {
+		if permission == p {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/rbac"
+)
+
+func TestPermissionDenials(t *testing.T) {
+	s := newTestServer(t)
+
+	owner := s.signUp("owner")
+	admin := s.signUp("admin")
+	manager := s.signUp("manager")
+	member := s.signUp("member")
+	outsider := s.signUp("outsider")
+
+	workspace := s.addWorkspace(owner)
+	s.addMember(owner, workspace, admin, rbac.ROLE_ADMIN)
+	s.addMember(owner, workspace, manager, rbac.ROLE_PROJECT_MANAGER)
+	s.addMember(owner, workspace, member, rbac.ROLE_MEMBER)
+
+	taskID := s.do(owner, http.MethodPost, workspace+"/task", gin.H{"description": "Design",
+		"start_time": "2022-03-01T09:00:00Z", "end_time": "2022-03-01T10:00:00Z"}, nil).expect(http.StatusOK).addedID()
+
+	tests := []struct {
+		name   string
+		user   *testUser
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{
+			name:   "without a token",
+			method: http.MethodGet,
+			path:   "/workspaces",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "outside the workspace",
+			user:   outsider,
+			method: http.MethodGet,
+			path:   workspace,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "outsider adding a time entry",
+			user:   outsider,
+			method: http.MethodPost,
+			path:   workspace + "/task",
+			body:   gin.H{"start_time": "2022-03-01T11:00:00Z"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "member adding a project",
+			user:   member,
+			method: http.MethodPost,
+			path:   workspace + "/project",
+			body:   gin.H{"name": "Alpha"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "member adding a client",
+			user:   member,
+			method: http.MethodPost,
+			path:   workspace + "/client",
+			body:   gin.H{"name": "Acme"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "member adding a team member",
+			user:   member,
+			method: http.MethodPost,
+			path:   workspace + "/team_member",
+			body:   gin.H{"user_email": outsider.Email},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "member listing invoices",
+			user:   member,
+			method: http.MethodGet,
+			path:   workspace + "/invoices",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "member changing the time entry of another",
+			user:   member,
+			method: http.MethodPatch,
+			path:   workspace + "/tasks/" + taskID,
+			body:   gin.H{"description": "Build"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "member reporting on another",
+			user:   member,
+			method: http.MethodGet,
+			path:   workspace + "/reports/summary?start=2022-03-01&end=2022-03-31&user_id=" + owner.ID,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "project manager managing invoices",
+			user:   manager,
+			method: http.MethodGet,
+			path:   workspace + "/invoices",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "project manager adding a project",
+			user:   manager,
+			method: http.MethodPost,
+			path:   workspace + "/project",
+			body:   gin.H{"name": "Alpha"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "admin deleting the workspace",
+			user:   admin,
+			method: http.MethodDelete,
+			path:   workspace,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "admin making an owner",
+			user:   admin,
+			method: http.MethodPost,
+			path:   workspace + "/team_member",
+			body:   gin.H{"user_email": outsider.Email, "team_role_id": rbac.ROLE_OWNER},
+			want:   http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.in(t).do(tt.user, tt.method, tt.path, tt.body, ifMatch(1)).expect(tt.want)
+		})
+	}
+}
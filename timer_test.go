@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qasim-sajid/clockify-api/models"
+)
+
+func TestTimer(t *testing.T) {
+	s := newTestServer(t)
+
+	user := s.signUp("ann")
+	workspace := s.addWorkspace(user)
+	workspaceID := strings.TrimPrefix(workspace, "/workspaces/")
+
+	s.do(user, http.MethodGet, "/timer/current", nil, nil).expect(http.StatusNotFound)
+	s.do(user, http.MethodPost, "/timer/stop", nil, nil).expect(http.StatusNotFound)
+
+	first := &models.Task{}
+	s.do(user, http.MethodPost, "/timer/start", gin.H{"description": "Design", "workspace_id": workspaceID}, nil).
+		expect(http.StatusOK).decode(first)
+	if !first.IsActive || first.EndTime != nil {
+		t.Fatalf("got a started entry %+v, want it running", first)
+	}
+
+	current := &models.Task{}
+	s.do(user, http.MethodGet, "/timer/current", nil, nil).expect(http.StatusOK).decode(current)
+	if current.ID != first.ID {
+		t.Fatalf("got the running entry %s, want %s", current.ID, first.ID)
+	}
+
+	// Starting again stops the running entry first
+	second := &models.Task{}
+	s.do(user, http.MethodPost, "/timer/start", gin.H{"description": "Build", "workspace_id": workspaceID}, nil).
+		expect(http.StatusOK).decode(second)
+
+	stopped := &models.Task{}
+	s.do(user, http.MethodGet, workspace+"/tasks/"+first.ID, nil, nil).expect(http.StatusOK).decode(stopped)
+	if stopped.IsActive || stopped.EndTime == nil {
+		t.Fatalf("got the first entry %+v, want it stopped", stopped)
+	}
+
+	// Resuming a finished entry stops the running one as well
+	resumed := &models.Task{}
+	s.do(user, http.MethodPatch, workspace+"/tasks/"+first.ID, gin.H{"is_active": true, "end_time": nil},
+		ifMatch(stopped.Version)).expect(http.StatusOK).decode(resumed)
+	if !resumed.IsActive || resumed.EndTime != nil {
+		t.Fatalf("got the resumed entry %+v, want it running", resumed)
+	}
+
+	s.do(user, http.MethodGet, workspace+"/tasks/"+second.ID, nil, nil).expect(http.StatusOK).decode(stopped)
+	if stopped.IsActive || stopped.EndTime == nil {
+		t.Fatalf("got the second entry %+v, want it stopped", stopped)
+	}
+
+	s.do(user, http.MethodPost, "/timer/stop", nil, nil).expect(http.StatusOK).decode(stopped)
+	if stopped.ID != first.ID || stopped.IsActive || stopped.EndTime == nil {
+		t.Fatalf("got the stopped entry %+v, want %s stopped", stopped, first.ID)
+	}
+
+	s.do(user, http.MethodGet, "/timer/current", nil, nil).expect(http.StatusNotFound)
+}